@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// accountPurger periodically hard-deletes accounts whose deletion grace
+// period has ended, and forgets failed logins that no longer count
+type accountPurger struct {
+	userService   domain.UserService
+	loginThrottle domain.LoginThrottleService
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+// startAccountPurger purges expired accounts every interval until Close is called
+func startAccountPurger(userService domain.UserService, loginThrottle domain.LoginThrottleService, interval time.Duration) *accountPurger {
+	p := &accountPurger{
+		userService:   userService,
+		loginThrottle: loginThrottle,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.purge()
+			}
+		}
+	}()
+	return p
+}
+
+func (p *accountPurger) purge() {
+	purged, err := p.userService.PurgeDeletedUsers(context.Background())
+	if err != nil {
+		log.Printf("Failed to purge deleted accounts: %v", err)
+	} else if purged > 0 {
+		log.Printf("Purged %d deleted account(s)", purged)
+	}
+
+	if _, err := p.loginThrottle.PruneStale(context.Background()); err != nil {
+		log.Printf("Failed to prune failed logins: %v", err)
+	}
+}
+
+// Close stops the purger and waits for a running purge to finish
+func (p *accountPurger) Close() error {
+	close(p.stop)
+	<-p.done
+	return nil
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// purgeCountingService counts PurgeDeletedUsers calls; other methods are not used
+type purgeCountingService struct {
+	domain.UserService
+	calls atomic.Int32
+}
+
+func (s *purgeCountingService) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	s.calls.Add(1)
+	return 0, nil
+}
+
+// pruneCountingThrottle counts PruneStale calls; other methods are not used
+type pruneCountingThrottle struct {
+	domain.LoginThrottleService
+	calls atomic.Int32
+}
+
+func (s *pruneCountingThrottle) PruneStale(ctx context.Context) (int, error) {
+	s.calls.Add(1)
+	return 0, nil
+}
+
+func TestAccountPurger_PurgesUntilClosed(t *testing.T) {
+	service := &purgeCountingService{}
+	throttle := &pruneCountingThrottle{}
+	purger := startAccountPurger(service, throttle, 5*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for service.calls.Load() < 2 || throttle.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the purger to run periodically")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	purger.Close()
+	calls := service.calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if service.calls.Load() != calls {
+		t.Error("Expected no purges after Close")
+	}
+}
@@ -1,7 +1,12 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
 
 	"hello-world/internal/domain"
 	"hello-world/internal/infrastructure"
@@ -12,52 +17,409 @@ import (
 
 // Container holds all the application dependencies
 type Container struct {
-	Config      *config.Config
-	Database    *sql.DB
-	UserRepo    domain.UserRepository
-	AuthService domain.AuthService
-	UserService domain.UserService
-	Router      *interfaces.Router
+	Config              *config.Config
+	Database            *sql.DB
+	UserRepo            domain.UserRepository
+	RefreshTokenRepo    domain.RefreshTokenRepository
+	RevocationStore     domain.TokenRevocationStore
+	KeyService          domain.SigningKeyService
+	AuthService         domain.AuthService
+	PasswordHasher      domain.PasswordHasher
+	UserService         domain.UserService
+	TokenService        domain.TokenService
+	MFAService          domain.MFAService
+	PasswordService     domain.PasswordResetService
+	VerificationService domain.EmailVerificationService
+	EmailChangeService  domain.EmailChangeService
+	PhoneService        domain.PhoneVerificationService
+	LoginThrottle       domain.LoginThrottleService
+	Mailer              domain.Mailer
+	SMSSender           domain.SMSSender
+	Router              *interfaces.Router
+
+	purger  *accountPurger
+	mailLog io.Closer
+	smsLog  io.Closer
 }
 
 // NewContainer creates and wires all dependencies
 func NewContainer() (*Container, error) {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if cfg.Phone.DefaultRegion != "" && !domain.IsPhoneRegion(cfg.Phone.DefaultRegion) {
+		return nil, fmt.Errorf("invalid configuration: unknown PHONE_DEFAULT_REGION %q", cfg.Phone.DefaultRegion)
+	}
 
-	// Initialize infrastructure layer
-	db, err := infrastructure.NewDatabase(infrastructure.DatabaseConfig{
-		Driver: cfg.Database.Driver,
-		DSN:    cfg.Database.DSN,
+	trustedProxies, err := cfg.Server.ParseTrustedProxies()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	// Load the token signing key before opening anything that needs closing
+	signingKey, err := configuredSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := passwordPolicy(cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := infrastructure.NewPasswordHasher(infrastructure.PasswordHashConfig{
+		Algorithm:  cfg.Password.HashAlgorithm,
+		BcryptCost: cfg.Password.BcryptCost,
+		Argon2id: infrastructure.Argon2idParams{
+			Memory:      uint32(cfg.Password.Argon2Memory),
+			Iterations:  uint32(cfg.Password.Argon2Iterations),
+			Parallelism: uint8(cfg.Password.Argon2Parallelism),
+		},
 	})
 	if err != nil {
+		return nil, fmt.Errorf("invalid password hashing configuration: %w", err)
+	}
+
+	// Initialize infrastructure layer and repositories (adapters)
+	storage, err := newStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The configured key seeds the key ring; rotated keys live in the store
+	jwt := jwtConfig(cfg)
+	keyRing, err := infrastructure.NewKeyRing(context.Background(), storage.signingKeyStore, signingKey, jwt.KeyRetention())
+	if err != nil {
+		storage.close()
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	if storage.db != nil {
+		keyRing.StartReloader(cfg.JWT.KeyReloadInterval)
+	}
+
+	smsSender, smsLog, err := newSMSSender(cfg.SMS)
+	if err != nil {
+		keyRing.Close()
+		storage.close()
 		return nil, err
 	}
 
-	// Initialize repositories (adapters)
-	userRepo := infrastructure.NewSQLiteUserRepository(db)
+	mailer, mailLog, err := newMailer(cfg.Mail)
+	if err != nil {
+		if smsLog != nil {
+			smsLog.Close()
+		}
+		keyRing.Close()
+		storage.close()
+		return nil, err
+	}
 
 	// Initialize services (adapters)
-	authService := infrastructure.NewJWTAuthService()
+	jwt.KeyRing = keyRing
+	authService := infrastructure.NewJWTAuthService(jwt)
 
 	// Initialize use cases (application layer)
-	userService := usecase.NewUserUseCase(userRepo, authService)
+	userService := usecase.NewUserUseCase(storage.userRepo, storage.mfaRepo, storage.refreshTokenRepo, authService, hasher, usecase.UserConfig{
+		DeletionGracePeriod:  cfg.Account.DeletionGracePeriod,
+		RequireVerifiedEmail: cfg.Account.EmailVerification == config.EmailVerificationLogin,
+		PasswordPolicy:       policy,
+		PhoneRegion:          cfg.Phone.DefaultRegion,
+	})
+	tokenService := usecase.NewTokenUseCase(storage.userRepo, storage.refreshTokenRepo, storage.revocationStore, authService, usecase.TokenConfig{
+		AccessTTL:  cfg.JWT.AccessTTL,
+		RefreshTTL: cfg.JWT.RefreshTTL,
+	})
+	passwordService := usecase.NewPasswordResetUseCase(storage.userRepo, storage.passwordResetRepo, hasher, tokenService, mailer, usecase.PasswordResetConfig{
+		TokenTTL:       cfg.Account.PasswordResetTTL,
+		ResetURL:       cfg.Account.PasswordResetURL,
+		PasswordPolicy: policy,
+	})
+	verificationService := usecase.NewEmailVerificationUseCase(storage.userRepo, authService, mailer, usecase.EmailVerificationConfig{
+		VerifyURL:      cfg.Account.EmailVerificationURL,
+		ResendInterval: cfg.Account.EmailVerificationResendInterval,
+		TokenTTL:       cfg.Account.EmailVerificationTTL,
+	})
+	emailChangeService := usecase.NewEmailChangeUseCase(storage.userRepo, hasher, authService, tokenService, mailer, usecase.EmailChangeConfig{
+		ConfirmURL:     cfg.Account.EmailChangeURL,
+		RevertURL:      cfg.Account.EmailRevertURL,
+		ResendInterval: cfg.Account.EmailVerificationResendInterval,
+		ConfirmTTL:     cfg.Account.EmailVerificationTTL,
+		RevertTTL:      cfg.Account.EmailRevertTTL,
+	})
+	phoneService := usecase.NewPhoneVerificationUseCase(storage.userRepo, storage.phoneVerificationRepo, smsSender, usecase.PhoneVerificationConfig{
+		DefaultRegion: cfg.Phone.DefaultRegion,
+		CodeTTL:       cfg.Phone.CodeTTL,
+		MaxAttempts:   cfg.Phone.CodeMaxAttempts,
+		MaxSends:      cfg.Phone.MaxSends,
+		SendWindow:    cfg.Phone.SendWindow,
+	})
+	loginThrottle := usecase.NewLoginThrottleUseCase(storage.loginAttemptRepo, storage.userRepo, usecase.LoginThrottleConfig{
+		MaxFailures:             cfg.Login.MaxFailures,
+		IPMaxFailures:           cfg.Login.IPMaxFailures,
+		LockoutDuration:         cfg.Login.Lockout,
+		MaxLockoutDuration:      cfg.Login.MaxLockout,
+		FailureWindow:           cfg.Login.FailureWindow,
+		MFAChallengeMaxFailures: cfg.MFA.ChallengeMaxFailures,
+	})
+	mfaService := usecase.NewMFAUseCase(storage.userRepo, storage.mfaRepo, authService, infrastructure.NewTOTPProvider(cfg.MFA.Issuer), storage.revocationStore, loginThrottle)
 
 	// Initialize interface layer
-	router := interfaces.NewRouter(userService, authService)
+	router := interfaces.NewRouter(userService, authService, tokenService, mfaService, passwordService, verificationService, emailChangeService, phoneService, loginThrottle, storage.revocationStore, keyRing, interfaces.RouterConfig{
+		RestrictUnverified: cfg.Account.EmailVerification == config.EmailVerificationRestrict,
+		MinimumAge:         cfg.Account.MinimumAge,
+		TrustedProxies:     trustedProxies,
+	})
 
 	return &Container{
-		Config:      cfg,
-		Database:    db,
-		UserRepo:    userRepo,
-		AuthService: authService,
-		UserService: userService,
-		Router:      router,
+		Config:              cfg,
+		Database:            storage.db,
+		UserRepo:            storage.userRepo,
+		RefreshTokenRepo:    storage.refreshTokenRepo,
+		RevocationStore:     storage.revocationStore,
+		KeyService:          keyRing,
+		AuthService:         authService,
+		PasswordHasher:      hasher,
+		UserService:         userService,
+		TokenService:        tokenService,
+		MFAService:          mfaService,
+		PasswordService:     passwordService,
+		VerificationService: verificationService,
+		EmailChangeService:  emailChangeService,
+		PhoneService:        phoneService,
+		LoginThrottle:       loginThrottle,
+		Mailer:              mailer,
+		SMSSender:           smsSender,
+		Router:              router,
+		purger:              startAccountPurger(userService, loginThrottle, cfg.Account.PurgeInterval),
+		mailLog:             mailLog,
+		smsLog:              smsLog,
+	}, nil
+}
+
+// newMailer creates the mailer chosen by MAIL_DRIVER. Emails are sent in the
+// background, so requests neither wait for the mail server nor reveal
+// delivery failures. The returned closer, if any, is the mail log file.
+func newMailer(cfg config.MailConfig) (*infrastructure.AsyncMailer, io.Closer, error) {
+	switch cfg.Driver {
+	case infrastructure.MailDriverSMTP:
+		return infrastructure.NewAsyncMailer(infrastructure.NewSMTPMailer(infrastructure.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})), nil, nil
+	case infrastructure.MailDriverLog, "":
+		if cfg.LogFile == "" {
+			return infrastructure.NewAsyncMailer(infrastructure.NewLogMailer(log.Writer())), nil, nil
+		}
+		file, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open mail log file: %w", err)
+		}
+		return infrastructure.NewAsyncMailer(infrastructure.NewLogMailer(file)), file, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported mail driver %q", cfg.Driver)
+	}
+}
+
+// newSMSSender creates the SMS sender chosen by SMS_DRIVER. The returned
+// closer, if any, is the SMS log file.
+func newSMSSender(cfg config.SMSConfig) (domain.SMSSender, io.Closer, error) {
+	switch cfg.Driver {
+	case infrastructure.SMSDriverWebhook:
+		return infrastructure.NewWebhookSMSSender(infrastructure.WebhookSMSConfig{
+			URL:   cfg.WebhookURL,
+			Token: cfg.WebhookToken,
+		}), nil, nil
+	case infrastructure.SMSDriverLog, "":
+		if cfg.LogFile == "" {
+			return infrastructure.NewLogSMSSender(log.Writer()), nil, nil
+		}
+		file, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open SMS log file: %w", err)
+		}
+		return infrastructure.NewLogSMSSender(file), file, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported SMS driver %q", cfg.Driver)
+	}
+}
+
+// passwordPolicy builds the configured password policy, loading the list of
+// banned passwords if there is one
+func passwordPolicy(cfg config.PasswordConfig) (domain.PasswordPolicy, error) {
+	policy := domain.PasswordPolicy{
+		MinLength:            cfg.MinLength,
+		MaxLength:            cfg.MaxLength,
+		RequireUppercase:     cfg.RequireUppercase,
+		RequireLowercase:     cfg.RequireLowercase,
+		RequireDigit:         cfg.RequireDigit,
+		RequireSymbol:        cfg.RequireSymbol,
+		DisallowPersonalInfo: cfg.DisallowPersonalInfo,
+	}
+	if cfg.BannedListFile != "" {
+		banned, err := infrastructure.LoadPasswordListFile(cfg.BannedListFile)
+		if err != nil {
+			return policy, fmt.Errorf("failed to load banned passwords: %w", err)
+		}
+		policy.Banned = banned
+	}
+	return policy, nil
+}
+
+// configuredSigningKey returns the key configured with JWT_PRIVATE_KEY_FILE,
+// or else the HS256 key derived from JWT_SECRET
+func configuredSigningKey(cfg *config.Config) (*infrastructure.SigningKey, error) {
+	if cfg.JWT.PrivateKeyFile == "" {
+		return infrastructure.NewHMACSigningKey([]byte(cfg.JWT.Secret)), nil
+	}
+	key, err := infrastructure.LoadSigningKeyFile(cfg.JWT.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT signing key: %w", err)
+	}
+	return key, nil
+}
+
+// jwtConfig returns the settings of the tokens the auth service signs,
+// without the key ring
+func jwtConfig(cfg *config.Config) infrastructure.JWTConfig {
+	return infrastructure.JWTConfig{
+		Issuer:               cfg.JWT.Issuer,
+		Audience:             cfg.JWT.Audience,
+		AccessTTL:            cfg.JWT.AccessTTL,
+		ClockSkew:            cfg.JWT.ClockSkew,
+		MFAChallengeTTL:      cfg.MFA.ChallengeTTL,
+		EmailVerificationTTL: cfg.Account.EmailVerificationTTL,
+		EmailRevertTTL:       cfg.Account.EmailRevertTTL,
+	}
+}
+
+// storage groups the persistence adapters chosen by the database driver
+type storage struct {
+	db                    *sql.DB
+	userRepo              domain.UserRepository
+	refreshTokenRepo      domain.RefreshTokenRepository
+	mfaRepo               domain.MFARepository
+	passwordResetRepo     domain.PasswordResetRepository
+	phoneVerificationRepo domain.PhoneVerificationRepository
+	loginAttemptRepo      domain.LoginAttemptRepository
+	revocationStore       domain.TokenRevocationStore
+	signingKeyStore       infrastructure.SigningKeyStore
+}
+
+// close releases the storage when the container cannot be completed
+func (s *storage) close() {
+	if closer, ok := s.revocationStore.(io.Closer); ok {
+		closer.Close()
+	}
+	if s.db != nil {
+		s.db.Close()
+	}
+}
+
+// newStorage opens the configured database and creates its repositories.
+// The memory driver needs no database and keeps all data in process memory.
+func newStorage(cfg *config.Config) (*storage, error) {
+	if cfg.Database.Driver == infrastructure.DriverMemory {
+		return &storage{
+			userRepo:              infrastructure.NewMemoryUserRepository(),
+			refreshTokenRepo:      infrastructure.NewMemoryRefreshTokenRepository(),
+			mfaRepo:               infrastructure.NewMemoryMFARepository(),
+			passwordResetRepo:     infrastructure.NewMemoryPasswordResetRepository(),
+			phoneVerificationRepo: infrastructure.NewMemoryPhoneVerificationRepository(),
+			loginAttemptRepo:      infrastructure.NewMemoryLoginAttemptRepository(),
+			revocationStore:       infrastructure.NewMemoryTokenRevocationStore(),
+			signingKeyStore:       infrastructure.NewMemorySigningKeyStore(),
+		}, nil
+	}
+
+	db, err := infrastructure.NewDatabase(infrastructure.DatabaseConfig{
+		Driver:         cfg.Database.Driver,
+		DSN:            cfg.Database.DSN,
+		SkipMigrations: !cfg.Database.AutoMigrate,
+		PhoneRegion:    cfg.Phone.DefaultRegion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userRepo, refreshTokenRepo, err := newRepositories(cfg.Database.Driver, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	signingKeyStore, err := newSigningKeyStore(cfg, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	revocationStore, err := infrastructure.NewSQLTokenRevocationStore(db, cfg.Database.Driver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	revocationStore.StartPruner(cfg.JWT.RevocationPruneInterval)
+
+	return &storage{
+		db:                    db,
+		userRepo:              userRepo,
+		refreshTokenRepo:      refreshTokenRepo,
+		mfaRepo:               infrastructure.NewSQLMFARepository(db, cfg.Database.Driver),
+		passwordResetRepo:     infrastructure.NewSQLPasswordResetRepository(db, cfg.Database.Driver),
+		phoneVerificationRepo: infrastructure.NewSQLPhoneVerificationRepository(db, cfg.Database.Driver),
+		loginAttemptRepo:      infrastructure.NewSQLLoginAttemptRepository(db, cfg.Database.Driver),
+		revocationStore:       revocationStore,
+		signingKeyStore:       signingKeyStore,
 	}, nil
 }
 
+// newSigningKeyStore stores the signing key ring in db, encrypted with the
+// configured key encryption key
+func newSigningKeyStore(cfg *config.Config, db *sql.DB) (*infrastructure.SQLSigningKeyStore, error) {
+	keyEncryptionKey, err := cfg.JWT.DecodeKeyEncryptionKey()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return infrastructure.NewSQLSigningKeyStore(db, cfg.Database.Driver, keyEncryptionKey)
+}
+
+// newRepositories picks the repository implementations for the database driver
+func newRepositories(driver string, db *sql.DB) (domain.UserRepository, domain.RefreshTokenRepository, error) {
+	switch driver {
+	case infrastructure.DriverSQLite:
+		return infrastructure.NewSQLiteUserRepository(db), infrastructure.NewSQLiteRefreshTokenRepository(db), nil
+	case infrastructure.DriverPostgres:
+		return infrastructure.NewPostgresUserRepository(db), infrastructure.NewPostgresRefreshTokenRepository(db), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
 // Close cleans up resources
 func (c *Container) Close() error {
+	if c.purger != nil {
+		c.purger.Close()
+	}
+	// Deliver the queued emails before closing the log they may be written to
+	if closer, ok := c.Mailer.(io.Closer); ok {
+		closer.Close()
+	}
+	if c.mailLog != nil {
+		c.mailLog.Close()
+	}
+	if c.smsLog != nil {
+		c.smsLog.Close()
+	}
+	if closer, ok := c.RevocationStore.(io.Closer); ok {
+		closer.Close()
+	}
+	if closer, ok := c.KeyService.(io.Closer); ok {
+		closer.Close()
+	}
 	if c.Database != nil {
 		return c.Database.Close()
 	}
@@ -1,11 +1,24 @@
 package app
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"hello-world/internal/domain"
+	"hello-world/internal/infrastructure"
 )
 
+func TestMain(m *testing.M) {
+	// The tests run with the default JWT secret, which only development allows
+	os.Setenv("APP_ENV", "development")
+	os.Exit(m.Run())
+}
+
 func TestNewContainer(t *testing.T) {
 	container, err := NewContainer()
 	if err != nil {
@@ -100,3 +113,126 @@ func TestContainer_ConfigValidation(t *testing.T) {
 		t.Error("Expected database DSN to be configured")
 	}
 }
+
+func TestNewRepositories_UnsupportedDriver(t *testing.T) {
+	if _, _, err := newRepositories("mysql", nil); err == nil {
+		t.Error("Expected error for unsupported driver")
+	}
+}
+
+func TestNewContainer_MemoryDriver(t *testing.T) {
+	originalDriver := os.Getenv("DB_DRIVER")
+	os.Setenv("DB_DRIVER", "memory")
+	defer os.Setenv("DB_DRIVER", originalDriver)
+
+	container, err := NewContainer()
+	if err != nil {
+		t.Fatalf("Failed to create container: %v", err)
+	}
+	defer container.Close()
+
+	if container.Database != nil {
+		t.Error("Expected no database for the memory driver")
+	}
+	if _, ok := container.UserRepo.(*infrastructure.MemoryUserRepository); !ok {
+		t.Errorf("Expected in-memory user repository, got %T", container.UserRepo)
+	}
+}
+
+// setDeliveryEnv configures the mail and SMS drivers required outside development
+func setDeliveryEnv(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMS_DRIVER", "webhook")
+	t.Setenv("SMS_WEBHOOK_URL", "https://sms.example.com/send")
+}
+
+func TestNewContainer_RejectsUnsafeJWTSecret(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("DB_DRIVER", "memory")
+	setDeliveryEnv(t)
+
+	for _, secret := range []string{"", "short-secret"} {
+		t.Setenv("JWT_SECRET", secret)
+		if container, err := NewContainer(); err == nil {
+			container.Close()
+			t.Errorf("Expected secret %q to be rejected outside development", secret)
+		}
+	}
+
+	t.Setenv("JWT_SECRET", "a-production-secret-of-32-bytes!")
+	container, err := NewContainer()
+	if err != nil {
+		t.Fatalf("Expected a long secret to be accepted, got %v", err)
+	}
+	container.Close()
+}
+
+func TestNewContainer_PrivateKeyFile(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	// No JWT_SECRET is needed in production when signing with a private key
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("DB_DRIVER", "memory")
+	setDeliveryEnv(t)
+	t.Setenv("JWT_PRIVATE_KEY_FILE", path)
+
+	container, err := NewContainer()
+	if err != nil {
+		t.Fatalf("Failed to create container: %v", err)
+	}
+	defer container.Close()
+
+	keys := container.AuthService.PublicKeys()
+	if len(keys) != 1 || keys[0].Algorithm != "EdDSA" {
+		t.Errorf("Expected the Ed25519 key to be published, got %+v", keys)
+	}
+
+	t.Setenv("JWT_PRIVATE_KEY_FILE", filepath.Join(t.TempDir(), "missing.pem"))
+	if _, err := NewContainer(); err == nil {
+		t.Error("Expected an error for a missing key file")
+	}
+}
+
+func TestNewContainer_Phone(t *testing.T) {
+	t.Setenv("DB_DRIVER", "memory")
+
+	t.Setenv("PHONE_DEFAULT_REGION", "XX")
+	if container, err := NewContainer(); err == nil {
+		container.Close()
+		t.Error("Expected an unknown phone region to be rejected")
+	}
+
+	t.Setenv("PHONE_DEFAULT_REGION", "th")
+	t.Setenv("SMS_DRIVER", "carrier-pigeon")
+	if container, err := NewContainer(); err == nil {
+		container.Close()
+		t.Error("Expected an unsupported SMS driver to be rejected")
+	}
+
+	t.Setenv("SMS_DRIVER", "log")
+	t.Setenv("SMS_LOG_FILE", filepath.Join(t.TempDir(), "sms.log"))
+	container, err := NewContainer()
+	if err != nil {
+		t.Fatalf("Failed to create container: %v", err)
+	}
+	defer container.Close()
+
+	if _, ok := container.SMSSender.(*infrastructure.LogSMSSender); !ok {
+		t.Errorf("Expected the log SMS sender, got %T", container.SMSSender)
+	}
+	if container.PhoneService == nil {
+		t.Error("Expected phone verification service to be initialized")
+	}
+}
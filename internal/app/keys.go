@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/infrastructure"
+	"hello-world/pkg/config"
+)
+
+const keysUsage = `usage: keys <command>
+
+commands:
+  list        list the active signing key and the retired keys still verifying tokens
+  rotate      generate a new active signing key and retire the current one`
+
+// RunKeys runs the "keys" CLI subcommand against the key ring in the
+// configured database. Running servers pick up a rotation on their next
+// reload, or as soon as they see a token signed with the new key.
+func RunKeys(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if cfg.Database.Driver == infrastructure.DriverMemory {
+		return errors.New("the memory driver keeps signing keys in the server process; use POST /admin/keys/rotate")
+	}
+
+	signingKey, err := configuredSigningKey(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := infrastructure.NewDatabase(infrastructure.DatabaseConfig{
+		Driver:         cfg.Database.Driver,
+		DSN:            cfg.Database.DSN,
+		SkipMigrations: !cfg.Database.AutoMigrate,
+		PhoneRegion:    cfg.Phone.DefaultRegion,
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	store, err := newSigningKeyStore(cfg, db)
+	if err != nil {
+		return err
+	}
+	keyRing, err := infrastructure.NewKeyRing(ctx, store, signingKey, jwtConfig(cfg).KeyRetention())
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		keys, err := keyRing.ListSigningKeys(ctx)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Fprintf(out, "%s\t%s\t%s\n", key.ID, key.Algorithm, keyState(key))
+		}
+
+	case "rotate":
+		key, err := keyRing.RotateSigningKey(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Activated signing key %s (%s)\n", key.ID, key.Algorithm)
+
+	default:
+		return errors.New(keysUsage)
+	}
+
+	return nil
+}
+
+// keyState describes whether a key signs new tokens or until when it verifies old ones
+func keyState(key domain.SigningKeyInfo) string {
+	if key.Active {
+		return "active since " + key.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if key.ExpiresAt == nil {
+		return "retired"
+	}
+	return "retired, expires " + key.ExpiresAt.UTC().Format(time.RFC3339)
+}
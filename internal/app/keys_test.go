@@ -0,0 +1,59 @@
+package app
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunKeys(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite3")
+	t.Setenv("DB_DSN", filepath.Join(t.TempDir(), "keys.db"))
+
+	var out bytes.Buffer
+	if err := RunKeys([]string{"list"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], "HS256\tactive since") {
+		t.Fatalf("Expected the configured key to be active, got %q", out.String())
+	}
+	configuredID := strings.Split(lines[0], "\t")[0]
+
+	out.Reset()
+	if err := RunKeys([]string{"rotate"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Activated signing key ") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	if err := RunKeys([]string{"list"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "active since") {
+		t.Fatalf("Expected the rotated key followed by the retired one, got %q", out.String())
+	}
+	if !strings.HasPrefix(lines[1], configuredID+"\tHS256\tretired, expires ") {
+		t.Errorf("Expected the configured key to be retired, got %q", lines[1])
+	}
+}
+
+func TestRunKeys_InvalidArguments(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite3")
+	t.Setenv("DB_DSN", filepath.Join(t.TempDir(), "keys.db"))
+
+	for _, args := range [][]string{nil, {"shuffle"}} {
+		if err := RunKeys(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected error for arguments %v", args)
+		}
+	}
+
+	t.Setenv("DB_DRIVER", "memory")
+	if err := RunKeys([]string{"rotate"}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected the memory driver to be rejected")
+	}
+}
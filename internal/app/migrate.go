@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/infrastructure"
+	"hello-world/pkg/config"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [n]    roll back the last n migrations (default 1)
+  status      list migrations and whether they are applied
+  unlock      release a lock left behind by a crashed migration`
+
+// RunMigrate runs the "migrate" CLI subcommand against the configured database
+func RunMigrate(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	cfg := config.Load()
+	if cfg.Database.Driver == infrastructure.DriverMemory {
+		return errors.New("the memory driver has no schema to migrate")
+	}
+	if cfg.Phone.DefaultRegion != "" && !domain.IsPhoneRegion(cfg.Phone.DefaultRegion) {
+		return fmt.Errorf("unknown PHONE_DEFAULT_REGION %q", cfg.Phone.DefaultRegion)
+	}
+
+	db, err := infrastructure.OpenDatabase(infrastructure.DatabaseConfig{
+		Driver: cfg.Database.Driver,
+		DSN:    cfg.Database.DSN,
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := infrastructure.NewMigrator(db, cfg.Database.Driver)
+	if err != nil {
+		return err
+	}
+	migrator.PhoneRegion = cfg.Phone.DefaultRegion
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Rolled back %d migration(s)\n", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Settings != "" {
+				state += " (" + status.Settings + ")"
+			}
+			fmt.Fprintf(out, "%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+
+	case "unlock":
+		if err := migrator.ForceUnlock(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Migration lock released")
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
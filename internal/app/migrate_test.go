@@ -0,0 +1,55 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMigrate(t *testing.T) {
+	originalDSN := os.Getenv("DB_DSN")
+	defer os.Setenv("DB_DSN", originalDSN)
+	os.Setenv("DB_DSN", filepath.Join(t.TempDir(), "migrate.db"))
+
+	var out bytes.Buffer
+	if err := RunMigrate([]string{"status"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "0001_create_users\tpending") {
+		t.Errorf("Expected pending migrations in status, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := RunMigrate([]string{"up"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.HasPrefix(out.String(), "Applied 0") {
+		t.Errorf("Expected migrations to be applied, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := RunMigrate([]string{"down", "1"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "Rolled back 1 migration(s)\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	if err := RunMigrate([]string{"unlock"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRunMigrate_InvalidArguments(t *testing.T) {
+	originalDSN := os.Getenv("DB_DSN")
+	defer os.Setenv("DB_DSN", originalDSN)
+	os.Setenv("DB_DSN", filepath.Join(t.TempDir(), "migrate.db"))
+
+	for _, args := range [][]string{nil, {"sideways"}, {"down", "zero"}} {
+		if err := RunMigrate(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected error for arguments %v", args)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"hello-world/pkg/config"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	server := NewHTTPServer(config.ServerConfig{
+		Host:              "127.0.0.1",
+		Port:              "8080",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+	}, http.NotFoundHandler())
+
+	if server.Addr != "127.0.0.1:8080" {
+		t.Errorf("Expected address 127.0.0.1:8080, got %s", server.Addr)
+	}
+	if server.ReadTimeout != time.Second || server.ReadHeaderTimeout != 2*time.Second ||
+		server.WriteTimeout != 3*time.Second || server.IdleTimeout != 4*time.Second {
+		t.Errorf("Expected configured timeouts, got %+v", server)
+	}
+}
+
+// startTestServer serves handler on a random port until the returned cancel
+// function is called; the server's result is sent on the returned channel
+func startTestServer(t *testing.T, handler http.Handler, shutdownTimeout time.Duration) (string, context.CancelFunc, <-chan error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, &http.Server{Handler: handler}, listener, shutdownTimeout)
+	}()
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	url, cancel, done := startTestServer(t, handler, time.Second)
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Errorf("Expected the in-flight request to finish, got %v", err)
+			close(responses)
+			return
+		}
+		resp.Body.Close()
+		responses <- resp
+	}()
+
+	<-started
+	cancel()
+
+	if resp, ok := <-responses; ok && resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	if _, err := http.Get(url); err == nil {
+		t.Error("Expected new connections to be refused after shutdown")
+	}
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	url, cancel, done := startTestServer(t, handler, 50*time.Millisecond)
+
+	go http.Get(url)
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.DeadlineExceeded {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected shutdown to give up after the timeout")
+	}
+}
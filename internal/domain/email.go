@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidEmailAddress is returned for email addresses that cannot be normalized
+var ErrInvalidEmailAddress = DomainError{Code: "INVALID_EMAIL_ADDRESS", Message: "Invalid email address"}
+
+// NormalizeEmail returns the form email addresses are stored in: surrounding
+// spaces trimmed and the domain lowercased and converted to ASCII, so an
+// internationalized domain is stored in its punycode form. The local part
+// keeps its case; accounts are told apart by EmailKey, which ignores it.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return "", ErrInvalidEmail
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", ErrInvalidEmailAddress
+	}
+	domain, err := idna.Lookup.ToASCII(email[at+1:])
+	if err != nil {
+		return "", ErrInvalidEmailAddress
+	}
+	return email[:at] + "@" + domain, nil
+}
+
+// EmailKey returns the key that identifies the account of an email address:
+// the normalized address in lower case, so that addresses differing only in
+// case or in the spelling of the domain belong to the same account.
+// Addresses that cannot be normalized are only trimmed and lowercased.
+func EmailKey(email string) string {
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		normalized = strings.TrimSpace(email)
+	}
+	return strings.ToLower(normalized)
+}
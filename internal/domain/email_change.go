@@ -0,0 +1,27 @@
+package domain
+
+import "context"
+
+// EmailChangeService defines the use case interface for changing the email
+// address users log in with. The new address only replaces the current one
+// once the user has proven they receive mail there.
+type EmailChangeService interface {
+	// RequestEmailChange checks the password and stores the new address as
+	// pending. A confirmation link is emailed to the new address and a
+	// notice with a revert link to the current one. It returns
+	// ErrVerificationThrottled if a link was sent too recently.
+	RequestEmailChange(ctx context.Context, userID int, password, newEmail string) error
+	// ConfirmEmailChange switches the email to the pending address in the
+	// link's token. It returns ErrUserAlreadyExists if another account has
+	// taken the address since the change was requested.
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+	// RevertEmailChange restores the address the revert link was sent to,
+	// drops any pending change and signs the user out everywhere
+	RevertEmailChange(ctx context.Context, token string) (*User, error)
+}
+
+// Email change errors
+var (
+	ErrInvalidEmailChangeToken = DomainError{Code: "INVALID_EMAIL_CHANGE_TOKEN", Message: "Invalid or expired email change link"}
+	ErrEmailUnchanged          = DomainError{Code: "EMAIL_UNCHANGED", Message: "The new email address is the current one"}
+)
@@ -0,0 +1,52 @@
+package domain
+
+import "testing"
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		expected string
+		err      error
+	}{
+		{name: "Already normalized", email: "bob@example.com", expected: "bob@example.com"},
+		{name: "Surrounding spaces", email: "  bob@example.com\t", expected: "bob@example.com"},
+		{name: "Domain is lowercased, local part is kept", email: "Bob.Smith@Example.COM", expected: "Bob.Smith@example.com"},
+		{name: "Internationalized domain", email: "info@Bücher.example", expected: "info@xn--bcher-kva.example"},
+		{name: "Punycode domain", email: "info@XN--BCHER-KVA.example", expected: "info@xn--bcher-kva.example"},
+		{name: "Quoted local part with @", email: `"a@b"@example.com`, expected: `"a@b"@example.com`},
+		{name: "Empty", email: "   ", err: ErrInvalidEmail},
+		{name: "No @", email: "bob.example.com", err: ErrInvalidEmailAddress},
+		{name: "No local part", email: "@example.com", err: ErrInvalidEmailAddress},
+		{name: "No domain", email: "bob@", err: ErrInvalidEmailAddress},
+		{name: "Invalid domain", email: "bob@exa mple.com", err: ErrInvalidEmailAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := NormalizeEmail(tt.email)
+			if err != tt.err {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if normalized != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestEmailKey(t *testing.T) {
+	same := []string{"Bob@Example.com", "bob@example.com", " BOB@EXAMPLE.COM "}
+	for _, email := range same {
+		if key := EmailKey(email); key != "bob@example.com" {
+			t.Errorf("Expected %q to have key bob@example.com, got %q", email, key)
+		}
+	}
+
+	if EmailKey("info@Bücher.example") != EmailKey("INFO@xn--bcher-kva.example") {
+		t.Error("Expected an internationalized domain and its punycode form to have the same key")
+	}
+	if key := EmailKey(" Not An Email "); key != "not an email" {
+		t.Errorf("Expected an invalid address to be trimmed and lowercased, got %q", key)
+	}
+}
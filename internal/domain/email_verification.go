@@ -0,0 +1,24 @@
+package domain
+
+import "context"
+
+// EmailVerificationService defines the use case interface for verifying
+// that users own their email address
+type EmailVerificationService interface {
+	// SendVerification emails a verification link to the user. It returns
+	// ErrVerificationThrottled if a link was sent too recently.
+	SendVerification(ctx context.Context, userID int) error
+	// ResendVerification emails a new link to the unverified account using
+	// the email, if any. It succeeds either way, so callers cannot tell
+	// whether the email is registered.
+	ResendVerification(ctx context.Context, email string) error
+	// VerifyEmail marks the email address in the link's token as verified
+	VerifyEmail(ctx context.Context, token string) (*User, error)
+}
+
+// Email verification errors
+var (
+	ErrEmailNotVerified         = DomainError{Code: "EMAIL_NOT_VERIFIED", Message: "Email address has not been verified"}
+	ErrInvalidVerificationToken = DomainError{Code: "INVALID_VERIFICATION_TOKEN", Message: "Invalid or expired email verification link"}
+	ErrVerificationThrottled    = DomainError{Code: "VERIFICATION_THROTTLED", Message: "A verification email was sent recently, please wait before asking for another"}
+)
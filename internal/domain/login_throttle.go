@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempts counts the recent failed logins for one key, which stands
+// for either an account or a client IP address
+type LoginAttempts struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	// LockedUntil is set while logins for the key are refused
+	LockedUntil *time.Time
+}
+
+// IsLocked reports whether logins for the key are refused at the given time
+func (a *LoginAttempts) IsLocked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
+
+// LoginAttemptRepository defines the contract for failed login persistence
+type LoginAttemptRepository interface {
+	// Get returns the attempts for the key, or nil if there are none
+	Get(ctx context.Context, key string) (*LoginAttempts, error)
+	// RecordFailure counts a failed login at failedAt and returns the
+	// updated attempts. If the previous failure was before resetBefore,
+	// counting starts over.
+	RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (*LoginAttempts, error)
+	// Lock refuses logins for the key until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets the failures and the lock of the key
+	Reset(ctx context.Context, key string) error
+	// DeleteStale removes the keys whose last failure and lock both ended
+	// before the cut-off, and returns how many were removed
+	DeleteStale(ctx context.Context, before time.Time) (int, error)
+}
+
+// LoginThrottleService defines the use case interface for slowing down
+// password guessing, per account and per client IP address, and second
+// factor guessing, per user and per MFA challenge
+type LoginThrottleService interface {
+	// Check returns ErrAccountLocked or ErrTooManyLoginAttempts, along with
+	// how long to wait, while logins for the email or from the IP are refused
+	Check(ctx context.Context, email, ip string) (time.Duration, error)
+	// RecordFailure counts a failed login, and locks the account or the IP
+	// once they reach their limit
+	RecordFailure(ctx context.Context, email, ip string) error
+	// RecordSuccess forgets the failed logins of the account
+	RecordSuccess(ctx context.Context, email, ip string) error
+	// CheckMFA returns ErrMFALocked, along with how long to wait, while the
+	// user's second factor is locked, or ErrInvalidMFAToken once the MFA
+	// challenge, if any, got too many wrong codes
+	CheckMFA(ctx context.Context, userID int, challengeID string) (time.Duration, error)
+	// RecordMFAFailure counts a wrong code for the user and the MFA
+	// challenge, if any, and locks them once they reach their limit
+	RecordMFAFailure(ctx context.Context, userID int, challengeID string) error
+	// RecordMFASuccess forgets the wrong codes of the user
+	RecordMFASuccess(ctx context.Context, userID int) error
+	// Unlock lets the user log in again right away
+	Unlock(ctx context.Context, userID int) error
+	// PruneStale forgets failed logins that no longer count, and returns
+	// how many accounts and IPs were forgotten
+	PruneStale(ctx context.Context) (int, error)
+}
+
+// Login throttling errors
+var (
+	ErrAccountLocked        = DomainError{Code: "ACCOUNT_LOCKED", Message: "Account is temporarily locked after too many failed logins"}
+	ErrTooManyLoginAttempts = DomainError{Code: "TOO_MANY_LOGIN_ATTEMPTS", Message: "Too many failed logins from this address, please try again later"}
+	ErrMFALocked            = DomainError{Code: "MFA_LOCKED", Message: "Two-factor authentication is temporarily locked after too many wrong codes"}
+)
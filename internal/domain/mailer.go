@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+// EmailMessage is a plain text email to a single recipient
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users
+type Mailer interface {
+	Send(ctx context.Context, message EmailMessage) error
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MFAEnrollment is a user's TOTP (RFC 6238) second factor. It is pending
+// until the user confirms it with a first valid code.
+type MFAEnrollment struct {
+	UserID int
+	Secret string
+	// ConfirmedAt is set once the enrollment has been confirmed; only then
+	// does login require a code
+	ConfirmedAt *time.Time
+	// LastUsedStep is the TOTP time step of the last accepted code, so a
+	// code cannot be used twice
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+// IsConfirmed reports whether login requires a code from this enrollment
+func (e *MFAEnrollment) IsConfirmed() bool {
+	return e.ConfirmedAt != nil
+}
+
+// MFARepository defines the contract for two-factor authentication persistence.
+// Recovery codes are stored as hashes only.
+type MFARepository interface {
+	// GetEnrollment returns ErrMFANotEnrolled if the user has no enrollment
+	GetEnrollment(ctx context.Context, userID int) (*MFAEnrollment, error)
+	// SaveEnrollment stores a pending enrollment, replacing a pending one
+	SaveEnrollment(ctx context.Context, enrollment *MFAEnrollment) error
+	// ConfirmEnrollment confirms a pending enrollment, records the step of
+	// the confirming code and stores the recovery codes. It returns
+	// ErrMFANotEnrolled if there is no pending enrollment.
+	ConfirmEnrollment(ctx context.Context, userID int, confirmedAt time.Time, step int64, recoveryCodeHashes []string) error
+	// DeleteEnrollment removes the enrollment and its recovery codes
+	DeleteEnrollment(ctx context.Context, userID int) error
+	// UseStep records that a code of the given time step was accepted. It
+	// returns false if a code of the same or a later step was accepted before.
+	UseStep(ctx context.Context, userID int, step int64) (bool, error)
+	// UseRecoveryCode marks an unused recovery code as used. It returns false
+	// if the user has no such unused code.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt time.Time) (bool, error)
+	// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string, createdAt time.Time) error
+	// CountRecoveryCodes returns how many unused recovery codes the user has
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+}
+
+// TOTPProvider generates and checks RFC 6238 time-based one-time passwords
+type TOTPProvider interface {
+	// GenerateSecret returns a new random base32 encoded secret
+	GenerateSecret() (string, error)
+	// ProvisioningURI returns the otpauth:// URI authenticator apps import
+	ProvisioningURI(secret, accountName string) string
+	// QRCode renders content as a PNG QR code
+	QRCode(content string) ([]byte, error)
+	// Verify checks the code at the given time, allowing for clock drift, and
+	// returns the time step it matched
+	Verify(secret, code string, at time.Time) (int64, bool)
+}
+
+// MFASetup is what a user needs to add a pending enrollment to an authenticator app
+type MFASetup struct {
+	Secret          string
+	ProvisioningURI string
+	// QRCode is a PNG image of ProvisioningURI
+	QRCode []byte
+}
+
+// MFAStatus describes a user's two-factor authentication
+type MFAStatus struct {
+	Enabled           bool
+	EnabledAt         *time.Time
+	RecoveryCodesLeft int
+}
+
+// LoginResult is the outcome of a password login. Users with two-factor
+// authentication get an MFA challenge to complete instead of a token.
+type LoginResult struct {
+	User         *User
+	Token        string
+	MFAChallenge string
+}
+
+// MFARequired reports whether the login must be completed with a second factor
+func (r *LoginResult) MFARequired() bool {
+	return r.MFAChallenge != ""
+}
+
+// MFAService defines the use case interface for two-factor authentication.
+// Wherever a code is expected, an unused recovery code is accepted as well,
+// except when confirming an enrollment.
+type MFAService interface {
+	GetStatus(ctx context.Context, userID int) (*MFAStatus, error)
+	// BeginEnrollment creates a pending enrollment with a new secret
+	BeginEnrollment(ctx context.Context, userID int) (*MFASetup, error)
+	// ConfirmEnrollment enables two-factor authentication once the user
+	// proves the authenticator works, and returns the recovery codes
+	ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error)
+	Disable(ctx context.Context, userID int, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	// CompleteLogin exchanges an MFA challenge and a code for an access token
+	CompleteLogin(ctx context.Context, challenge, code string) (string, *User, error)
+}
+
+// Two-factor authentication errors
+var (
+	ErrMFANotEnrolled    = DomainError{Code: "MFA_NOT_ENROLLED", Message: "Two-factor authentication is not set up"}
+	ErrMFAAlreadyEnabled = DomainError{Code: "MFA_ALREADY_ENABLED", Message: "Two-factor authentication is already enabled"}
+	ErrInvalidMFACode    = DomainError{Code: "INVALID_MFA_CODE", Message: "Invalid authentication code"}
+	ErrInvalidMFAToken   = DomainError{Code: "INVALID_MFA_TOKEN", Message: "Invalid or expired MFA token"}
+	ErrMFASetupError     = DomainError{Code: "MFA_SETUP_ERROR", Message: "Failed to set up two-factor authentication"}
+)
@@ -0,0 +1,14 @@
+package domain
+
+// PasswordHasher hashes passwords for storage and checks passwords against
+// stored hashes. Every hash names the algorithm and parameters it was made
+// with, so hashes made before a change of settings can still be checked.
+type PasswordHasher interface {
+	// Hash hashes a plain text password with the current algorithm and parameters
+	Hash(password string) (string, error)
+	// Compare returns ErrInvalidCredentials if the password does not match the hash
+	Compare(hash, password string) error
+	// NeedsRehash reports whether the hash was made with another algorithm
+	// or other parameters than Hash uses now
+	NeedsRehash(hash string) bool
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BcryptMaxPasswordBytes is the longest password bcrypt hashes; it ignores
+// every byte after the first 72
+const BcryptMaxPasswordBytes = 72
+
+// minPersonalInfoLength is the shortest email part or name a password may
+// not contain, so short names such as "Al" do not reject ordinary passwords
+const minPersonalInfoLength = 3
+
+// Password policy rules, reported in PasswordViolation.Rule
+const (
+	PasswordRuleMinLength    = "min_length"
+	PasswordRuleMaxLength    = "max_length"
+	PasswordRuleUppercase    = "uppercase"
+	PasswordRuleLowercase    = "lowercase"
+	PasswordRuleDigit        = "digit"
+	PasswordRuleSymbol       = "symbol"
+	PasswordRulePersonalInfo = "personal_info"
+	PasswordRuleBanned       = "banned"
+)
+
+// PasswordPolicy holds the rules new passwords must follow. The zero value
+// accepts every password.
+type PasswordPolicy struct {
+	// MinLength is the fewest characters a password may have
+	MinLength int
+	// MaxLength is the most bytes a password may have; zero means no limit.
+	// Passwords longer than BcryptMaxPasswordBytes are not fully checked by bcrypt.
+	MaxLength int
+
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	// RequireSymbol asks for a character that is neither a letter nor a digit
+	RequireSymbol bool
+
+	// DisallowPersonalInfo rejects passwords containing the user's email
+	// address, the part of it before the @, or their first or last name
+	DisallowPersonalInfo bool
+	// Banned lists breached or common passwords that are refused
+	Banned PasswordList
+}
+
+// PasswordList is a set of passwords, compared without regard to case
+type PasswordList map[string]struct{}
+
+// NewPasswordList creates a list of the given passwords
+func NewPasswordList(passwords ...string) PasswordList {
+	list := make(PasswordList, len(passwords))
+	for _, password := range passwords {
+		list.Add(password)
+	}
+	return list
+}
+
+// Add puts the password on the list
+func (l PasswordList) Add(password string) {
+	l[strings.ToLower(password)] = struct{}{}
+}
+
+// Contains reports whether the password is on the list
+func (l PasswordList) Contains(password string) bool {
+	_, found := l[strings.ToLower(password)]
+	return found
+}
+
+// PasswordViolation is a policy rule a password breaks
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+// ErrWeakPassword is the domain error a PasswordPolicyError stands for
+var ErrWeakPassword = DomainError{Code: "WEAK_PASSWORD", Message: "Password does not meet the password policy"}
+
+func (e *PasswordPolicyError) Error() string {
+	return ErrWeakPassword.Message
+}
+
+// Validate checks the password against every rule of the policy and returns
+// a *PasswordPolicyError listing those it breaks. The personal information
+// is the user's email address and names.
+func (p PasswordPolicy) Validate(password string, personalInfo ...string) error {
+	var violations []PasswordViolation
+	violate := func(rule, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violate(PasswordRuleMinLength, fmt.Sprintf("Password must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violate(PasswordRuleMaxLength, fmt.Sprintf("Password must be at most %d bytes long", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		violate(PasswordRuleUppercase, "Password must contain an uppercase letter")
+	}
+	if p.RequireLowercase && !hasLower {
+		violate(PasswordRuleLowercase, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violate(PasswordRuleDigit, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violate(PasswordRuleSymbol, "Password must contain a symbol")
+	}
+
+	if p.DisallowPersonalInfo && containsPersonalInfo(password, personalInfo) {
+		violate(PasswordRulePersonalInfo, "Password must not contain your email address or name")
+	}
+	if p.Banned.Contains(password) {
+		violate(PasswordRuleBanned, "Password is too common or has appeared in a data breach")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo reports whether the password contains any of the
+// values, or the part of an email address before the @, ignoring case
+func containsPersonalInfo(password string, values []string) bool {
+	password = strings.ToLower(password)
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		parts := []string{value}
+		if local, _, found := strings.Cut(value, "@"); found {
+			parts = append(parts, local)
+		}
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(password, part) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:            8,
+		MaxLength:            BcryptMaxPasswordBytes,
+		RequireUppercase:     true,
+		RequireLowercase:     true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+		Banned:               NewPasswordList("Password1!", "Qwerty123!"),
+	}
+	personalInfo := []string{"john.doe@example.com", "John", "Doe"}
+
+	tests := []struct {
+		name     string
+		password string
+		rules    []string
+	}{
+		{name: "Valid password", password: "Tr0ub4dor&3"},
+		{name: "Too short", password: "Ab1!", rules: []string{PasswordRuleMinLength}},
+		{name: "Too long", password: "Ab1!" + strings.Repeat("x", BcryptMaxPasswordBytes), rules: []string{PasswordRuleMaxLength}},
+		{name: "Length counts characters, not bytes", password: "Ab1!éééé"},
+		{name: "Missing character classes", password: "alllowercase", rules: []string{PasswordRuleUppercase, PasswordRuleDigit, PasswordRuleSymbol}},
+		{name: "Only lowercase missing", password: "UPPER123!", rules: []string{PasswordRuleLowercase}},
+		{name: "Contains email local part", password: "xJOHN.DOE1!", rules: []string{PasswordRulePersonalInfo}},
+		{name: "Contains last name", password: "MrDoe2024!", rules: []string{PasswordRulePersonalInfo}},
+		{name: "Banned, ignoring case", password: "pASSWORD1!", rules: []string{PasswordRuleBanned}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, personalInfo...)
+			if len(tt.rules) == 0 {
+				if err != nil {
+					t.Fatalf("Expected password to be accepted, got %v", err)
+				}
+				return
+			}
+
+			policyErr, ok := err.(*PasswordPolicyError)
+			if !ok {
+				t.Fatalf("Expected *PasswordPolicyError, got %v", err)
+			}
+			var rules []string
+			for _, violation := range policyErr.Violations {
+				rules = append(rules, violation.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("Expected violations %v, got %v", tt.rules, rules)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_ZeroValueAcceptsAnything(t *testing.T) {
+	if err := (PasswordPolicy{}).Validate("a", "a@example.com"); err != nil {
+		t.Errorf("Expected the zero policy to accept any password, got %v", err)
+	}
+}
+
+func TestPasswordPolicy_ShortNamesAreIgnored(t *testing.T) {
+	policy := PasswordPolicy{DisallowPersonalInfo: true}
+	if err := policy.Validate("totally-random", "al@example.com", "Al", "Ng"); err != nil {
+		t.Errorf("Expected names shorter than %d characters to be ignored, got %v", minPersonalInfoLength, err)
+	}
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PasswordResetToken is a single-use token that lets a user choose a new
+// password without knowing the old one. Only the hash of the token is persisted.
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// IsExpired reports whether the token is past its expiry time
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// PasswordResetRepository defines the contract for password reset token persistence
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *PasswordResetToken) error
+	// GetByHash returns ErrInvalidResetToken if there is no such token
+	GetByHash(ctx context.Context, tokenHash string) (*PasswordResetToken, error)
+	// MarkUsed flags the token as used. It returns false if the token had
+	// already been used, which lets callers detect concurrent use.
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error)
+	// MarkUnused makes a token marked as used usable again, for when the
+	// password could not be saved after all
+	MarkUnused(ctx context.Context, id int) error
+	// InvalidateAllForUser marks every unused token of the user as used
+	InvalidateAllForUser(ctx context.Context, userID int, usedAt time.Time) error
+}
+
+// PasswordResetService defines the use case interface for recovering an account
+type PasswordResetService interface {
+	// RequestReset emails a reset link if an active account uses the email.
+	// It succeeds either way, so callers cannot tell whether it does.
+	RequestReset(ctx context.Context, email string) error
+	// ResetPassword sets a new password with a reset token and signs the
+	// user out everywhere. Of concurrent requests with the same token, only
+	// one succeeds.
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+// Password reset errors
+var (
+	ErrInvalidResetToken = DomainError{Code: "INVALID_RESET_TOKEN", Message: "Invalid or expired password reset token"}
+)
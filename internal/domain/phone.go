@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"strings"
+
+	"github.com/ttacon/libphonenumber"
+)
+
+// ErrInvalidPhoneNumber is returned for phone numbers that cannot be normalized
+var ErrInvalidPhoneNumber = DomainError{Code: "INVALID_PHONE_NUMBER", Message: "Invalid phone number"}
+
+// NormalizePhone returns the E.164 form phone numbers are stored in, such as
+// +66812345678. Numbers written without a country code are read as numbers
+// of defaultRegion, an ISO 3166-1 alpha-2 code such as "TH"; without a
+// default region they must start with + and their country code. Numbers
+// that cannot be assigned in their region are refused.
+func NormalizePhone(phone, defaultRegion string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	number, err := libphonenumber.Parse(phone, strings.ToUpper(defaultRegion))
+	if err != nil || !libphonenumber.IsValidNumber(number) {
+		return "", ErrInvalidPhoneNumber
+	}
+	return libphonenumber.Format(number, libphonenumber.E164), nil
+}
+
+// IsPhoneRegion reports whether region is an ISO 3166-1 alpha-2 code that
+// NormalizePhone can read numbers of
+func IsPhoneRegion(region string) bool {
+	_, ok := libphonenumber.GetSupportedRegions()[strings.ToUpper(region)]
+	return ok
+}
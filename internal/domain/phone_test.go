@@ -0,0 +1,55 @@
+package domain
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name          string
+		phone         string
+		defaultRegion string
+		expected      string
+	}{
+		{name: "National number", phone: "081 234 5678", defaultRegion: "TH", expected: "+66812345678"},
+		{name: "Lowercase region", phone: "0812345678", defaultRegion: "th", expected: "+66812345678"},
+		{name: "International number", phone: "+1 (415) 555-2671", defaultRegion: "TH", expected: "+14155552671"},
+		{name: "International number without default region", phone: "+66 81 234 5678", expected: "+66812345678"},
+		{name: "Already normalized", phone: " +66812345678 ", defaultRegion: "US", expected: "+66812345678"},
+		{name: "National number without default region", phone: "0812345678"},
+		{name: "Too short", phone: "12", defaultRegion: "TH"},
+		{name: "Not assigned in the region", phone: "+1 (123) 456-7890", defaultRegion: "US"},
+		{name: "Unknown country code", phone: "+999 1234567"},
+		{name: "Letters", phone: "call me", defaultRegion: "TH"},
+		{name: "Empty", phone: "  ", defaultRegion: "TH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := NormalizePhone(tt.phone, tt.defaultRegion)
+			if tt.expected == "" {
+				if err != ErrInvalidPhoneNumber {
+					t.Fatalf("Expected ErrInvalidPhoneNumber, got %q, %v", normalized, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if normalized != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, normalized)
+			}
+		})
+	}
+}
+
+func TestIsPhoneRegion(t *testing.T) {
+	for _, region := range []string{"TH", "us", "GB"} {
+		if !IsPhoneRegion(region) {
+			t.Errorf("Expected %q to be a phone region", region)
+		}
+	}
+	for _, region := range []string{"", "XX", "USA"} {
+		if IsPhoneRegion(region) {
+			t.Errorf("Expected %q not to be a phone region", region)
+		}
+	}
+}
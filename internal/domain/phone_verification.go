@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PhoneVerificationCode is a one-time code texted to a phone number to prove
+// the user receives messages there. Only the hash of the code is persisted.
+type PhoneVerificationCode struct {
+	ID     int
+	UserID int
+	// Phone is the number the code was sent to, in E.164 form
+	Phone    string
+	CodeHash string
+	// Attempts counts how many times a code was entered against this one
+	Attempts  int
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// IsExpired reports whether the code is past its expiry time
+func (c *PhoneVerificationCode) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// PhoneVerificationRepository defines the contract for phone verification code persistence
+type PhoneVerificationRepository interface {
+	// Create stores a new code, unless max codes were already sent to its
+	// phone number since the given time. It returns false, and stores
+	// nothing, if so.
+	Create(ctx context.Context, code *PhoneVerificationCode, since time.Time, max int) (bool, error)
+	// GetLatest returns the code last sent to the user, or
+	// ErrInvalidPhoneCode if there is none
+	GetLatest(ctx context.Context, userID int) (*PhoneVerificationCode, error)
+	// RecordAttempt counts an attempt at entering the code. It returns
+	// false, and counts nothing, if max attempts were already made.
+	RecordAttempt(ctx context.Context, id int, max int) (bool, error)
+	// MarkUsed flags the code as used. It returns false if the code had
+	// already been used, which lets callers detect concurrent use.
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error)
+}
+
+// PhoneVerificationService defines the use case interface for verifying
+// that users own their phone number
+type PhoneVerificationService interface {
+	// SendCode texts a new code to the user's phone number; codes sent
+	// before stop working. It returns ErrPhoneCodeThrottled if too many
+	// codes were sent to the number recently.
+	SendCode(ctx context.Context, userID int) error
+	// VerifyPhone marks the user's phone number as verified with the code
+	// last sent to it
+	VerifyPhone(ctx context.Context, userID int, code string) (*User, error)
+}
+
+// Phone verification errors
+var (
+	ErrPhoneNumberMissing = DomainError{Code: "PHONE_NUMBER_MISSING", Message: "Add a phone number to your account first"}
+	ErrInvalidPhoneCode   = DomainError{Code: "INVALID_PHONE_CODE", Message: "Invalid or expired phone verification code"}
+	ErrPhoneCodeThrottled = DomainError{Code: "PHONE_CODE_THROTTLED", Message: "Too many codes were sent to this phone number, please try again later"}
+)
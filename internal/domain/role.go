@@ -0,0 +1,29 @@
+package domain
+
+// Role determines what a user is allowed to do
+type Role string
+
+// Supported roles
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole converts a string into a supported role
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if !role.IsValid() {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// IsValid reports whether the role is one of the supported roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "testing"
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Role
+		expectError bool
+	}{
+		{input: "user", expected: RoleUser},
+		{input: "admin", expected: RoleAdmin},
+		{input: "Admin", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			role, err := ParseRole(tt.input)
+			if tt.expectError {
+				if err != ErrInvalidRole {
+					t.Errorf("Expected ErrInvalidRole, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if role != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, role)
+			}
+		})
+	}
+}
+
+func TestUser_HasRole(t *testing.T) {
+	user := &User{Role: RoleAdmin}
+
+	if !user.HasRole(RoleUser, RoleAdmin) {
+		t.Error("Expected an admin to match the admin role")
+	}
+	if user.HasRole(RoleUser) {
+		t.Error("Expected an admin not to match only the user role")
+	}
+}
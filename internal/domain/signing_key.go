@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SigningKeyInfo describes a key access tokens are signed with, without its
+// secret material
+type SigningKeyInfo struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	// RetiredAt is set once the key no longer signs new tokens. It keeps
+	// verifying tokens it signed until ExpiresAt.
+	RetiredAt *time.Time
+	ExpiresAt *time.Time
+	Active    bool
+}
+
+// SigningKeyService manages the key ring access tokens are signed and verified with
+type SigningKeyService interface {
+	ListSigningKeys(ctx context.Context) ([]SigningKeyInfo, error)
+	// RotateSigningKey generates a new active key. The previous active key
+	// is retired and keeps verifying tokens until they have all expired.
+	RotateSigningKey(ctx context.Context) (*SigningKeyInfo, error)
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "context"
+
+// SMSMessage is a text message to a single phone number, in E.164 form
+type SMSMessage struct {
+	To   string
+	Body string
+}
+
+// SMSSender delivers text messages to users
+type SMSSender interface {
+	Send(ctx context.Context, message SMSMessage) error
+}
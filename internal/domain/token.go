@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RefreshToken represents a long-lived, opaque refresh token.
+// Only the hash of the token is persisted. Tokens obtained by rotating
+// each other share a FamilyID, so the whole chain can be revoked at once.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// IsExpired reports whether the token is past its expiry time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// TokenPair holds an access token together with its refresh token
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// RefreshTokenRepository defines the contract for refresh token persistence
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkUsed flags the token as rotated. It returns false if the token
+	// had already been used, which lets callers detect concurrent reuse.
+	MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error
+}
+
+// TokenRevocationStore keeps track of access tokens that must be rejected before they expire
+type TokenRevocationStore interface {
+	// RevokeToken revokes a single access token by its jti until it expires
+	RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error
+	// RevokeUserTokens revokes every access token of the user issued before issuedBefore.
+	// The entry can be forgotten after expiresAt, once all such tokens have expired.
+	RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims *TokenClaims) (bool, error)
+}
+
+// TokenService defines the use case interface for session token operations
+type TokenService interface {
+	IssueRefreshToken(ctx context.Context, userID int) (string, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, claims *TokenClaims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID int) error
+}
+
+// Token errors
+var (
+	ErrInvalidRefreshToken = DomainError{Code: "INVALID_REFRESH_TOKEN", Message: "Invalid or expired refresh token"}
+	ErrRefreshTokenReused  = DomainError{Code: "REFRESH_TOKEN_REUSED", Message: "Refresh token has already been used"}
+	ErrTokenRevoked        = DomainError{Code: "TOKEN_REVOKED", Message: "Token has been revoked"}
+)
@@ -14,14 +14,30 @@ type User struct {
 	LastName  string
 	Phone     string
 	Birthday  time.Time
+	Role      Role
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set while the account is soft-deleted and can still be restored
+	DeletedAt *time.Time
+	// EmailVerifiedAt is set once the user has proven they own the email address
+	EmailVerifiedAt *time.Time
+	// PhoneVerifiedAt is set once the user has proven they own the phone
+	// number, and cleared when it changes
+	PhoneVerifiedAt *time.Time
+	// PendingEmail is the address the user asked to change to, until they
+	// confirm it from the link sent there
+	PendingEmail string
+	// TokenVersion is written into access tokens and bumped when the
+	// password changes; tokens carrying an older version are rejected
+	TokenVersion int
 }
 
-// NewUser creates a new user entity with validation
+// NewUser creates a new user entity with validation. The email is stored
+// normalized, see NormalizeEmail.
 func NewUser(email, password, firstName, lastName, phone string, birthday time.Time) (*User, error) {
-	if email == "" {
-		return nil, ErrInvalidEmail
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
 	}
 	if firstName == "" {
 		return nil, ErrInvalidFirstName
@@ -38,6 +54,7 @@ func NewUser(email, password, firstName, lastName, phone string, birthday time.T
 		LastName:  lastName,
 		Phone:     phone,
 		Birthday:  birthday,
+		Role:      RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}, nil
@@ -48,6 +65,31 @@ func (u *User) GetFullName() string {
 	return u.FirstName + " " + u.LastName
 }
 
+// IsDeleted reports whether the account has been soft-deleted
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// IsEmailVerified reports whether the user has verified their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsPhoneVerified reports whether the user has verified their phone number
+func (u *User) IsPhoneVerified() bool {
+	return u.PhoneVerifiedAt != nil
+}
+
+// HasRole reports whether the user has one of the given roles
+func (u *User) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
 // IsValidForUpdate checks if user data is valid for update
 func (u *User) IsValidForUpdate() error {
 	if u.Email == "" {
@@ -62,37 +104,146 @@ func (u *User) IsValidForUpdate() error {
 	return nil
 }
 
-// UserRepository defines the contract for user data persistence
+// UserUpdate describes a partial update of a user's profile.
+// A nil field is left untouched; a non-nil field replaces the stored value.
+// Every field is required, so none can be cleared. A new phone number is no
+// longer verified.
+type UserUpdate struct {
+	FirstName *string
+	LastName  *string
+	Phone     *string
+	Birthday  *time.Time
+}
+
+// Apply copies the set fields of the update onto the user
+func (u UserUpdate) Apply(user *User) {
+	if u.FirstName != nil {
+		user.FirstName = *u.FirstName
+	}
+	if u.LastName != nil {
+		user.LastName = *u.LastName
+	}
+	if u.Phone != nil {
+		if *u.Phone != user.Phone {
+			user.PhoneVerifiedAt = nil
+		}
+		user.Phone = *u.Phone
+	}
+	if u.Birthday != nil {
+		user.Birthday = *u.Birthday
+	}
+}
+
+// UserRepository defines the contract for user data persistence.
+// Soft-deleted users are invisible to every method except those that
+// explicitly deal with deleted users, but their email stays taken until
+// they are purged.
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetByID(ctx context.Context, id int) (*User, error)
 	Update(ctx context.Context, user *User) error
+	// Delete removes a user permanently
 	Delete(ctx context.Context, id int) error
 	Exists(ctx context.Context, email string) (bool, error)
+	List(ctx context.Context, query UserListQuery) (*UserPage, error)
+	// SoftDelete marks an active user as deleted. It returns ErrUserNotFound
+	// if there is no such user or it is already deleted.
+	SoftDelete(ctx context.Context, id int, deletedAt time.Time) error
+	// GetDeletedByID retrieves a soft-deleted user by ID
+	GetDeletedByID(ctx context.Context, id int) (*User, error)
+	// Restore clears the deletion mark. It returns ErrUserNotFound if there
+	// is no such soft-deleted user.
+	Restore(ctx context.Context, id int) error
+	// PurgeDeleted permanently removes users soft-deleted before the cut-off
+	// and returns how many were removed
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
+	// RecordVerificationSent notes that a verification email is sent to the
+	// user at sentAt. It returns false, and records nothing, if one was
+	// already sent after notBefore.
+	RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error)
+	// ReplacePasswordHash stores newHash as the user's password hash if it
+	// is still oldHash. It returns false, and changes nothing, if the
+	// password changed in the meantime.
+	ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error)
+	// UpdatePassword stores a new password hash and bumps the user's token
+	// version in one statement, returning the new version. Update leaves
+	// both alone, so that writing back a user read before cannot undo them.
+	UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error)
 }
 
 // AuthService defines the contract for authentication operations
 type AuthService interface {
-	GenerateToken(userID int, email string) (string, error)
+	// GenerateToken signs an access token. The token version is copied from
+	// the user, so that bumping it invalidates every token issued before.
+	GenerateToken(userID int, email string, role Role, tokenVersion int) (string, error)
 	ValidateToken(token string) (*TokenClaims, error)
-	HashPassword(password string) (string, error)
-	ComparePassword(hashedPassword, password string) error
+	// GenerateMFAChallenge creates a short-lived token proving the user got
+	// past the password step. It is not accepted as an access token.
+	GenerateMFAChallenge(userID int) (string, error)
+	// ValidateMFAChallenge returns the claims of the challenge: the user it
+	// was issued to, its ID and its lifetime
+	ValidateMFAChallenge(token string) (*TokenClaims, error)
+	// GenerateEmailVerificationToken signs a token proving the user received
+	// mail at the email address
+	GenerateEmailVerificationToken(userID int, email string) (string, error)
+	// ValidateEmailVerificationToken returns the user and email address the
+	// token was issued for
+	ValidateEmailVerificationToken(token string) (int, string, error)
+	// GenerateEmailRevertToken signs a token that restores the email address
+	// of the user after it was changed
+	GenerateEmailRevertToken(userID int, email string) (string, error)
+	// ValidateEmailRevertToken returns the user and email address the token
+	// was issued for
+	ValidateEmailRevertToken(token string) (int, string, error)
+	// PublicKeys returns the keys other services can verify tokens with.
+	// It is empty when tokens are signed with a shared secret.
+	PublicKeys() []JSONWebKey
+}
+
+// JSONWebKey is a public token verification key (RFC 7517). RSA keys use
+// Modulus and Exponent; Ed25519 keys use Curve and X.
+type JSONWebKey struct {
+	KeyType   string
+	KeyID     string
+	Algorithm string
+	Use       string
+	Modulus   string
+	Exponent  string
+	Curve     string
+	X         string
 }
 
 // TokenClaims represents JWT token claims
 type TokenClaims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	Role    Role   `json:"role"`
+	TokenID string `json:"jti"`
+	// TokenVersion is the user's token version when the token was issued
+	TokenVersion int       `json:"ver"`
+	IssuedAt     time.Time `json:"iat"`
+	ExpiresAt    time.Time `json:"exp"`
 }
 
 // UserService defines the use case interface for user operations
 type UserService interface {
 	Register(ctx context.Context, email, password, firstName, lastName, phone string, birthday time.Time) (*User, error)
-	Login(ctx context.Context, email, password string) (string, *User, error)
+	Login(ctx context.Context, email, password string) (*LoginResult, error)
 	GetUserByID(ctx context.Context, userID int) (*User, error)
 	GetUserProfile(ctx context.Context, userID int) (*User, error)
 	UpdateUser(ctx context.Context, userID int, firstName, lastName, phone string, birthday *time.Time) (*User, error)
+	UpdateProfile(ctx context.Context, userID int, update UserUpdate) (*User, error)
+	// ChangePassword replaces the password after checking the current one,
+	// revokes every refresh token and bumps the token version, so access
+	// tokens issued before stop working. The result holds a new access token
+	// for the caller.
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (*LoginResult, error)
+	SetRole(ctx context.Context, userID int, role Role) (*User, error)
+	ListUsers(ctx context.Context, query UserListQuery) (*UserPage, error)
+	DeleteAccount(ctx context.Context, userID int) error
+	RestoreAccount(ctx context.Context, userID int) (*User, error)
+	PurgeDeletedUsers(ctx context.Context) (int, error)
 }
 
 // DomainError represents domain-specific errors
@@ -110,6 +261,7 @@ var (
 	ErrUserNotFound         = DomainError{Code: "USER_NOT_FOUND", Message: "User not found"}
 	ErrUserAlreadyExists    = DomainError{Code: "USER_ALREADY_EXISTS", Message: "User already exists"}
 	ErrInvalidCredentials   = DomainError{Code: "INVALID_CREDENTIALS", Message: "Invalid email or password"}
+	ErrIncorrectPassword    = DomainError{Code: "INCORRECT_PASSWORD", Message: "Current password is incorrect"}
 	ErrInvalidToken         = DomainError{Code: "INVALID_TOKEN", Message: "Invalid token"}
 	ErrUnauthorized         = DomainError{Code: "UNAUTHORIZED", Message: "Unauthorized access"}
 	ErrInvalidEmail         = DomainError{Code: "INVALID_EMAIL", Message: "Email is required"}
@@ -119,4 +271,10 @@ var (
 	ErrPasswordHashError    = DomainError{Code: "PASSWORD_HASH_ERROR", Message: "Failed to hash password"}
 	ErrUserCreationError    = DomainError{Code: "USER_CREATION_ERROR", Message: "Failed to create user"}
 	ErrTokenGenerationError = DomainError{Code: "TOKEN_GENERATION_ERROR", Message: "Failed to generate token"}
+	ErrUserUpdateError      = DomainError{Code: "USER_UPDATE_ERROR", Message: "Failed to update user"}
+	ErrInvalidRole          = DomainError{Code: "INVALID_ROLE", Message: "Role must be one of: user, admin"}
+	ErrForbidden            = DomainError{Code: "FORBIDDEN", Message: "Insufficient permissions"}
+	ErrInvalidCursor        = DomainError{Code: "INVALID_CURSOR", Message: "Invalid pagination cursor"}
+	ErrInvalidSortField     = DomainError{Code: "INVALID_SORT", Message: "Sort must be one of: created_at, lastname"}
+	ErrRestorePeriodExpired = DomainError{Code: "RESTORE_PERIOD_EXPIRED", Message: "The account can no longer be restored"}
 )
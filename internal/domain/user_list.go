@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Page size limits for user listings
+const (
+	DefaultUserListLimit = 20
+	MaxUserListLimit     = 100
+)
+
+// UserSortField is a column users can be listed by
+type UserSortField string
+
+// Supported sort fields
+const (
+	UserSortCreatedAt UserSortField = "created_at"
+	UserSortLastName  UserSortField = "lastname"
+)
+
+// IsValid reports whether users can be sorted by the field
+func (f UserSortField) IsValid() bool {
+	return f == UserSortCreatedAt || f == UserSortLastName
+}
+
+// UserListFilter narrows down a user listing. Zero values match every
+// active user.
+type UserListFilter struct {
+	EmailContains string
+	CreatedFrom   *time.Time // inclusive
+	CreatedTo     *time.Time // exclusive
+	// Deleted lists soft-deleted users instead of active ones
+	Deleted bool
+}
+
+// UserListQuery describes one page of a user listing
+type UserListQuery struct {
+	Filter     UserListFilter
+	SortBy     UserSortField
+	Descending bool
+	Limit      int
+	// After is the position of the last user of the previous page, if any
+	After *UserCursor
+}
+
+// UserPage is one page of a user listing
+type UserPage struct {
+	Users []*User
+	// NextCursor is empty on the last page
+	NextCursor string
+	// Total counts every user matching the filter, across all pages
+	Total int
+}
+
+// UserCursor is a keyset position in a user listing: the sort value and ID of
+// the last user on a page. It is handed to clients as an opaque string.
+type UserCursor struct {
+	SortBy     UserSortField `json:"s"`
+	Descending bool          `json:"d,omitempty"`
+	CreatedAt  time.Time     `json:"c,omitempty"`
+	LastName   string        `json:"l,omitempty"`
+	ID         int           `json:"i"`
+}
+
+// NewUserCursor returns the cursor positioned at the user for the query's ordering
+func NewUserCursor(query UserListQuery, user *User) UserCursor {
+	cursor := UserCursor{SortBy: query.SortBy, Descending: query.Descending, ID: user.ID}
+	if query.SortBy == UserSortLastName {
+		cursor.LastName = user.LastName
+	} else {
+		cursor.CreatedAt = user.CreatedAt.UTC()
+	}
+	return cursor
+}
+
+// Encode returns the cursor as an opaque, URL-safe string
+func (c UserCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeUserCursor parses a cursor produced by Encode
+func DecodeUserCursor(s string) (*UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor UserCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || !cursor.SortBy.IsValid() || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Validate checks the query and fills in defaults
+func (q *UserListQuery) Validate() error {
+	if q.SortBy == "" {
+		q.SortBy = UserSortCreatedAt
+	}
+	if !q.SortBy.IsValid() {
+		return ErrInvalidSortField
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultUserListLimit
+	}
+	if q.Limit > MaxUserListLimit {
+		q.Limit = MaxUserListLimit
+	}
+
+	q.Filter.EmailContains = strings.TrimSpace(q.Filter.EmailContains)
+
+	// A cursor only makes sense for the ordering it was created with
+	if q.After != nil && (q.After.SortBy != q.SortBy || q.After.Descending != q.Descending) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Matches reports whether the user passes the filter
+func (f UserListFilter) Matches(user *User) bool {
+	if user.IsDeleted() != f.Deleted {
+		return false
+	}
+	if f.EmailContains != "" && !strings.Contains(strings.ToLower(user.Email), strings.ToLower(f.EmailContains)) {
+		return false
+	}
+	if f.CreatedFrom != nil && user.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !user.CreatedAt.Before(*f.CreatedTo) {
+		return false
+	}
+	return true
+}
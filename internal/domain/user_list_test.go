@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserCursor_EncodeDecode(t *testing.T) {
+	createdAt := time.Date(2025, 8, 27, 14, 0, 0, 123456789, time.UTC)
+	query := UserListQuery{SortBy: UserSortCreatedAt, Descending: true}
+	cursor := NewUserCursor(query, &User{ID: 7, LastName: "Doe", CreatedAt: createdAt})
+
+	decoded, err := DecodeUserCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decoded.ID != 7 || decoded.SortBy != UserSortCreatedAt || !decoded.Descending {
+		t.Errorf("Expected cursor to round-trip, got %+v", decoded)
+	}
+	if !decoded.CreatedAt.Equal(createdAt) {
+		t.Errorf("Expected created_at %v, got %v", createdAt, decoded.CreatedAt)
+	}
+	if decoded.LastName != "" {
+		t.Errorf("Expected lastname to be left out of a created_at cursor, got %s", decoded.LastName)
+	}
+}
+
+func TestDecodeUserCursor_Invalid(t *testing.T) {
+	for _, input := range []string{"", "not base64!", "e30", UserCursor{SortBy: "email", ID: 1}.Encode()} {
+		if _, err := DecodeUserCursor(input); err != ErrInvalidCursor {
+			t.Errorf("Expected ErrInvalidCursor for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestUserListQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         UserListQuery
+		expectedErr   error
+		expectedLimit int
+	}{
+		{name: "Defaults", query: UserListQuery{}, expectedLimit: DefaultUserListLimit},
+		{name: "Limit is capped", query: UserListQuery{Limit: 1000}, expectedLimit: MaxUserListLimit},
+		{name: "Unknown sort field", query: UserListQuery{SortBy: "email"}, expectedErr: ErrInvalidSortField},
+		{
+			name:        "Cursor from another ordering",
+			query:       UserListQuery{SortBy: UserSortCreatedAt, After: &UserCursor{SortBy: UserSortCreatedAt, Descending: true, ID: 1}},
+			expectedErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if err != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil {
+				if tt.query.Limit != tt.expectedLimit {
+					t.Errorf("Expected limit %d, got %d", tt.expectedLimit, tt.query.Limit)
+				}
+				if tt.query.SortBy != UserSortCreatedAt {
+					t.Errorf("Expected default sort created_at, got %s", tt.query.SortBy)
+				}
+			}
+		})
+	}
+}
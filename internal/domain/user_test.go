@@ -38,6 +38,17 @@ func TestNewUser(t *testing.T) {
 			expectError: true,
 			expectedErr: ErrInvalidEmail,
 		},
+		{
+			name:        "Invalid email",
+			email:       "test.example.com",
+			password:    "hashedpassword",
+			firstName:   "John",
+			lastName:    "Doe",
+			phone:       "1234567890",
+			birthday:    time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectError: true,
+			expectedErr: ErrInvalidEmailAddress,
+		},
 		{
 			name:        "Empty first name",
 			email:       "test@example.com",
@@ -185,6 +196,29 @@ func TestUser_IsValidForUpdate(t *testing.T) {
 	}
 }
 
+func TestUserUpdate_Apply_Phone(t *testing.T) {
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := &User{FirstName: "John", Phone: "+66812345678", PhoneVerifiedAt: &verifiedAt}
+
+	samePhone := "+66812345678"
+	UserUpdate{Phone: &samePhone}.Apply(user)
+	if !user.IsPhoneVerified() {
+		t.Error("Expected the phone number to stay verified when it does not change")
+	}
+
+	firstName := "Jane"
+	UserUpdate{FirstName: &firstName}.Apply(user)
+	if !user.IsPhoneVerified() {
+		t.Error("Expected the phone number to stay verified when other fields change")
+	}
+
+	newPhone := "+14155552671"
+	UserUpdate{Phone: &newPhone}.Apply(user)
+	if user.Phone != newPhone || user.IsPhoneVerified() {
+		t.Errorf("Expected the new phone number to be unverified, got %q verified at %v", user.Phone, user.PhoneVerifiedAt)
+	}
+}
+
 func TestDomainError_Error(t *testing.T) {
 	err := DomainError{
 		Code:    "TEST_ERROR",
@@ -214,6 +248,11 @@ func TestDomainErrors(t *testing.T) {
 		ErrPasswordHashError,
 		ErrUserCreationError,
 		ErrTokenGenerationError,
+		ErrUserUpdateError,
+		ErrInvalidRole,
+		ErrForbidden,
+		ErrInvalidCursor,
+		ErrInvalidSortField,
 	}
 
 	for _, err := range errors {
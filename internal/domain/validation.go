@@ -0,0 +1,49 @@
+package domain
+
+// Field validation rules, reported in FieldViolation.Rule. Password policy
+// violations keep the PasswordRule* rules.
+const (
+	FieldRuleEmail    = "email"
+	FieldRulePhone    = "phone"
+	FieldRuleBirthday = "birthday"
+)
+
+// FieldViolation is a rule a field of a request breaks
+type FieldViolation struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+// ValidationError lists every field of a request that breaks a rule, so that
+// they are all reported at once instead of one per attempt
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+// ErrValidationFailed is the domain error a ValidationError stands for
+var ErrValidationFailed = DomainError{Code: "VALIDATION_ERROR", Message: "Validation failed"}
+
+func (e *ValidationError) Error() string {
+	return ErrValidationFailed.Message
+}
+
+// Add records that field breaks rule
+func (e *ValidationError) Add(field, rule, message string) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Rule: rule, Message: message})
+}
+
+// AddPasswordPolicy records every password policy rule field breaks
+func (e *ValidationError) AddPasswordPolicy(field string, err *PasswordPolicyError) {
+	for _, violation := range err.Violations {
+		e.Add(field, violation.Rule, violation.Message)
+	}
+}
+
+// Err returns e if it holds a violation, and nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
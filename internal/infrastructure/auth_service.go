@@ -1,88 +1,370 @@
 package infrastructure
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
 	"hello-world/internal/domain"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// IssuedAtPrecision is the precision of the iat and exp claims. Whole
+// seconds would let a token issued earlier in the second of a logout
+// everywhere survive it, so tokens carry fractional seconds.
+const IssuedAtPrecision = time.Microsecond
+
+func init() {
+	jwt.TimePrecision = IssuedAtPrecision
+}
+
+// DefaultAccessTokenTTL is the lifetime of issued access tokens when none is configured
+const DefaultAccessTokenTTL = 24 * time.Hour
+
+// DefaultMFAChallengeTTL is how long a user has to enter a second factor
+// after the password step when none is configured
+const DefaultMFAChallengeTTL = 5 * time.Minute
+
+// DefaultEmailVerificationTTL is how long an email verification link works
+// when none is configured
+const DefaultEmailVerificationTTL = 24 * time.Hour
+
+// DefaultEmailRevertTTL is how long the link restoring a changed email
+// address works when none is configured
+const DefaultEmailRevertTTL = 7 * 24 * time.Hour
+
+// Token uses other than access. A token with a use is audienced to its use
+// instead of the access token audience and has a typ header of its own (see
+// tokenType), so neither this service nor others verifying tokens with the
+// published keys take it for an access token.
+const (
+	// tokenUseMFAChallenge marks tokens that only allow completing a login
+	tokenUseMFAChallenge = "mfa_challenge"
+	// tokenUseEmailVerification marks tokens that only verify an email address
+	tokenUseEmailVerification = "email_verification"
+	// tokenUseEmailRevert marks tokens that only restore a changed email address
+	tokenUseEmailRevert = "email_revert"
+)
+
+// accessTokenType is the typ header of access tokens
+const accessTokenType = "JWT"
+
+// tokenType returns the typ header of tokens with the use, such as
+// "mfa-challenge+jwt" (RFC 8725 explicit typing)
+func tokenType(use string) string {
+	if use == "" {
+		return accessTokenType
+	}
+	return strings.ReplaceAll(use, "_", "-") + "+jwt"
+}
+
+// JWTConfig holds the settings used to sign and verify access tokens
+type JWTConfig struct {
+	// KeyRing signs tokens with its active key and verifies them with any of
+	// its keys. When nil, Key signs and verifies every token; when that is nil
+	// too, tokens are signed with HS256 using Secret.
+	KeyRing *KeyRing
+	Key     *SigningKey
+	Secret  string
+	// Issuer and Audience are written to the iss and aud claims and, when
+	// set, required on every token that is validated
+	Issuer    string
+	Audience  string
+	AccessTTL time.Duration
+	// MFAChallengeTTL is the lifetime of MFA challenge tokens
+	MFAChallengeTTL time.Duration
+	// EmailVerificationTTL is the lifetime of email verification tokens
+	EmailVerificationTTL time.Duration
+	// EmailRevertTTL is the lifetime of tokens restoring a changed email address
+	EmailRevertTTL time.Duration
+	// ClockSkew is the leeway allowed when checking exp, iat and nbf, to
+	// tolerate clock differences between servers
+	ClockSkew time.Duration
+}
+
+// KeyRetention is how long a retired signing key must keep verifying
+// tokens: the lifetime of the longest-lived token signed with it, plus the
+// clock skew
+func (c JWTConfig) KeyRetention() time.Duration {
+	c = c.withDefaults()
+	longest := c.AccessTTL
+	for _, ttl := range []time.Duration{c.MFAChallengeTTL, c.EmailVerificationTTL, c.EmailRevertTTL} {
+		if ttl > longest {
+			longest = ttl
+		}
+	}
+	return longest + c.ClockSkew
+}
+
+// withDefaults fills in the default lifetime of every kind of token left unset
+func (c JWTConfig) withDefaults() JWTConfig {
+	if c.AccessTTL <= 0 {
+		c.AccessTTL = DefaultAccessTokenTTL
+	}
+	if c.MFAChallengeTTL <= 0 {
+		c.MFAChallengeTTL = DefaultMFAChallengeTTL
+	}
+	if c.EmailVerificationTTL <= 0 {
+		c.EmailVerificationTTL = DefaultEmailVerificationTTL
+	}
+	if c.EmailRevertTTL <= 0 {
+		c.EmailRevertTTL = DefaultEmailRevertTTL
+	}
+	return c
+}
+
+// keySource supplies the keys tokens are signed and verified with
+type keySource interface {
+	ActiveKey() *SigningKey
+	VerificationKey(kid string) (*SigningKey, bool)
+	PublicKeys() []domain.JSONWebKey
+}
+
+// staticKey is a keySource with a single key
+type staticKey struct {
+	key *SigningKey
+}
+
+func (s staticKey) ActiveKey() *SigningKey {
+	return s.key
+}
+
+func (s staticKey) VerificationKey(kid string) (*SigningKey, bool) {
+	return s.key, kid == s.key.ID
+}
+
+func (s staticKey) PublicKeys() []domain.JSONWebKey {
+	keys := []domain.JSONWebKey{}
+	if jwk, ok := s.key.PublicJWK(); ok {
+		keys = append(keys, jwk)
+	}
+	return keys
+}
+
 // JWTAuthService implements domain.AuthService using JWT
 type JWTAuthService struct {
-	secretKey []byte
+	keys   keySource
+	config JWTConfig
+	now    func() time.Time
 }
 
 // NewJWTAuthService creates a new JWT authentication service
-func NewJWTAuthService() *JWTAuthService {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key" // Default secret for development
+func NewJWTAuthService(config JWTConfig) *JWTAuthService {
+	config = config.withDefaults()
+	var keys keySource
+	switch {
+	case config.KeyRing != nil:
+		keys = config.KeyRing
+	case config.Key != nil:
+		keys = staticKey{key: config.Key}
+	default:
+		keys = staticKey{key: NewHMACSigningKey([]byte(config.Secret))}
 	}
 
 	return &JWTAuthService{
-		secretKey: []byte(secret),
+		keys:   keys,
+		config: config,
+		now:    time.Now,
 	}
 }
 
-// JWTClaims represents the JWT claims structure
+// JWTClaims represents the JWT claims structure.
+// RegisteredClaims.ID carries the jti used for revocation. Use is empty on
+// access tokens and names the purpose of any other token.
 type JWTClaims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID int         `json:"user_id"`
+	Email  string      `json:"email"`
+	Role   domain.Role `json:"role"`
+	Use    string      `json:"token_use,omitempty"`
+	// Version is the user's token version on access tokens; tokens issued
+	// before versions existed have none and count as version 0
+	Version int `json:"ver,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // GenerateToken creates a JWT token for the user
-func (a *JWTAuthService) GenerateToken(userID int, email string) (string, error) {
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+func (a *JWTAuthService) GenerateToken(userID int, email string, role domain.Role, tokenVersion int) (string, error) {
+	return a.sign(JWTClaims{UserID: userID, Email: email, Role: role, Version: tokenVersion}, a.config.AccessTTL)
+}
+
+// GenerateMFAChallenge creates a short-lived token that only allows completing a login
+func (a *JWTAuthService) GenerateMFAChallenge(userID int) (string, error) {
+	return a.sign(JWTClaims{UserID: userID, Use: tokenUseMFAChallenge}, a.config.MFAChallengeTTL)
+}
+
+// GenerateEmailVerificationToken creates a token for the link that verifies
+// the user's email address. It only works while the user keeps that address.
+func (a *JWTAuthService) GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	return a.sign(JWTClaims{UserID: userID, Email: email, Use: tokenUseEmailVerification}, a.config.EmailVerificationTTL)
+}
+
+// GenerateEmailRevertToken creates a token for the link that restores the
+// user's email address after a change
+func (a *JWTAuthService) GenerateEmailRevertToken(userID int, email string) (string, error) {
+	return a.sign(JWTClaims{UserID: userID, Email: email, Use: tokenUseEmailRevert}, a.config.EmailRevertTTL)
+}
+
+// sign fills in the registered claims and signs the token with the active key
+func (a *JWTAuthService) sign(claims JWTClaims, ttl time.Duration) (string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(a.secretKey)
+	now := a.now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        tokenID,
+		Issuer:    a.config.Issuer,
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	if audience := a.audience(claims.Use); audience != "" {
+		claims.Audience = jwt.ClaimStrings{audience}
+	}
+
+	key := a.keys.ActiveKey()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = tokenType(claims.Use)
+	return token.SignedString(key.signKey)
+}
+
+// audience returns the aud claim of tokens with the use: the configured
+// audience for access tokens, the use itself for any other token
+func (a *JWTAuthService) audience(use string) string {
+	if use == "" {
+		return a.config.Audience
+	}
+	return use
+}
+
+// parse verifies a token and returns its claims if it has the use, the typ
+// header of that use and, for tokens with a use, that use as audience.
+// Access tokens issued before typ headers were set have none.
+func (a *JWTAuthService) parse(tokenString, use string) (*JWTClaims, bool) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, a.verificationKey, a.parserOptions(use)...)
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || claims.Use != use {
+		return nil, false
+	}
+
+	typ, hasType := token.Header["typ"]
+	if !hasType {
+		return claims, use == ""
+	}
+	return claims, strings.EqualFold(fmt.Sprint(typ), tokenType(use))
 }
 
 // ValidateToken validates and parses the JWT token
 func (a *JWTAuthService) ValidateToken(tokenString string) (*domain.TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	if claims, ok := a.parse(tokenString, ""); ok {
+		tokenClaims := &domain.TokenClaims{
+			UserID:       claims.UserID,
+			Email:        claims.Email,
+			Role:         claims.Role,
+			TokenID:      claims.ID,
+			TokenVersion: claims.Version,
 		}
-		return a.secretKey, nil
-	})
+		if claims.IssuedAt != nil {
+			tokenClaims.IssuedAt = claims.IssuedAt.Time
+		}
+		if claims.ExpiresAt != nil {
+			tokenClaims.ExpiresAt = claims.ExpiresAt.Time
+		}
+		return tokenClaims, nil
+	}
 
-	if err != nil {
-		return nil, domain.ErrInvalidToken
+	return nil, domain.ErrInvalidToken
+}
+
+// ValidateMFAChallenge validates an MFA challenge token and returns its
+// claims, identifying the challenge by its jti
+func (a *JWTAuthService) ValidateMFAChallenge(tokenString string) (*domain.TokenClaims, error) {
+	claims, ok := a.parse(tokenString, tokenUseMFAChallenge)
+	if !ok || claims.ID == "" {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	challengeClaims := &domain.TokenClaims{UserID: claims.UserID, TokenID: claims.ID}
+	if claims.IssuedAt != nil {
+		challengeClaims.IssuedAt = claims.IssuedAt.Time
 	}
+	if claims.ExpiresAt != nil {
+		challengeClaims.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return challengeClaims, nil
+}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return &domain.TokenClaims{
-			UserID: claims.UserID,
-			Email:  claims.Email,
-		}, nil
+// ValidateEmailVerificationToken validates an email verification token and
+// returns the user and email address it was issued for
+func (a *JWTAuthService) ValidateEmailVerificationToken(tokenString string) (int, string, error) {
+	claims, ok := a.parse(tokenString, tokenUseEmailVerification)
+	if !ok {
+		return 0, "", domain.ErrInvalidVerificationToken
 	}
+	return claims.UserID, claims.Email, nil
+}
 
-	return nil, domain.ErrInvalidToken
+// ValidateEmailRevertToken validates an email revert token and returns the
+// user and email address it was issued for
+func (a *JWTAuthService) ValidateEmailRevertToken(tokenString string) (int, string, error) {
+	claims, ok := a.parse(tokenString, tokenUseEmailRevert)
+	if !ok {
+		return 0, "", domain.ErrInvalidEmailChangeToken
+	}
+	return claims.UserID, claims.Email, nil
 }
 
-// HashPassword hashes a plain text password
-func (a *JWTAuthService) HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
+// verificationKey picks the key a token was signed with by its kid. Tokens
+// issued before kids were introduced have none and are checked against the
+// active key. The algorithm must match the key, so a public key can never
+// be used as an HMAC secret.
+func (a *JWTAuthService) verificationKey(token *jwt.Token) (interface{}, error) {
+	key := a.keys.ActiveKey()
+	if header, ok := token.Header["kid"]; ok {
+		kid, _ := header.(string)
+		if key, ok = a.keys.VerificationKey(kid); !ok {
+			return nil, fmt.Errorf("unknown key id: %v", header)
+		}
 	}
-	return string(hashedBytes), nil
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.verifyKey, nil
+}
+
+// PublicKeys returns the public verification keys as JSON Web Keys
+func (a *JWTAuthService) PublicKeys() []domain.JSONWebKey {
+	return a.keys.PublicKeys()
+}
+
+// parserOptions returns the claim checks applied to tokens with the use
+func (a *JWTAuthService) parserOptions(use string) []jwt.ParserOption {
+	options := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(a.config.ClockSkew),
+		jwt.WithTimeFunc(a.now),
+	}
+	if a.config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(a.config.Issuer))
+	}
+	if audience := a.audience(use); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return options
 }
 
-// ComparePassword compares a hashed password with a plain text password
-func (a *JWTAuthService) ComparePassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// newTokenID returns a random identifier for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
@@ -1,64 +1,30 @@
 package infrastructure
 
 import (
+	"crypto/ed25519"
 	"testing"
+	"time"
 
 	"hello-world/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
+var testJWTConfig = JWTConfig{Secret: "test-secret-key-that-is-32-bytes!"}
+
 func TestNewJWTAuthService(t *testing.T) {
-	authService := NewJWTAuthService()
+	authService := NewJWTAuthService(testJWTConfig)
 	if authService == nil {
 		t.Error("Expected auth service to be created but got nil")
 	}
 }
 
-func TestJWTAuthService_HashPassword(t *testing.T) {
-	authService := NewJWTAuthService()
-	password := "testpassword123"
-
-	hashedPassword, err := authService.HashPassword(password)
-	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
-	}
-
-	if hashedPassword == "" {
-		t.Error("Expected hashed password to be non-empty")
-	}
-
-	if hashedPassword == password {
-		t.Error("Expected hashed password to be different from original password")
-	}
-}
-
-func TestJWTAuthService_ComparePassword(t *testing.T) {
-	authService := NewJWTAuthService()
-	password := "testpassword123"
-
-	hashedPassword, err := authService.HashPassword(password)
-	if err != nil {
-		t.Fatalf("Failed to hash password: %v", err)
-	}
-
-	// Test correct password
-	err = authService.ComparePassword(hashedPassword, password)
-	if err != nil {
-		t.Errorf("Expected no error for correct password, got: %v", err)
-	}
-
-	// Test incorrect password
-	err = authService.ComparePassword(hashedPassword, "wrongpassword")
-	if err == nil {
-		t.Error("Expected error for incorrect password")
-	}
-}
-
 func TestJWTAuthService_GenerateToken(t *testing.T) {
-	authService := NewJWTAuthService()
+	authService := NewJWTAuthService(testJWTConfig)
 	userID := 123
 	email := "test@example.com"
 
-	token, err := authService.GenerateToken(userID, email)
+	token, err := authService.GenerateToken(userID, email, domain.RoleUser, 0)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -75,12 +41,12 @@ func TestJWTAuthService_GenerateToken(t *testing.T) {
 }
 
 func TestJWTAuthService_ValidateToken(t *testing.T) {
-	authService := NewJWTAuthService()
+	authService := NewJWTAuthService(testJWTConfig)
 	userID := 123
 	email := "test@example.com"
 
 	// Generate a token first
-	token, err := authService.GenerateToken(userID, email)
+	token, err := authService.GenerateToken(userID, email, domain.RoleUser, 3)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -98,10 +64,31 @@ func TestJWTAuthService_ValidateToken(t *testing.T) {
 	if claims.Email != email {
 		t.Errorf("Expected email %s, got %s", email, claims.Email)
 	}
+
+	if claims.TokenVersion != 3 {
+		t.Errorf("Expected token version 3, got %d", claims.TokenVersion)
+	}
+}
+
+func TestJWTAuthService_ValidateToken_Role(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	token, err := authService.GenerateToken(1, "admin@example.com", domain.RoleAdmin, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Unexpected error validating token: %v", err)
+	}
+	if claims.Role != domain.RoleAdmin {
+		t.Errorf("Expected role %s, got %s", domain.RoleAdmin, claims.Role)
+	}
 }
 
 func TestJWTAuthService_ValidateToken_Invalid(t *testing.T) {
-	authService := NewJWTAuthService()
+	authService := NewJWTAuthService(testJWTConfig)
 
 	tests := []struct {
 		name  string
@@ -132,7 +119,7 @@ func TestJWTAuthService_ValidateToken_Invalid(t *testing.T) {
 }
 
 func TestJWTAuthService_TokenRoundTrip(t *testing.T) {
-	authService := NewJWTAuthService()
+	authService := NewJWTAuthService(testJWTConfig)
 
 	testCases := []struct {
 		userID int
@@ -146,7 +133,7 @@ func TestJWTAuthService_TokenRoundTrip(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run("", func(t *testing.T) {
 			// Generate token
-			token, err := authService.GenerateToken(tc.userID, tc.email)
+			token, err := authService.GenerateToken(tc.userID, tc.email, domain.RoleUser, 0)
 			if err != nil {
 				t.Fatalf("Failed to generate token: %v", err)
 			}
@@ -172,3 +159,406 @@ func TestJWTAuthService_Interface(t *testing.T) {
 	// Test that JWTAuthService implements domain.AuthService interface
 	var _ domain.AuthService = &JWTAuthService{}
 }
+
+func TestJWTAuthService_GenerateToken_TokenID(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	first, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	second, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	firstClaims, err := authService.ValidateToken(first)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	secondClaims, err := authService.ValidateToken(second)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+
+	if firstClaims.TokenID == "" {
+		t.Error("Expected token to carry a jti")
+	}
+	if firstClaims.TokenID == secondClaims.TokenID {
+		t.Error("Expected every token to get a unique jti")
+	}
+	if firstClaims.ExpiresAt.IsZero() || firstClaims.IssuedAt.IsZero() {
+		t.Error("Expected iat and exp to be returned")
+	}
+}
+
+func TestNewJWTAuthService_DefaultAccessTTL(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	token, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if ttl := claims.ExpiresAt.Sub(claims.IssuedAt); ttl != DefaultAccessTokenTTL {
+		t.Errorf("Expected default TTL %v, got %v", DefaultAccessTokenTTL, ttl)
+	}
+}
+
+func TestJWTConfig_KeyRetention(t *testing.T) {
+	config := JWTConfig{AccessTTL: 15 * time.Minute, EmailVerificationTTL: 24 * time.Hour, ClockSkew: time.Minute}
+	if retention := config.KeyRetention(); retention != DefaultEmailRevertTTL+time.Minute {
+		t.Errorf("Expected the default email revert TTL plus the clock skew, got %v", retention)
+	}
+
+	config.EmailVerificationTTL = 30 * 24 * time.Hour
+	if retention := config.KeyRetention(); retention != 30*24*time.Hour+time.Minute {
+		t.Errorf("Expected the email verification TTL plus the clock skew, got %v", retention)
+	}
+}
+
+func TestJWTAuthService_ValidateToken_IssuerAndAudience(t *testing.T) {
+	config := testJWTConfig
+	config.Issuer = "be-test"
+	config.Audience = "be-test-api"
+	authService := NewJWTAuthService(config)
+
+	token, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := authService.ValidateToken(token); err != nil {
+		t.Fatalf("Expected token with matching issuer and audience to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config JWTConfig
+	}{
+		{name: "Other issuer", config: JWTConfig{Secret: config.Secret, Issuer: "someone-else", Audience: config.Audience}},
+		{name: "Other audience", config: JWTConfig{Secret: config.Secret, Issuer: config.Issuer, Audience: "other-api"}},
+		{name: "Other secret", config: JWTConfig{Secret: "another-secret-key-that-is-32-bytes", Issuer: config.Issuer, Audience: config.Audience}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewJWTAuthService(tt.config).ValidateToken(token); err != domain.ErrInvalidToken {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuthService_ValidateToken_ClockSkew(t *testing.T) {
+	config := testJWTConfig
+	config.AccessTTL = time.Minute
+	config.ClockSkew = 30 * time.Second
+	authService := NewJWTAuthService(config)
+
+	issuedAt := time.Now()
+	authService.now = func() time.Time { return issuedAt }
+	token, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		isValid bool
+	}{
+		{name: "Issued slightly in the future", now: issuedAt.Add(-20 * time.Second), isValid: true},
+		{name: "Expired within the skew", now: issuedAt.Add(time.Minute + 20*time.Second), isValid: true},
+		{name: "Expired beyond the skew", now: issuedAt.Add(time.Minute + 40*time.Second), isValid: false},
+		{name: "Issued too far in the future", now: issuedAt.Add(-40 * time.Second), isValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService.now = func() time.Time { return tt.now }
+			_, err := authService.ValidateToken(token)
+			if tt.isValid && err != nil {
+				t.Errorf("Expected token to be valid, got %v", err)
+			}
+			if !tt.isValid && err != domain.ErrInvalidToken {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuthService_AsymmetricSigning(t *testing.T) {
+	rsaKey, err := NewRSASigningKey(generateRSAKey(t, 2048))
+	if err != nil {
+		t.Fatalf("Failed to create RSA signing key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  *SigningKey
+	}{
+		{name: "RS256", key: rsaKey},
+		{name: "EdDSA", key: NewEd25519SigningKey(generateEd25519Key(t))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authService := NewJWTAuthService(JWTConfig{Key: tt.key})
+
+			tokenString, err := authService.GenerateToken(7, "test@example.com", domain.RoleAdmin, 0)
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &JWTClaims{})
+			if err != nil {
+				t.Fatalf("Failed to parse token: %v", err)
+			}
+			if token.Header["alg"] != tt.key.Algorithm || token.Header["kid"] != tt.key.ID {
+				t.Errorf("Expected alg %s and kid %s, got %v", tt.key.Algorithm, tt.key.ID, token.Header)
+			}
+
+			claims, err := authService.ValidateToken(tokenString)
+			if err != nil {
+				t.Fatalf("Failed to validate token: %v", err)
+			}
+			if claims.UserID != 7 || claims.Role != domain.RoleAdmin {
+				t.Errorf("Unexpected claims: %+v", claims)
+			}
+
+			keys := authService.PublicKeys()
+			if len(keys) != 1 || keys[0].KeyID != tt.key.ID {
+				t.Errorf("Expected the signing key to be published, got %+v", keys)
+			}
+		})
+	}
+}
+
+func TestJWTAuthService_ValidateToken_RejectsForeignKeys(t *testing.T) {
+	key := NewEd25519SigningKey(generateEd25519Key(t))
+	authService := NewJWTAuthService(JWTConfig{Key: key})
+
+	otherKey := NewEd25519SigningKey(generateEd25519Key(t))
+	otherToken, err := NewJWTAuthService(JWTConfig{Key: otherKey}).GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+
+	// A token whose kid names our key but which is signed by another key
+	forged := jwt.NewWithClaims(jwt.SigningMethodEdDSA, JWTClaims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}})
+	forged.Header["kid"] = key.ID
+	forgedToken, err := forged.SignedString(otherKey.signKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	// HS256 signed with the public key, the classic algorithm confusion attack
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}})
+	confused.Header["kid"] = key.ID
+	confusedToken, err := confused.SignedString([]byte(key.verifyKey.(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	for name, token := range map[string]string{
+		"Unknown kid":         otherToken,
+		"Wrong signature":     forgedToken,
+		"Algorithm confusion": confusedToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := authService.ValidateToken(token); err != domain.ErrInvalidToken {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTAuthService_ValidateToken_WithoutKeyID(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	// Tokens issued before kids were introduced
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{UserID: 5, RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}})
+	token, err := legacy.SignedString([]byte(testJWTConfig.Secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected a token without kid to be accepted, got %v", err)
+	}
+	if claims.UserID != 5 {
+		t.Errorf("Expected user ID 5, got %d", claims.UserID)
+	}
+	if keys := authService.PublicKeys(); len(keys) != 0 {
+		t.Errorf("Expected no public keys for a shared secret, got %+v", keys)
+	}
+}
+
+func TestJWTAuthService_MFAChallenge(t *testing.T) {
+	config := testJWTConfig
+	config.MFAChallengeTTL = time.Minute
+	authService := NewJWTAuthService(config)
+
+	challenge, err := authService.GenerateMFAChallenge(42)
+	if err != nil {
+		t.Fatalf("Failed to generate MFA challenge: %v", err)
+	}
+	challengeClaims, err := authService.ValidateMFAChallenge(challenge)
+	if err != nil {
+		t.Fatalf("Expected valid MFA challenge, got %v", err)
+	}
+	if challengeClaims.UserID != 42 {
+		t.Errorf("Expected user ID 42, got %d", challengeClaims.UserID)
+	}
+	if challengeClaims.TokenID == "" || challengeClaims.ExpiresAt.IsZero() {
+		t.Errorf("Expected the challenge to have an ID and an expiry, got %+v", challengeClaims)
+	}
+
+	// A challenge is not an access token and vice versa
+	if _, err := authService.ValidateToken(challenge); err != domain.ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for an MFA challenge, got %v", err)
+	}
+	token, err := authService.GenerateToken(42, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := authService.ValidateMFAChallenge(token); err != domain.ErrInvalidMFAToken {
+		t.Errorf("Expected ErrInvalidMFAToken for an access token, got %v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(challenge, claims); err != nil {
+		t.Fatalf("Failed to parse challenge: %v", err)
+	}
+	exp, _ := claims.GetExpirationTime()
+	iat, _ := claims.GetIssuedAt()
+	if ttl := exp.Sub(iat.Time); ttl != time.Minute {
+		t.Errorf("Expected challenge TTL %v, got %v", time.Minute, ttl)
+	}
+}
+
+func TestJWTAuthService_EmailVerificationToken(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	token, err := authService.GenerateEmailVerificationToken(42, "test@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate email verification token: %v", err)
+	}
+	userID, email, err := authService.ValidateEmailVerificationToken(token)
+	if err != nil {
+		t.Fatalf("Expected valid email verification token, got %v", err)
+	}
+	if userID != 42 || email != "test@example.com" {
+		t.Errorf("Expected user 42 and test@example.com, got %d and %s", userID, email)
+	}
+
+	// Neither an access token nor an MFA challenge
+	if _, err := authService.ValidateToken(token); err != domain.ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for an email verification token, got %v", err)
+	}
+	if _, err := authService.ValidateMFAChallenge(token); err != domain.ErrInvalidMFAToken {
+		t.Errorf("Expected ErrInvalidMFAToken for an email verification token, got %v", err)
+	}
+	challenge, err := authService.GenerateMFAChallenge(42)
+	if err != nil {
+		t.Fatalf("Failed to generate MFA challenge: %v", err)
+	}
+	if _, _, err := authService.ValidateEmailVerificationToken(challenge); err != domain.ErrInvalidVerificationToken {
+		t.Errorf("Expected ErrInvalidVerificationToken for an MFA challenge, got %v", err)
+	}
+}
+
+func TestJWTAuthService_EmailRevertToken(t *testing.T) {
+	authService := NewJWTAuthService(testJWTConfig)
+
+	token, err := authService.GenerateEmailRevertToken(42, "old@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate email revert token: %v", err)
+	}
+	userID, email, err := authService.ValidateEmailRevertToken(token)
+	if err != nil {
+		t.Fatalf("Expected valid email revert token, got %v", err)
+	}
+	if userID != 42 || email != "old@example.com" {
+		t.Errorf("Expected user 42 and old@example.com, got %d and %s", userID, email)
+	}
+
+	// Revert and verification tokens are not interchangeable
+	if _, _, err := authService.ValidateEmailVerificationToken(token); err != domain.ErrInvalidVerificationToken {
+		t.Errorf("Expected ErrInvalidVerificationToken for an email revert token, got %v", err)
+	}
+	verification, err := authService.GenerateEmailVerificationToken(42, "old@example.com")
+	if err != nil {
+		t.Fatalf("Failed to generate email verification token: %v", err)
+	}
+	if _, _, err := authService.ValidateEmailRevertToken(verification); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected ErrInvalidEmailChangeToken for an email verification token, got %v", err)
+	}
+	if _, err := authService.ValidateToken(token); err != domain.ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for an email revert token, got %v", err)
+	}
+}
+
+func TestJWTAuthService_NonAccessTokensAreTyped(t *testing.T) {
+	config := testJWTConfig
+	config.Audience = "be-test-api"
+	authService := NewJWTAuthService(config)
+
+	generators := map[string]func() (string, error){
+		"mfa-challenge+jwt":      func() (string, error) { return authService.GenerateMFAChallenge(42) },
+		"email-verification+jwt": func() (string, error) { return authService.GenerateEmailVerificationToken(42, "test@example.com") },
+		"email-revert+jwt":       func() (string, error) { return authService.GenerateEmailRevertToken(42, "test@example.com") },
+	}
+	for typ, generate := range generators {
+		t.Run(typ, func(t *testing.T) {
+			token, err := generate()
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("Failed to parse token: %v", err)
+			}
+			if parsed.Header["typ"] != typ {
+				t.Errorf("Expected typ %q, got %v", typ, parsed.Header["typ"])
+			}
+
+			// Another service verifying access tokens with the same key and
+			// the access token audience rejects it
+			_, err = jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+				return []byte(config.Secret), nil
+			}, jwt.WithAudience(config.Audience))
+			if err == nil {
+				t.Error("Expected the access token audience check to reject the token")
+			}
+			if _, err := authService.ValidateToken(token); err != domain.ErrInvalidToken {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+
+	// Access tokens keep the plain JWT type and the configured audience
+	token, err := authService.GenerateToken(42, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	if _, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+		return []byte(config.Secret), nil
+	}, jwt.WithAudience(config.Audience)); err != nil {
+		t.Errorf("Expected the access token to pass the audience check, got %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package infrastructure
 
 import (
+	"context"
 	"database/sql"
 	"log"
 
@@ -11,61 +12,61 @@ import (
 type DatabaseConfig struct {
 	Driver string
 	DSN    string
+	// SkipMigrations disables applying pending migrations on startup,
+	// for deployments that run "migrate up" as a separate step
+	SkipMigrations bool
+	// PhoneRegion is the region migrations read stored phone numbers
+	// without a country code in
+	PhoneRegion string
 }
 
-// NewDatabase creates a new database connection
+// NewDatabase creates a new database connection and applies pending migrations
 func NewDatabase(config DatabaseConfig) (*sql.DB, error) {
-	db, err := sql.Open(config.Driver, config.DSN)
+	db, err := OpenDatabase(config)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = db.Ping(); err != nil {
+	if !config.SkipMigrations {
+		if err = migrate(db, config); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	log.Println("Database initialized successfully")
+	return db, nil
+}
+
+// OpenDatabase opens and pings a database connection without touching the schema
+func OpenDatabase(config DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
 		return nil, err
 	}
 
-	// Create tables
-	if err = createTables(db); err != nil {
+	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	log.Println("Database initialized successfully")
 	return db, nil
 }
 
-// createTables creates the necessary database tables
-func createTables(db *sql.DB) error {
-	createUsersTable := `
-		CREATE TABLE IF NOT EXISTS users (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			email TEXT UNIQUE NOT NULL,
-			password TEXT NOT NULL,
-			firstname TEXT NOT NULL,
-			lastname TEXT NOT NULL,
-			phone TEXT NOT NULL,
-			birthday DATE NOT NULL,
-			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-	`
-
-	_, err := db.Exec(createUsersTable)
+// migrate applies the embedded migrations for the driver
+func migrate(db *sql.DB, config DatabaseConfig) error {
+	migrator, err := NewMigrator(db, config.Driver)
 	if err != nil {
 		return err
 	}
+	migrator.PhoneRegion = config.PhoneRegion
 
-	// Create indexes for better performance
-	createIndexes := []string{
-		`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);`,
-		`CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);`,
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return err
 	}
-
-	for _, indexSQL := range createIndexes {
-		_, err = db.Exec(indexSQL)
-		if err != nil {
-			return err
-		}
+	if applied > 0 {
+		log.Printf("Applied %d database migration(s)", applied)
 	}
-
 	return nil
 }
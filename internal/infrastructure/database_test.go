@@ -165,3 +165,17 @@ func TestDatabase_TableStructure(t *testing.T) {
 		}
 	}
 }
+
+func TestDatabase_RefreshTokensTable(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	var tableName string
+	err = db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name='refresh_tokens'").Scan(&tableName)
+	if err != nil {
+		t.Errorf("Refresh tokens table was not created: %v", err)
+	}
+}
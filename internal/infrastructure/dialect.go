@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Supported database drivers
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory" // no database, data is kept in process memory
+)
+
+// rebind rewrites ? placeholders into the $1, $2, ... form used by PostgreSQL.
+// Queries for other drivers are returned unchanged.
+func rebind(driver, query string) string {
+	if driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
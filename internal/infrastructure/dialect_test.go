@@ -0,0 +1,23 @@
+package infrastructure
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	query := `SELECT id FROM users WHERE email = ? AND id <> ?`
+
+	testCases := []struct {
+		driver   string
+		expected string
+	}{
+		{driver: DriverSQLite, expected: query},
+		{driver: DriverPostgres, expected: `SELECT id FROM users WHERE email = $1 AND id <> $2`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.driver, func(t *testing.T) {
+			if got := rebind(tc.driver, query); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
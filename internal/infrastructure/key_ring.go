@@ -0,0 +1,398 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// hmacKeyBytes is the size of the secrets generated for rotated HS256 keys
+const hmacKeyBytes = 32
+
+// minKeyReloadInterval limits how often a token with an unknown kid can
+// trigger a reload of the key ring
+const minKeyReloadInterval = 10 * time.Second
+
+// StoredSigningKey is a signing key as persisted by a SigningKeyStore.
+// PrivateKey holds a PKCS #8 PEM block, or the base64 encoded secret of an
+// HS256 key.
+type StoredSigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey string
+	CreatedAt  time.Time
+	RetiredAt  *time.Time
+	ExpiresAt  *time.Time
+}
+
+// SigningKeyStore persists the keys of a KeyRing
+type SigningKeyStore interface {
+	List(ctx context.Context) ([]StoredSigningKey, error)
+	// Activate retires every active key, to expire at expiresAt, and stores
+	// key as the new active key, atomically
+	Activate(ctx context.Context, key StoredSigningKey, expiresAt time.Time) error
+	// EraseExpired deletes the key material of keys that expired before
+	// now. Their IDs are kept, so a configured key that was rotated out is
+	// recognised and not activated again.
+	EraseExpired(ctx context.Context, now time.Time) error
+}
+
+// ringKey is a key loaded into a KeyRing
+type ringKey struct {
+	key       *SigningKey
+	createdAt time.Time
+	retiredAt *time.Time
+	expiresAt *time.Time
+}
+
+// KeyRing implements domain.SigningKeyService. It holds one active key that
+// signs new tokens and the retired keys that still verify tokens they signed,
+// selected by kid. Retired keys expire once every token they signed has.
+// Keys are kept in memory and reloaded from the store by the background
+// reloader, which picks up rotations made by other instances.
+type KeyRing struct {
+	store SigningKeyStore
+	// retention is how long a retired key keeps verifying tokens: the
+	// longest token lifetime plus the allowed clock skew
+	retention time.Duration
+
+	mu         sync.RWMutex
+	active     *ringKey
+	keys       map[string]*ringKey
+	lastReload time.Time
+
+	stop chan struct{}
+	done chan struct{}
+	now  func() time.Time
+}
+
+// NewKeyRing loads the key ring from the store. The configured key becomes
+// the active key when the store does not know it yet, so a new secret or key
+// file takes over signing while tokens signed with the previous key remain
+// valid until they expire.
+func NewKeyRing(ctx context.Context, store SigningKeyStore, configured *SigningKey, retention time.Duration) (*KeyRing, error) {
+	return newKeyRing(ctx, store, configured, retention, time.Now)
+}
+
+func newKeyRing(ctx context.Context, store SigningKeyStore, configured *SigningKey, retention time.Duration, now func() time.Time) (*KeyRing, error) {
+	r := &KeyRing{
+		store:     store,
+		retention: retention,
+		keys:      make(map[string]*ringKey),
+		now:       now,
+	}
+
+	known, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !known[configured.ID] {
+		if err := r.activate(ctx, configured); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// ActiveKey returns the key new tokens are signed with
+func (r *KeyRing) ActiveKey() *SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active.key
+}
+
+// VerificationKey returns the unexpired key with the given ID. An unknown ID
+// may belong to a key rotated by another instance, so it triggers a reload,
+// at most once every minKeyReloadInterval.
+func (r *KeyRing) VerificationKey(kid string) (*SigningKey, bool) {
+	if key, ok := r.lookup(kid); ok {
+		return key, true
+	}
+
+	r.mu.RLock()
+	stale := r.now().Sub(r.lastReload) >= minKeyReloadInterval
+	r.mu.RUnlock()
+	if !stale {
+		return nil, false
+	}
+	if err := r.Reload(context.Background()); err != nil {
+		log.Printf("Failed to reload signing keys: %v", err)
+		return nil, false
+	}
+	return r.lookup(kid)
+}
+
+func (r *KeyRing) lookup(kid string) (*SigningKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.keys[kid]
+	if !ok || (entry.expiresAt != nil && !r.now().Before(*entry.expiresAt)) {
+		return nil, false
+	}
+	return entry.key, true
+}
+
+// PublicKeys returns the public halves of the unexpired asymmetric keys,
+// newest first
+func (r *KeyRing) PublicKeys() []domain.JSONWebKey {
+	keys := []domain.JSONWebKey{}
+	for _, entry := range r.entries() {
+		if jwk, ok := entry.key.PublicJWK(); ok {
+			keys = append(keys, jwk)
+		}
+	}
+	return keys
+}
+
+// ListSigningKeys describes the unexpired keys, newest first
+func (r *KeyRing) ListSigningKeys(ctx context.Context) ([]domain.SigningKeyInfo, error) {
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	active := r.active
+	r.mu.RUnlock()
+
+	entries := r.entries()
+	infos := make([]domain.SigningKeyInfo, 0, len(entries))
+	for _, entry := range entries {
+		infos = append(infos, entry.info(entry == active))
+	}
+	return infos, nil
+}
+
+// RotateSigningKey generates a new active key with the algorithm of the
+// current one and retires the current one
+func (r *KeyRing) RotateSigningKey(ctx context.Context) (*domain.SigningKeyInfo, error) {
+	key, err := GenerateSigningKey(r.ActiveKey().Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.activate(ctx, key); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	info := r.active.info(true)
+	r.mu.RUnlock()
+	return &info, nil
+}
+
+// activate stores key as the active key and reloads the ring
+func (r *KeyRing) activate(ctx context.Context, key *SigningKey) error {
+	privateKey, err := key.marshalPrivateKey()
+	if err != nil {
+		return err
+	}
+
+	now := r.now().UTC()
+	stored := StoredSigningKey{
+		ID:         key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: privateKey,
+		CreatedAt:  now,
+	}
+	if err := r.store.Activate(ctx, stored, now.Add(r.retention)); err != nil {
+		return err
+	}
+	return r.Reload(ctx)
+}
+
+// entries returns the unexpired keys, newest first
+func (r *KeyRing) entries() []*ringKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	entries := make([]*ringKey, 0, len(r.keys))
+	for _, entry := range r.keys {
+		if entry.expiresAt == nil || now.Before(*entry.expiresAt) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].createdAt.After(entries[j].createdAt)
+	})
+	return entries
+}
+
+// Reload replaces the keys with the unexpired keys in the store. The newest
+// key that has not been retired becomes the active key.
+func (r *KeyRing) Reload(ctx context.Context) error {
+	_, err := r.load(ctx)
+	return err
+}
+
+// load reloads the ring when the store has an active key and returns the IDs
+// of every stored key, expired or not
+func (r *KeyRing) load(ctx context.Context) (map[string]bool, error) {
+	stored, err := r.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	known := make(map[string]bool, len(stored))
+	keys := make(map[string]*ringKey, len(stored))
+	var active *ringKey
+	for _, s := range stored {
+		known[s.ID] = true
+		if s.PrivateKey == "" || (s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)) {
+			continue
+		}
+
+		key, err := parseStoredSigningKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", s.ID, err)
+		}
+		entry := &ringKey{key: key, createdAt: s.CreatedAt, retiredAt: s.RetiredAt, expiresAt: s.ExpiresAt}
+		keys[s.ID] = entry
+		if s.RetiredAt == nil && (active == nil || entry.createdAt.After(active.createdAt)) {
+			active = entry
+		}
+	}
+
+	if active == nil {
+		if len(known) == 0 && r.active == nil {
+			// Nothing to sign with yet; NewKeyRing activates the configured key
+			return known, nil
+		}
+		return nil, errors.New("no active signing key in the key store")
+	}
+
+	r.mu.Lock()
+	r.active = active
+	r.keys = keys
+	r.lastReload = now
+	r.mu.Unlock()
+	return known, nil
+}
+
+// PruneExpired erases the keys whose tokens have all expired
+func (r *KeyRing) PruneExpired(ctx context.Context) error {
+	return r.store.EraseExpired(ctx, r.now().UTC())
+}
+
+// StartReloader prunes expired keys and reloads the ring every interval
+// until Close is called
+func (r *KeyRing) StartReloader(interval time.Duration) {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				ctx := context.Background()
+				if err := r.PruneExpired(ctx); err != nil {
+					log.Printf("Failed to prune signing keys: %v", err)
+				}
+				if err := r.Reload(ctx); err != nil {
+					log.Printf("Failed to reload signing keys: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the background reloader, if it was started
+func (r *KeyRing) Close() error {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop = nil
+	}
+	return nil
+}
+
+func (e *ringKey) info(active bool) domain.SigningKeyInfo {
+	return domain.SigningKeyInfo{
+		ID:        e.key.ID,
+		Algorithm: e.key.Algorithm,
+		CreatedAt: e.createdAt,
+		RetiredAt: e.retiredAt,
+		ExpiresAt: e.expiresAt,
+		Active:    active,
+	}
+}
+
+// GenerateSigningKey creates a random key for the algorithm
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	switch algorithm {
+	case AlgorithmHS256:
+		secret := make([]byte, hmacKeyBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return NewHMACSigningKey(secret), nil
+	case AlgorithmRS256:
+		privateKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		return NewRSASigningKey(privateKey)
+	case AlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return NewEd25519SigningKey(privateKey), nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+}
+
+// marshalPrivateKey encodes the key for a SigningKeyStore
+func (k *SigningKey) marshalPrivateKey() (string, error) {
+	if secret, ok := k.signKey.([]byte); ok {
+		return base64.StdEncoding.EncodeToString(secret), nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(k.signKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// parseStoredSigningKey decodes a key written by marshalPrivateKey and checks
+// it matches its stored ID and algorithm
+func parseStoredSigningKey(stored StoredSigningKey) (*SigningKey, error) {
+	var key *SigningKey
+	if stored.Algorithm == AlgorithmHS256 {
+		secret, err := base64.StdEncoding.DecodeString(stored.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		key = NewHMACSigningKey(secret)
+	} else {
+		var err error
+		if key, err = ParseSigningKeyPEM([]byte(stored.PrivateKey)); err != nil {
+			return nil, err
+		}
+	}
+
+	if key.ID != stored.ID || key.Algorithm != stored.Algorithm {
+		return nil, fmt.Errorf("stored key does not match its id or algorithm %s", stored.Algorithm)
+	}
+	return key, nil
+}
@@ -0,0 +1,239 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// fakeClock is a settable time source shared by a key ring and an auth service
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestKeyRing(t *testing.T, store SigningKeyStore, configured *SigningKey, clock *fakeClock) *KeyRing {
+	ring, err := newKeyRing(context.Background(), store, configured, time.Hour, clock.Now)
+	if err != nil {
+		t.Fatalf("Failed to create key ring: %v", err)
+	}
+	return ring
+}
+
+func newKeyRingAuthService(ring *KeyRing, clock *fakeClock) *JWTAuthService {
+	authService := NewJWTAuthService(JWTConfig{KeyRing: ring, AccessTTL: 30 * time.Minute})
+	authService.now = clock.Now
+	return authService
+}
+
+func TestKeyRing_RotationKeepsOldTokensValidUntilTheyExpire(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	configured := NewHMACSigningKey([]byte(testJWTConfig.Secret))
+	ring := newTestKeyRing(t, NewMemorySigningKeyStore(), configured, clock)
+	authService := newKeyRingAuthService(ring, clock)
+
+	oldToken, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	rotated, err := ring.RotateSigningKey(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rotated.ID == configured.ID || !rotated.Active || rotated.Algorithm != AlgorithmHS256 {
+		t.Errorf("Expected a new active HS256 key, got %+v", rotated)
+	}
+	if ring.ActiveKey().ID != rotated.ID {
+		t.Errorf("Expected new tokens to be signed with %s, got %s", rotated.ID, ring.ActiveKey().ID)
+	}
+
+	newToken, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := authService.ValidateToken(token); err != nil {
+			t.Errorf("Expected the %s token to be valid after rotation, got %v", name, err)
+		}
+	}
+
+	keys, err := ring.ListSigningKeys(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 2 || !keys[0].Active || keys[1].ID != configured.ID {
+		t.Fatalf("Expected the new key followed by the retired one, got %+v", keys)
+	}
+	if keys[1].RetiredAt == nil || keys[1].ExpiresAt == nil || !keys[1].ExpiresAt.Equal(keys[1].RetiredAt.Add(time.Hour)) {
+		t.Errorf("Expected the retired key to expire after the retention period, got %+v", keys[1])
+	}
+
+	// Once the retention period has passed the retired key is gone
+	clock.now = clock.now.Add(time.Hour + time.Second)
+	if _, ok := ring.VerificationKey(configured.ID); ok {
+		t.Error("Expected the retired key to have expired")
+	}
+	if err := ring.PruneExpired(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	keys, err = ring.ListSigningKeys(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != rotated.ID {
+		t.Errorf("Expected only the active key to be left, got %+v", keys)
+	}
+}
+
+func TestKeyRing_RotationKeepsEmailRevertTokensValid(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	config := JWTConfig{AccessTTL: 30 * time.Minute, EmailRevertTTL: 7 * 24 * time.Hour, ClockSkew: 30 * time.Second}
+	ring, err := newKeyRing(ctx, NewMemorySigningKeyStore(), NewHMACSigningKey([]byte(testJWTConfig.Secret)), config.KeyRetention(), clock.Now)
+	if err != nil {
+		t.Fatalf("Failed to create key ring: %v", err)
+	}
+	config.KeyRing = ring
+	authService := NewJWTAuthService(config)
+	authService.now = clock.Now
+
+	token, err := authService.GenerateEmailRevertToken(42, "old@example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ring.RotateSigningKey(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The retired key outlives access tokens for as long as the revert link works
+	clock.now = clock.now.Add(6 * 24 * time.Hour)
+	if err := ring.PruneExpired(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	userID, email, err := authService.ValidateEmailRevertToken(token)
+	if err != nil {
+		t.Fatalf("Expected the revert token to be valid after rotation, got %v", err)
+	}
+	if userID != 42 || email != "old@example.com" {
+		t.Errorf("Expected user 42 and old@example.com, got %d and %s", userID, email)
+	}
+}
+
+func TestKeyRing_ConfiguredKey(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	store := NewMemorySigningKeyStore()
+	configured := NewHMACSigningKey([]byte(testJWTConfig.Secret))
+
+	ring := newTestKeyRing(t, store, configured, clock)
+	if ring.ActiveKey().ID != configured.ID {
+		t.Fatalf("Expected the configured key to be activated, got %s", ring.ActiveKey().ID)
+	}
+	clock.now = clock.now.Add(time.Minute)
+	rotated, err := ring.RotateSigningKey(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Restarting with the same configuration keeps the rotated key, even
+	// after the configured key has expired and been erased
+	if restarted := newTestKeyRing(t, store, configured, clock); restarted.ActiveKey().ID != rotated.ID {
+		t.Errorf("Expected the rotated key to stay active, got %s", restarted.ActiveKey().ID)
+	}
+	if err := store.EraseExpired(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restarted := newTestKeyRing(t, store, configured, clock); restarted.ActiveKey().ID != rotated.ID {
+		t.Errorf("Expected the erased configured key not to be reactivated, got %s", restarted.ActiveKey().ID)
+	}
+
+	// A newly configured key takes over and the previous key is retired
+	replacement := NewEd25519SigningKey(generateEd25519Key(t))
+	clock.now = clock.now.Add(time.Minute)
+	restarted := newTestKeyRing(t, store, replacement, clock)
+	if restarted.ActiveKey().ID != replacement.ID {
+		t.Errorf("Expected the new configured key to be active, got %s", restarted.ActiveKey().ID)
+	}
+	if _, ok := restarted.VerificationKey(rotated.ID); !ok {
+		t.Error("Expected the previous key to keep verifying tokens")
+	}
+	if keys := restarted.PublicKeys(); len(keys) != 1 || keys[0].KeyID != replacement.ID {
+		t.Errorf("Expected only the Ed25519 key to be published, got %+v", keys)
+	}
+}
+
+func TestKeyRing_PicksUpRotationsByOtherInstances(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLSigningKeyStore(db, DriverSQLite, nil)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	clock := &fakeClock{now: time.Now()}
+	configured := NewEd25519SigningKey(generateEd25519Key(t))
+	first := newTestKeyRing(t, store, configured, clock)
+	second := newTestKeyRing(t, store, configured, clock)
+
+	clock.now = clock.now.Add(time.Second)
+	if _, err := first.RotateSigningKey(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	token, err := newKeyRingAuthService(first, clock).GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The second instance has just loaded the ring and does not reload yet
+	secondAuth := newKeyRingAuthService(second, clock)
+	if _, err := secondAuth.ValidateToken(token); err != domain.ErrInvalidToken {
+		t.Errorf("Expected the unknown key to be rejected right after a reload, got %v", err)
+	}
+
+	clock.now = clock.now.Add(minKeyReloadInterval)
+	if _, err := secondAuth.ValidateToken(token); err != nil {
+		t.Errorf("Expected the rotated key to be picked up, got %v", err)
+	}
+	if second.ActiveKey().ID != first.ActiveKey().ID {
+		t.Errorf("Expected both instances to sign with %s, got %s", first.ActiveKey().ID, second.ActiveKey().ID)
+	}
+}
+
+func TestGenerateSigningKey_StoredRoundTrip(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateSigningKey(algorithm)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			privateKey, err := key.marshalPrivateKey()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			parsed, err := parseStoredSigningKey(StoredSigningKey{ID: key.ID, Algorithm: algorithm, PrivateKey: privateKey})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if parsed.ID != key.ID || parsed.Algorithm != algorithm {
+				t.Errorf("Expected key %s (%s), got %s (%s)", key.ID, algorithm, parsed.ID, parsed.Algorithm)
+			}
+
+			if _, err := parseStoredSigningKey(StoredSigningKey{ID: "other", Algorithm: algorithm, PrivateKey: privateKey}); err == nil {
+				t.Error("Expected a key stored under another ID to be rejected")
+			}
+		})
+	}
+
+	if _, err := GenerateSigningKey("none"); err == nil {
+		t.Error("Expected an unsupported algorithm to be rejected")
+	}
+}
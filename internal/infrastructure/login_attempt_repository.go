@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// SQLLoginAttemptRepository implements domain.LoginAttemptRepository using
+// the SQL database (SQLite or PostgreSQL), so that every instance sharing
+// the database counts the same failures
+type SQLLoginAttemptRepository struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLLoginAttemptRepository creates a new SQL login attempt repository
+func NewSQLLoginAttemptRepository(db *sql.DB, driver string) domain.LoginAttemptRepository {
+	return &SQLLoginAttemptRepository{db: db, driver: driver}
+}
+
+// Get returns the attempts for the key, or nil if there are none
+func (r *SQLLoginAttemptRepository) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	query := `SELECT attempt_key, failures, last_failed_at, locked_until FROM login_attempts WHERE attempt_key = ?`
+
+	attempts := &domain.LoginAttempts{}
+	var lockedUntil sql.NullTime
+	err := r.db.QueryRowContext(ctx, rebind(r.driver, query), key).Scan(
+		&attempts.Key, &attempts.Failures, &attempts.LastFailedAt, &lockedUntil,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		attempts.LockedUntil = &lockedUntil.Time
+	}
+	return attempts, nil
+}
+
+// RecordFailure counts a failed login. The count is incremented in a
+// single statement, so concurrent failures are all counted.
+func (r *SQLLoginAttemptRepository) RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (*domain.LoginAttempts, error) {
+	query := `
+		INSERT INTO login_attempts (attempt_key, failures, last_failed_at) VALUES (?, 1, ?)
+		ON CONFLICT(attempt_key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failed_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failed_at = excluded.last_failed_at
+	`
+	if _, err := r.db.ExecContext(ctx, rebind(r.driver, query), key, failedAt.UTC(), resetBefore.UTC()); err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, key)
+}
+
+// Lock refuses logins for the key until the given time
+func (r *SQLLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until = ? WHERE attempt_key = ?`
+	_, err := r.db.ExecContext(ctx, rebind(r.driver, query), until.UTC(), key)
+	return err
+}
+
+// Reset forgets the failures and the lock of the key
+func (r *SQLLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, rebind(r.driver, `DELETE FROM login_attempts WHERE attempt_key = ?`), key)
+	return err
+}
+
+// DeleteStale removes the keys whose last failure and lock both ended before the cut-off
+func (r *SQLLoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (int, error) {
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failed_at < ? AND (locked_until IS NULL OR locked_until < ?)
+	`
+	result, err := r.db.ExecContext(ctx, rebind(r.driver, query), before.UTC(), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// loginAttemptRepositories returns every login attempt repository implementation under test
+func loginAttemptRepositories(t *testing.T) map[string]domain.LoginAttemptRepository {
+	db := setupRefreshTokenTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	return map[string]domain.LoginAttemptRepository{
+		"SQLite": NewSQLLoginAttemptRepository(db, DriverSQLite),
+		"Memory": NewMemoryLoginAttemptRepository(),
+	}
+}
+
+func TestLoginAttemptRepository_RecordFailure(t *testing.T) {
+	for name, repo := range loginAttemptRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+
+			if attempts, err := repo.Get(ctx, "account:a@example.com"); err != nil || attempts != nil {
+				t.Fatalf("Expected no attempts, got %+v, %v", attempts, err)
+			}
+
+			for i := 1; i <= 3; i++ {
+				attempts, err := repo.RecordFailure(ctx, "account:a@example.com", now.Add(time.Duration(i)*time.Second), now.Add(-time.Hour))
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if attempts.Failures != i {
+					t.Errorf("Expected %d failures, got %d", i, attempts.Failures)
+				}
+			}
+
+			// Counting starts over once the last failure is outside the window
+			later := now.Add(2 * time.Hour)
+			attempts, err := repo.RecordFailure(ctx, "account:a@example.com", later, later.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if attempts.Failures != 1 || !attempts.LastFailedAt.Equal(later) {
+				t.Errorf("Expected 1 failure at %v, got %d at %v", later, attempts.Failures, attempts.LastFailedAt)
+			}
+
+			if other, _ := repo.Get(ctx, "ip:192.0.2.1"); other != nil {
+				t.Errorf("Expected other keys to be unaffected, got %+v", other)
+			}
+		})
+	}
+}
+
+func TestLoginAttemptRepository_LockAndReset(t *testing.T) {
+	for name, repo := range loginAttemptRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+
+			if _, err := repo.RecordFailure(ctx, "ip:192.0.2.1", now, now.Add(-time.Hour)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			until := now.Add(time.Minute)
+			if err := repo.Lock(ctx, "ip:192.0.2.1", until); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			attempts, err := repo.Get(ctx, "ip:192.0.2.1")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if attempts.LockedUntil == nil || !attempts.LockedUntil.Equal(until) {
+				t.Fatalf("Expected lock until %v, got %v", until, attempts.LockedUntil)
+			}
+			if !attempts.IsLocked(now) || attempts.IsLocked(until) {
+				t.Error("Expected the key to be locked until the lock ends")
+			}
+
+			if err := repo.Reset(ctx, "ip:192.0.2.1"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if attempts, _ := repo.Get(ctx, "ip:192.0.2.1"); attempts != nil {
+				t.Errorf("Expected attempts to be forgotten, got %+v", attempts)
+			}
+		})
+	}
+}
+
+func TestLoginAttemptRepository_DeleteStale(t *testing.T) {
+	for name, repo := range loginAttemptRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+			old := now.Add(-2 * time.Hour)
+
+			for _, key := range []string{"stale", "locked", "recent"} {
+				failedAt := old
+				if key == "recent" {
+					failedAt = now
+				}
+				if _, err := repo.RecordFailure(ctx, key, failedAt, failedAt.Add(-time.Hour)); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if err := repo.Lock(ctx, "locked", now.Add(time.Hour)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			deleted, err := repo.DeleteStale(ctx, now.Add(-time.Hour))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if deleted != 1 {
+				t.Errorf("Expected 1 key to be deleted, got %d", deleted)
+			}
+			if attempts, _ := repo.Get(ctx, "stale"); attempts != nil {
+				t.Error("Expected the stale key to be deleted")
+			}
+			for _, key := range []string{"locked", "recent"} {
+				if attempts, _ := repo.Get(ctx, key); attempts == nil {
+					t.Errorf("Expected %s to be kept", key)
+				}
+			}
+		})
+	}
+}
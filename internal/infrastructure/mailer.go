@@ -0,0 +1,173 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// Supported mail drivers
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverLog  = "log" // emails are written out instead of sent, for development
+)
+
+// mailQueueSize is how many emails AsyncMailer buffers before Send blocks
+const mailQueueSize = 100
+
+// errMailerClosed is returned when sending through a closed AsyncMailer
+var errMailerClosed = errors.New("mailer is closed")
+
+// SMTPConfig holds the settings used to deliver emails through an SMTP server
+type SMTPConfig struct {
+	Host string
+	Port string
+	// Username and Password authenticate with PLAIN auth; leave Username
+	// empty for servers that accept mail without authentication
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer implements domain.Mailer by delivering emails to an SMTP server.
+// The connection is upgraded with STARTTLS when the server supports it.
+type SMTPMailer struct {
+	config SMTPConfig
+	send   func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+	now    func() time.Time
+}
+
+// NewSMTPMailer creates a mailer that sends through the configured SMTP server
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config, send: smtp.SendMail, now: time.Now}
+}
+
+// Send delivers a plain text email
+func (m *SMTPMailer) Send(ctx context.Context, message domain.EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg, err := formatEmail(m.config.From, message, m.now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	return m.send(addr, auth, m.config.From, []string{message.To}, msg)
+}
+
+// formatEmail renders an RFC 5322 message. Header values must not contain
+// line breaks, which would let them inject further headers.
+func formatEmail(from string, message domain.EmailMessage, date time.Time) ([]byte, error) {
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("email header contains a line break")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
+
+// LogMailer implements domain.Mailer by writing emails to a writer, such as
+// a file or the console, so flows that send emails can be tried locally
+type LogMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogMailer creates a mailer that writes every email to w
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{w: w}
+}
+
+// Send writes the email out
+func (m *LogMailer) Send(ctx context.Context, message domain.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- email -----\nTo: %s\nSubject: %s\n\n%s\n----- end of email -----\n",
+		message.To, message.Subject, message.Body)
+	return err
+}
+
+// AsyncMailer delivers emails in the background through another mailer.
+// Callers neither wait for a slow mail server nor learn about delivery
+// failures, which are logged instead.
+type AsyncMailer struct {
+	mailer domain.Mailer
+	queue  chan domain.EmailMessage
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAsyncMailer starts delivering queued emails through mailer until Close is called
+func NewAsyncMailer(mailer domain.Mailer) *AsyncMailer {
+	m := &AsyncMailer{
+		mailer: mailer,
+		queue:  make(chan domain.EmailMessage, mailQueueSize),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(m.done)
+		for message := range m.queue {
+			if err := m.mailer.Send(context.Background(), message); err != nil {
+				log.Printf("Failed to send email %q: %v", message.Subject, err)
+			}
+		}
+	}()
+	return m
+}
+
+// Send queues the email for delivery. It only waits if the queue is full.
+func (m *AsyncMailer) Send(ctx context.Context, message domain.EmailMessage) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return errMailerClosed
+	}
+	select {
+	case m.queue <- message:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting emails and waits for the queued ones to be delivered
+func (m *AsyncMailer) Close() error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	<-m.done
+	return nil
+}
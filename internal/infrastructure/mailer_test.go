@@ -0,0 +1,115 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: "587", Username: "user", Password: "secret", From: "no-reply@example.com"})
+	mailer.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	var addr, from string
+	var to []string
+	var msg []byte
+	var auth smtp.Auth
+	mailer.send = func(a string, au smtp.Auth, f string, t []string, m []byte) error {
+		addr, auth, from, to, msg = a, au, f, t, m
+		return nil
+	}
+
+	err := mailer.Send(context.Background(), domain.EmailMessage{To: "user@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if addr != "smtp.example.com:587" || from != "no-reply@example.com" || len(to) != 1 || to[0] != "user@example.com" {
+		t.Errorf("Unexpected envelope: addr=%s from=%s to=%v", addr, from, to)
+	}
+	if auth == nil {
+		t.Error("Expected PLAIN auth with a username")
+	}
+	for _, expected := range []string{
+		"From: no-reply@example.com\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Hello\r\n",
+		"Content-Type: text/plain; charset=UTF-8\r\n",
+		"\r\n\r\nLine one\r\nLine two",
+	} {
+		if !strings.Contains(string(msg), expected) {
+			t.Errorf("Expected message to contain %q, got %q", expected, msg)
+		}
+	}
+}
+
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	mailer := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Port: "25", From: "no-reply@example.com"})
+	mailer.send = func(string, smtp.Auth, string, []string, []byte) error {
+		t.Fatal("Expected no email to be sent")
+		return nil
+	}
+
+	err := mailer.Send(context.Background(), domain.EmailMessage{To: "user@example.com", Subject: "Hello\r\nBcc: victim@example.com", Body: "Hi"})
+	if err == nil {
+		t.Fatal("Expected a subject with a line break to be rejected")
+	}
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	var buf bytes.Buffer
+	mailer := NewLogMailer(&buf)
+
+	if err := mailer.Send(context.Background(), domain.EmailMessage{To: "user@example.com", Subject: "Hello", Body: "Open https://example.com"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"To: user@example.com", "Subject: Hello", "Open https://example.com"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, buf.String())
+		}
+	}
+}
+
+// recordingMailer keeps the emails it is asked to send and fails those with the subject "fail"
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []domain.EmailMessage
+}
+
+func (m *recordingMailer) Send(ctx context.Context, message domain.EmailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, message)
+	if message.Subject == "fail" {
+		return errors.New("delivery failed")
+	}
+	return nil
+}
+
+func TestAsyncMailer(t *testing.T) {
+	inner := &recordingMailer{}
+	mailer := NewAsyncMailer(inner)
+
+	for _, subject := range []string{"one", "fail", "two"} {
+		if err := mailer.Send(context.Background(), domain.EmailMessage{To: "user@example.com", Subject: subject}); err != nil {
+			t.Fatalf("Expected delivery failures not to reach the caller, got %v", err)
+		}
+	}
+
+	// Close waits for the queued emails to be delivered
+	mailer.Close()
+	if len(inner.sent) != 3 {
+		t.Fatalf("Expected 3 emails to be delivered, got %d", len(inner.sent))
+	}
+
+	if err := mailer.Send(context.Background(), domain.EmailMessage{To: "user@example.com"}); err == nil {
+		t.Error("Expected sending through a closed mailer to fail")
+	}
+}
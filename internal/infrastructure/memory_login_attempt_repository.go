@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryLoginAttemptRepository implements domain.LoginAttemptRepository in memory
+type MemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]domain.LoginAttempts
+}
+
+// NewMemoryLoginAttemptRepository creates a new, empty in-memory login attempt repository
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{attempts: make(map[string]domain.LoginAttempts)}
+}
+
+// Get returns the attempts for the key, or nil if there are none
+func (r *MemoryLoginAttemptRepository) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.get(key), nil
+}
+
+// RecordFailure counts a failed login
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (*domain.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok {
+		attempts = domain.LoginAttempts{Key: key}
+	}
+	if attempts.LastFailedAt.Before(resetBefore) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailedAt = failedAt
+	r.attempts[key] = attempts
+	return r.get(key), nil
+}
+
+// Lock refuses logins for the key until the given time
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempts, ok := r.attempts[key]; ok {
+		attempts.LockedUntil = &until
+		r.attempts[key] = attempts
+	}
+	return nil
+}
+
+// Reset forgets the failures and the lock of the key
+func (r *MemoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// DeleteStale removes the keys whose last failure and lock both ended before the cut-off
+func (r *MemoryLoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for key, attempts := range r.attempts {
+		if attempts.LastFailedAt.Before(before) && (attempts.LockedUntil == nil || attempts.LockedUntil.Before(before)) {
+			delete(r.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// get returns a copy of the attempts for the key, or nil
+func (r *MemoryLoginAttemptRepository) get(key string) *domain.LoginAttempts {
+	attempts, ok := r.attempts[key]
+	if !ok {
+		return nil
+	}
+	if attempts.LockedUntil != nil {
+		lockedUntil := *attempts.LockedUntil
+		attempts.LockedUntil = &lockedUntil
+	}
+	return &attempts
+}
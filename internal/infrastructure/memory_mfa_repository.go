@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// memoryRecoveryCode is a recovery code stored by MemoryMFARepository
+type memoryRecoveryCode struct {
+	hash string
+	used bool
+}
+
+// MemoryMFARepository implements domain.MFARepository in memory
+type MemoryMFARepository struct {
+	mu            sync.Mutex
+	enrollments   map[int]domain.MFAEnrollment
+	recoveryCodes map[int][]memoryRecoveryCode
+}
+
+// NewMemoryMFARepository creates a new, empty in-memory two-factor authentication repository
+func NewMemoryMFARepository() *MemoryMFARepository {
+	return &MemoryMFARepository{
+		enrollments:   make(map[int]domain.MFAEnrollment),
+		recoveryCodes: make(map[int][]memoryRecoveryCode),
+	}
+}
+
+// GetEnrollment retrieves the user's enrollment
+func (r *MemoryMFARepository) GetEnrollment(ctx context.Context, userID int) (*domain.MFAEnrollment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	if enrollment.ConfirmedAt != nil {
+		confirmedAt := *enrollment.ConfirmedAt
+		enrollment.ConfirmedAt = &confirmedAt
+	}
+	return &enrollment, nil
+}
+
+// SaveEnrollment stores a pending enrollment. A confirmed enrollment is left untouched.
+func (r *MemoryMFARepository) SaveEnrollment(ctx context.Context, enrollment *domain.MFAEnrollment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.enrollments[enrollment.UserID]; ok && existing.IsConfirmed() {
+		return nil
+	}
+	r.enrollments[enrollment.UserID] = domain.MFAEnrollment{
+		UserID:    enrollment.UserID,
+		Secret:    enrollment.Secret,
+		CreatedAt: enrollment.CreatedAt,
+	}
+	return nil
+}
+
+// ConfirmEnrollment confirms a pending enrollment and stores its recovery codes
+func (r *MemoryMFARepository) ConfirmEnrollment(ctx context.Context, userID int, confirmedAt time.Time, step int64, recoveryCodeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok || enrollment.IsConfirmed() {
+		return domain.ErrMFANotEnrolled
+	}
+	enrollment.ConfirmedAt = &confirmedAt
+	enrollment.LastUsedStep = step
+	r.enrollments[userID] = enrollment
+	r.replaceRecoveryCodesLocked(userID, recoveryCodeHashes)
+	return nil
+}
+
+// DeleteEnrollment removes the enrollment and its recovery codes
+func (r *MemoryMFARepository) DeleteEnrollment(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.enrollments, userID)
+	delete(r.recoveryCodes, userID)
+	return nil
+}
+
+// UseStep records an accepted code unless a code of the same or a later step was accepted before
+func (r *MemoryMFARepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	enrollment, ok := r.enrollments[userID]
+	if !ok || enrollment.LastUsedStep >= step {
+		return false, nil
+	}
+	enrollment.LastUsedStep = step
+	r.enrollments[userID] = enrollment
+	return true, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used
+func (r *MemoryMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	codes := r.recoveryCodes[userID]
+	for i := range codes {
+		if codes[i].hash == codeHash && !codes[i].used {
+			codes[i].used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones
+func (r *MemoryMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string, createdAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replaceRecoveryCodesLocked(userID, codeHashes)
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has
+func (r *MemoryMFARepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, code := range r.recoveryCodes[userID] {
+		if !code.used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// replaceRecoveryCodesLocked swaps the user's recovery codes; r.mu must be held
+func (r *MemoryMFARepository) replaceRecoveryCodesLocked(userID int, codeHashes []string) {
+	codes := make([]memoryRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, memoryRecoveryCode{hash: hash})
+	}
+	r.recoveryCodes[userID] = codes
+}
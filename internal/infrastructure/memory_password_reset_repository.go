@@ -0,0 +1,98 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryPasswordResetRepository implements domain.PasswordResetRepository in memory
+type MemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[int]*domain.PasswordResetToken
+	byHash map[string]int
+	nextID int
+}
+
+// NewMemoryPasswordResetRepository creates a new, empty in-memory password reset token repository
+func NewMemoryPasswordResetRepository() *MemoryPasswordResetRepository {
+	return &MemoryPasswordResetRepository{
+		tokens: make(map[int]*domain.PasswordResetToken),
+		byHash: make(map[string]int),
+		nextID: 1,
+	}
+}
+
+// Create stores a new password reset token and assigns its ID
+func (r *MemoryPasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byHash[token.TokenHash]; exists {
+		return domain.ErrInvalidResetToken
+	}
+
+	token.ID = r.nextID
+	r.nextID++
+	stored := *token
+	r.tokens[token.ID] = &stored
+	r.byHash[token.TokenHash] = token.ID
+	return nil
+}
+
+// GetByHash retrieves a password reset token by the hash of its value
+func (r *MemoryPasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidResetToken
+	}
+
+	token := *r.tokens[id]
+	if token.UsedAt != nil {
+		usedAt := *token.UsedAt
+		token.UsedAt = &usedAt
+	}
+	return &token, nil
+}
+
+// MarkUsed flags a password reset token as used, unless it has already been used
+func (r *MemoryPasswordResetRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	return true, nil
+}
+
+// MarkUnused makes a password reset token usable again
+func (r *MemoryPasswordResetRepository) MarkUnused(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.tokens[id]; ok {
+		token.UsedAt = nil
+	}
+	return nil
+}
+
+// InvalidateAllForUser marks every unused password reset token of a user as used
+func (r *MemoryPasswordResetRepository) InvalidateAllForUser(ctx context.Context, userID int, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
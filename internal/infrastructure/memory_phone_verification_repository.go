@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryPhoneVerificationRepository implements domain.PhoneVerificationRepository in memory
+type MemoryPhoneVerificationRepository struct {
+	mu     sync.Mutex
+	codes  map[int]*domain.PhoneVerificationCode
+	nextID int
+}
+
+// NewMemoryPhoneVerificationRepository creates a new, empty in-memory phone verification code repository
+func NewMemoryPhoneVerificationRepository() *MemoryPhoneVerificationRepository {
+	return &MemoryPhoneVerificationRepository{
+		codes:  make(map[int]*domain.PhoneVerificationCode),
+		nextID: 1,
+	}
+}
+
+// Create stores a new phone verification code and assigns its ID, unless
+// max codes were sent to the phone number since the given time
+func (r *MemoryPhoneVerificationRepository) Create(ctx context.Context, code *domain.PhoneVerificationCode, since time.Time, max int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sent := 0
+	for _, stored := range r.codes {
+		if stored.Phone == code.Phone && stored.CreatedAt.After(since) {
+			sent++
+		}
+	}
+	if sent >= max {
+		return false, nil
+	}
+
+	code.ID = r.nextID
+	r.nextID++
+	stored := *code
+	r.codes[code.ID] = &stored
+	return true, nil
+}
+
+// GetLatest retrieves the phone verification code last sent to a user
+func (r *MemoryPhoneVerificationRepository) GetLatest(ctx context.Context, userID int) (*domain.PhoneVerificationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var latest *domain.PhoneVerificationCode
+	for _, stored := range r.codes {
+		if stored.UserID == userID && (latest == nil || stored.ID > latest.ID) {
+			latest = stored
+		}
+	}
+	if latest == nil {
+		return nil, domain.ErrInvalidPhoneCode
+	}
+
+	code := *latest
+	if code.UsedAt != nil {
+		usedAt := *code.UsedAt
+		code.UsedAt = &usedAt
+	}
+	return &code, nil
+}
+
+// RecordAttempt counts an attempt at entering a phone verification code,
+// unless max attempts were already made
+func (r *MemoryPhoneVerificationRepository) RecordAttempt(ctx context.Context, id int, max int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok || code.Attempts >= max {
+		return false, nil
+	}
+	code.Attempts++
+	return true, nil
+}
+
+// MarkUsed flags a phone verification code as used, unless it has already been used
+func (r *MemoryPhoneVerificationRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok || code.UsedAt != nil {
+		return false, nil
+	}
+	code.UsedAt = &usedAt
+	return true, nil
+}
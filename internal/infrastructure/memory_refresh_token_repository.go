@@ -0,0 +1,102 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryRefreshTokenRepository implements domain.RefreshTokenRepository in memory
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[int]*domain.RefreshToken
+	byHash map[string]int
+	nextID int
+}
+
+// NewMemoryRefreshTokenRepository creates a new, empty in-memory refresh token repository
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{
+		tokens: make(map[int]*domain.RefreshToken),
+		byHash: make(map[string]int),
+		nextID: 1,
+	}
+}
+
+// Create stores a new refresh token and assigns its ID
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byHash[token.TokenHash]; exists {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	token.ID = r.nextID
+	r.nextID++
+	stored := *token
+	r.tokens[token.ID] = &stored
+	r.byHash[token.TokenHash] = token.ID
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *MemoryRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.byHash[tokenHash]
+	if !ok {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	token := *r.tokens[id]
+	if token.UsedAt != nil {
+		usedAt := *token.UsedAt
+		token.UsedAt = &usedAt
+	}
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		token.RevokedAt = &revokedAt
+	}
+	return &token, nil
+}
+
+// MarkUsed flags a refresh token as rotated, unless it has already been used
+func (r *MemoryRefreshTokenRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+	token.UsedAt = &usedAt
+	return true, nil
+}
+
+// RevokeFamily revokes every refresh token descending from the same login
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	r.revokeWhere(func(token *domain.RefreshToken) bool { return token.FamilyID == familyID }, revokedAt)
+	return nil
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user
+func (r *MemoryRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error {
+	r.revokeWhere(func(token *domain.RefreshToken) bool { return token.UserID == userID }, revokedAt)
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) revokeWhere(match func(*domain.RefreshToken) bool, revokedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			at := revokedAt
+			token.RevokedAt = &at
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemorySigningKeyStore implements SigningKeyStore in memory
+type MemorySigningKeyStore struct {
+	mu   sync.Mutex
+	keys []StoredSigningKey
+}
+
+// NewMemorySigningKeyStore creates a new, empty in-memory signing key store
+func NewMemorySigningKeyStore() *MemorySigningKeyStore {
+	return &MemorySigningKeyStore{}
+}
+
+// List returns every stored key, oldest first
+func (s *MemorySigningKeyStore) List(ctx context.Context) ([]StoredSigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]StoredSigningKey, len(s.keys))
+	copy(keys, s.keys)
+	return keys, nil
+}
+
+// Activate retires the active keys and stores key as the new active key
+func (s *MemorySigningKeyStore) Activate(ctx context.Context, key StoredSigningKey, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].RetiredAt == nil {
+			retiredAt, expires := key.CreatedAt, expiresAt
+			s.keys[i].RetiredAt = &retiredAt
+			s.keys[i].ExpiresAt = &expires
+		}
+	}
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+// EraseExpired deletes the key material of keys that expired before now
+func (s *MemorySigningKeyStore) EraseExpired(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].ExpiresAt != nil && !now.Before(*s.keys[i].ExpiresAt) {
+			s.keys[i].PrivateKey = ""
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryTokenRevocationStore implements domain.TokenRevocationStore in memory.
+// Expired entries are dropped whenever a new revocation is recorded.
+type MemoryTokenRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]userRevocation
+	now    func() time.Time
+}
+
+// NewMemoryTokenRevocationStore creates a new, empty in-memory revocation store
+func NewMemoryTokenRevocationStore() *MemoryTokenRevocationStore {
+	return &MemoryTokenRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]userRevocation),
+		now:    time.Now,
+	}
+}
+
+// RevokeToken revokes a single access token until it expires
+func (s *MemoryTokenRevocationStore) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeUserTokens revokes every access token of a user issued before the
+// cut-off, rounded down to the precision of iat
+func (s *MemoryTokenRevocationStore) RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	issuedBefore = issuedBefore.Truncate(IssuedAtPrecision)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	s.users[userID] = userRevocation{revokedBefore: issuedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+// IsRevoked reports whether the token was revoked individually or by a user-wide cut-off
+func (s *MemoryTokenRevocationStore) IsRevoked(ctx context.Context, claims *domain.TokenClaims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if claims.TokenID != "" {
+		if _, revoked := s.tokens[claims.TokenID]; revoked {
+			return true, nil
+		}
+	}
+
+	if revocation, ok := s.users[claims.UserID]; ok {
+		// A login right after logging out everywhere is issued at or after
+		// the cut-off and stays valid
+		if claims.IssuedAt.Before(revocation.revokedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// pruneLocked drops entries for tokens that have expired anyway; s.mu must be held
+func (s *MemoryTokenRevocationStore) pruneLocked() {
+	now := s.now()
+	for tokenID, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, tokenID)
+		}
+	}
+	for userID, revocation := range s.users {
+		if !now.Before(revocation.expiresAt) {
+			delete(s.users, userID)
+		}
+	}
+}
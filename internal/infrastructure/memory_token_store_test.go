@@ -0,0 +1,100 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+func TestMemoryRefreshTokenRepository_Rotation(t *testing.T) {
+	repo := NewMemoryRefreshTokenRepository()
+	ctx := context.Background()
+
+	token := newTestRefreshToken("hash-1", "family-1")
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := repo.Create(ctx, newTestRefreshToken("hash-1", "family-1")); err != domain.ErrInvalidRefreshToken {
+		t.Errorf("Expected ErrInvalidRefreshToken for duplicate hash, got %v", err)
+	}
+
+	marked, err := repo.MarkUsed(ctx, token.ID, time.Now())
+	if err != nil || !marked {
+		t.Fatalf("Expected first MarkUsed to succeed, got %v (err: %v)", marked, err)
+	}
+	marked, _ = repo.MarkUsed(ctx, token.ID, time.Now())
+	if marked {
+		t.Error("Expected second MarkUsed to report the token as already used")
+	}
+
+	if err := repo.RevokeFamily(ctx, "family-1", time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	found, err := repo.GetByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if found.UsedAt == nil || found.RevokedAt == nil {
+		t.Errorf("Expected token to be used and revoked, got %+v", found)
+	}
+
+	if _, err := repo.GetByHash(ctx, "missing"); err != domain.ErrInvalidRefreshToken {
+		t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestMemoryTokenRevocationStore(t *testing.T) {
+	store := NewMemoryTokenRevocationStore()
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).Add(400 * time.Millisecond)
+
+	if err := store.RevokeToken(ctx, "jti-1", 1, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := store.RevokeUserTokens(ctx, 2, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		claims   *domain.TokenClaims
+		expected bool
+	}{
+		{name: "Revoked token", claims: &domain.TokenClaims{UserID: 1, TokenID: "jti-1", IssuedAt: now}, expected: true},
+		{name: "Other token", claims: &domain.TokenClaims{UserID: 1, TokenID: "jti-2", IssuedAt: now}, expected: false},
+		{name: "Issued before cut-off", claims: &domain.TokenClaims{UserID: 2, TokenID: "jti-3", IssuedAt: now.Add(-time.Minute)}, expected: true},
+		{name: "Issued after cut-off", claims: &domain.TokenClaims{UserID: 2, TokenID: "jti-4", IssuedAt: now.Add(time.Minute)}, expected: false},
+		{name: "Issued earlier in the second of the cut-off", claims: &domain.TokenClaims{UserID: 2, TokenID: "jti-5", IssuedAt: now.Add(-200 * time.Millisecond)}, expected: true},
+		{name: "Issued later in the second of the cut-off", claims: &domain.TokenClaims{UserID: 2, TokenID: "jti-6", IssuedAt: now.Add(200 * time.Millisecond)}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, err := store.IsRevoked(ctx, tc.claims)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if revoked != tc.expected {
+				t.Errorf("Expected revoked %v, got %v", tc.expected, revoked)
+			}
+		})
+	}
+}
+
+func TestMemoryTokenRevocationStore_PrunesExpired(t *testing.T) {
+	store := NewMemoryTokenRevocationStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	store.RevokeToken(ctx, "expired", 1, now.Add(-time.Minute))
+	store.RevokeToken(ctx, "active", 1, now.Add(time.Hour))
+
+	if _, ok := store.tokens["expired"]; ok {
+		t.Error("Expected expired revocation to be pruned")
+	}
+	if _, ok := store.tokens["active"]; !ok {
+		t.Error("Expected active revocation to be kept")
+	}
+}
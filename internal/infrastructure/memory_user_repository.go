@@ -0,0 +1,302 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MemoryUserRepository implements domain.UserRepository in memory. It has the
+// same semantics as the SQL repositories (emails unique regardless of case,
+// auto-increment IDs that are never reused) and is safe for concurrent use.
+// Data is lost when the process exits.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[int]domain.User
+	// byEmail is keyed by domain.EmailKey, like the email_normalized column
+	byEmail map[string]int
+	nextID  int
+	// verificationSent holds when a verification email was last sent to each user
+	verificationSent map[int]time.Time
+}
+
+// NewMemoryUserRepository creates a new, empty in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:            make(map[int]domain.User),
+		byEmail:          make(map[string]int),
+		nextID:           1,
+		verificationSent: make(map[int]time.Time),
+	}
+}
+
+// Create stores a new user and assigns its ID
+func (r *MemoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byEmail[domain.EmailKey(user.Email)]; exists {
+		return domain.ErrUserAlreadyExists
+	}
+
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+	user.ID = r.nextID
+	r.nextID++
+	r.users[user.ID] = *user
+	r.byEmail[domain.EmailKey(user.Email)] = user.ID
+	return nil
+}
+
+// GetByEmail retrieves a user by email
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byEmail[domain.EmailKey(email)]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	user := r.users[id]
+	if user.IsDeleted() {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsDeleted() {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// Update replaces a stored user, except for its password and token version.
+// Like an SQL UPDATE, updating a user that does not exist is not an error.
+func (r *MemoryUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok || stored.IsDeleted() {
+		return nil
+	}
+	if id, exists := r.byEmail[domain.EmailKey(user.Email)]; exists && id != user.ID {
+		return domain.ErrUserAlreadyExists
+	}
+
+	updated := *user
+	updated.CreatedAt = stored.CreatedAt
+	updated.Password = stored.Password
+	updated.TokenVersion = stored.TokenVersion
+	updated.DeletedAt = nil
+	delete(r.byEmail, domain.EmailKey(stored.Email))
+	r.byEmail[domain.EmailKey(updated.Email)] = updated.ID
+	r.users[updated.ID] = updated
+	return nil
+}
+
+// Delete removes a user by ID
+func (r *MemoryUserRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		delete(r.byEmail, domain.EmailKey(user.Email))
+		delete(r.users, id)
+		delete(r.verificationSent, id)
+	}
+	return nil
+}
+
+// Exists checks if a user with the given email exists
+func (r *MemoryUserRepository) Exists(ctx context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.byEmail[domain.EmailKey(email)]
+	if !exists {
+		return false, nil
+	}
+	user := r.users[id]
+	return !user.IsDeleted(), nil
+}
+
+// List returns one page of users matching the query, ordered like the SQL repositories
+func (r *MemoryUserRepository) List(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	r.mu.RLock()
+	matching := make([]*domain.User, 0, len(r.users))
+	for _, stored := range r.users {
+		user := stored
+		if query.Filter.Matches(&user) {
+			matching = append(matching, &user)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool {
+		return compareUsers(query, matching[i], matching[j]) < 0
+	})
+
+	start := 0
+	if query.After != nil {
+		start = sort.Search(len(matching), func(i int) bool {
+			return compareToCursor(query, matching[i], query.After) > 0
+		})
+	}
+
+	page := &domain.UserPage{Users: matching[start:], Total: len(matching)}
+	if len(page.Users) > query.Limit {
+		page.Users = page.Users[:query.Limit]
+		page.NextCursor = domain.NewUserCursor(query, page.Users[query.Limit-1]).Encode()
+	}
+	return page, nil
+}
+
+// SoftDelete marks an active user as deleted
+func (r *MemoryUserRepository) SoftDelete(ctx context.Context, id int, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsDeleted() {
+		return domain.ErrUserNotFound
+	}
+	user.DeletedAt = &deletedAt
+	r.users[id] = user
+	return nil
+}
+
+// GetDeletedByID retrieves a soft-deleted user by ID
+func (r *MemoryUserRepository) GetDeletedByID(ctx context.Context, id int) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.IsDeleted() {
+		return nil, domain.ErrUserNotFound
+	}
+	deletedAt := *user.DeletedAt
+	user.DeletedAt = &deletedAt
+	return &user, nil
+}
+
+// Restore clears the deletion mark of a soft-deleted user
+func (r *MemoryUserRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.IsDeleted() {
+		return domain.ErrUserNotFound
+	}
+	user.DeletedAt = nil
+	r.users[id] = user
+	return nil
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the cut-off
+func (r *MemoryUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for id, user := range r.users {
+		if user.IsDeleted() && user.DeletedAt.Before(deletedBefore) {
+			delete(r.byEmail, domain.EmailKey(user.Email))
+			delete(r.users, id)
+			delete(r.verificationSent, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// RecordVerificationSent notes that a verification email is sent, unless one was sent after notBefore
+func (r *MemoryUserRepository) RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsDeleted() {
+		return false, nil
+	}
+	if last, sent := r.verificationSent[id]; sent && last.After(notBefore) {
+		return false, nil
+	}
+	r.verificationSent[id] = sentAt
+	return true, nil
+}
+
+// ReplacePasswordHash swaps the password hash, unless the password changed since oldHash was read
+func (r *MemoryUserRepository) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsDeleted() || user.Password != oldHash {
+		return false, nil
+	}
+	user.Password = newHash
+	r.users[id] = user
+	return true, nil
+}
+
+// UpdatePassword stores a new password hash and bumps the token version, returning the new one
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.IsDeleted() {
+		return 0, domain.ErrUserNotFound
+	}
+	user.Password = hash
+	user.UpdatedAt = updatedAt
+	user.TokenVersion++
+	r.users[id] = user
+	return user.TokenVersion, nil
+}
+
+// compareUsers orders two users by the query's sort field, then by ID
+func compareUsers(query domain.UserListQuery, a, b *domain.User) int {
+	return compareToCursor(query, a, &domain.UserCursor{CreatedAt: b.CreatedAt, LastName: b.LastName, ID: b.ID})
+}
+
+// compareToCursor reports whether the user sorts before (<0), at (0) or after (>0) the cursor
+func compareToCursor(query domain.UserListQuery, user *domain.User, cursor *domain.UserCursor) int {
+	var result int
+	if query.SortBy == domain.UserSortLastName {
+		result = strings.Compare(user.LastName, cursor.LastName)
+	} else {
+		result = user.CreatedAt.Compare(cursor.CreatedAt)
+	}
+	if result == 0 {
+		result = compareInts(user.ID, cursor.ID)
+	}
+	if query.Descending {
+		result = -result
+	}
+	return result
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
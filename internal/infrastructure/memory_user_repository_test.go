@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"hello-world/internal/domain"
+)
+
+func TestMemoryUserRepository_ConcurrentCreate(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	const workers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- repo.Create(ctx, newConformanceUser(fmt.Sprintf("user%d@example.com", i)))
+		}(i)
+		go func() {
+			defer wg.Done()
+			errs <- repo.Create(ctx, newConformanceUser("shared@example.com"))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	duplicates := 0
+	for err := range errs {
+		if err == domain.ErrUserAlreadyExists {
+			duplicates++
+		} else if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if duplicates != workers-1 {
+		t.Errorf("Expected %d duplicate errors, got %d", workers-1, duplicates)
+	}
+
+	seen := make(map[int]bool)
+	for id := 1; id <= workers+1; id++ {
+		user, err := repo.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Expected user %d to exist, got %v", id, err)
+		}
+		if seen[user.ID] {
+			t.Errorf("Expected unique IDs, got %d twice", user.ID)
+		}
+		seen[user.ID] = true
+	}
+}
+
+func TestMemoryUserRepository_ReturnsCopies(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	user := newConformanceUser("copy@example.com")
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	user.FirstName = "Changed"
+
+	found, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	found.LastName = "Changed"
+
+	stored, _ := repo.GetByID(ctx, user.ID)
+	if stored.FirstName != "John" || stored.LastName != "Doe" {
+		t.Errorf("Expected stored user to be unaffected by caller changes, got %+v", stored)
+	}
+}
+
+func TestMemoryUserRepository_IDsAreNotReused(t *testing.T) {
+	repo := NewMemoryUserRepository()
+	ctx := context.Background()
+
+	first := newConformanceUser("first@example.com")
+	repo.Create(ctx, first)
+	repo.Delete(ctx, first.ID)
+
+	second := newConformanceUser("second@example.com")
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Errorf("Expected a new ID after delete, got %d again", second.ID)
+	}
+}
@@ -0,0 +1,162 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// SQLMFARepository implements domain.MFARepository using the SQL database
+// (SQLite or PostgreSQL)
+type SQLMFARepository struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLMFARepository creates a new SQL two-factor authentication repository
+func NewSQLMFARepository(db *sql.DB, driver string) domain.MFARepository {
+	return &SQLMFARepository{db: db, driver: driver}
+}
+
+// GetEnrollment retrieves the user's enrollment
+func (r *SQLMFARepository) GetEnrollment(ctx context.Context, userID int) (*domain.MFAEnrollment, error) {
+	query := `SELECT user_id, totp_secret, confirmed_at, last_used_step, created_at FROM user_mfa WHERE user_id = ?`
+
+	enrollment := &domain.MFAEnrollment{}
+	var confirmedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, rebind(r.driver, query), userID).Scan(
+		&enrollment.UserID, &enrollment.Secret, &confirmedAt, &enrollment.LastUsedStep, &enrollment.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		enrollment.ConfirmedAt = &confirmedAt.Time
+	}
+	return enrollment, nil
+}
+
+// SaveEnrollment stores a pending enrollment. A confirmed enrollment is left untouched.
+func (r *SQLMFARepository) SaveEnrollment(ctx context.Context, enrollment *domain.MFAEnrollment) error {
+	query := `
+		INSERT INTO user_mfa (user_id, totp_secret, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET totp_secret = excluded.totp_secret, created_at = excluded.created_at
+		WHERE user_mfa.confirmed_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, rebind(r.driver, query), enrollment.UserID, enrollment.Secret, enrollment.CreatedAt.UTC())
+	return err
+}
+
+// ConfirmEnrollment confirms a pending enrollment and stores its recovery codes
+func (r *SQLMFARepository) ConfirmEnrollment(ctx context.Context, userID int, confirmedAt time.Time, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	confirmSQL := `UPDATE user_mfa SET confirmed_at = ?, last_used_step = ? WHERE user_id = ? AND confirmed_at IS NULL`
+	result, err := tx.ExecContext(ctx, rebind(r.driver, confirmSQL), confirmedAt.UTC(), step, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrMFANotEnrolled
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, r.driver, userID, recoveryCodeHashes, confirmedAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteEnrollment removes the enrollment and its recovery codes
+func (r *SQLMFARepository) DeleteEnrollment(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, rebind(r.driver, `DELETE FROM mfa_recovery_codes WHERE user_id = ?`), userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, rebind(r.driver, `DELETE FROM user_mfa WHERE user_id = ?`), userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep records an accepted code unless a code of the same or a later step was accepted before
+func (r *SQLMFARepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`
+	result, err := r.db.ExecContext(ctx, rebind(r.driver, query), step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used
+func (r *SQLMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt time.Time) (bool, error) {
+	query := `UPDATE mfa_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, rebind(r.driver, query), usedAt.UTC(), userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes and stores new ones
+func (r *SQLMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string, createdAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, r.driver, userID, codeHashes, createdAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has
+func (r *SQLMFARepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = ? AND used_at IS NULL`
+	var count int
+	err := r.db.QueryRowContext(ctx, rebind(r.driver, query), userID).Scan(&count)
+	return count, err
+}
+
+// replaceRecoveryCodes swaps the user's recovery codes within tx
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, driver string, userID int, codeHashes []string, createdAt time.Time) error {
+	if _, err := tx.ExecContext(ctx, rebind(driver, `DELETE FROM mfa_recovery_codes WHERE user_id = ?`), userID); err != nil {
+		return err
+	}
+
+	insertSQL := rebind(driver, `INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)`)
+	for _, codeHash := range codeHashes {
+		if _, err := tx.ExecContext(ctx, insertSQL, userID, codeHash, createdAt.UTC()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// mfaRepositories returns every MFA repository implementation under test
+func mfaRepositories(t *testing.T) map[string]domain.MFARepository {
+	db := setupRefreshTokenTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	return map[string]domain.MFARepository{
+		"SQLite": NewSQLMFARepository(db, DriverSQLite),
+		"Memory": NewMemoryMFARepository(),
+	}
+}
+
+func TestMFARepository_Enrollment(t *testing.T) {
+	for name, repo := range mfaRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+
+			if _, err := repo.GetEnrollment(ctx, 1); err != domain.ErrMFANotEnrolled {
+				t.Fatalf("Expected ErrMFANotEnrolled, got %v", err)
+			}
+			if err := repo.ConfirmEnrollment(ctx, 1, now, 10, nil); err != domain.ErrMFANotEnrolled {
+				t.Fatalf("Expected ErrMFANotEnrolled without a pending enrollment, got %v", err)
+			}
+
+			if err := repo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: 1, Secret: "FIRST", CreatedAt: now}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// A pending enrollment can be replaced
+			if err := repo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: 1, Secret: "SECOND", CreatedAt: now}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			enrollment, err := repo.GetEnrollment(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if enrollment.Secret != "SECOND" || enrollment.IsConfirmed() {
+				t.Fatalf("Expected pending enrollment with secret SECOND, got %+v", enrollment)
+			}
+
+			if err := repo.ConfirmEnrollment(ctx, 1, now, 10, []string{"hash-1", "hash-2"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			// A confirmed enrollment is left untouched
+			if err := repo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: 1, Secret: "THIRD", CreatedAt: now}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			enrollment, err = repo.GetEnrollment(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if enrollment.Secret != "SECOND" || !enrollment.IsConfirmed() || enrollment.LastUsedStep != 10 {
+				t.Fatalf("Expected confirmed enrollment with secret SECOND at step 10, got %+v", enrollment)
+			}
+			if !enrollment.ConfirmedAt.Equal(now) {
+				t.Errorf("Expected confirmed at %v, got %v", now, enrollment.ConfirmedAt)
+			}
+			if count, _ := repo.CountRecoveryCodes(ctx, 1); count != 2 {
+				t.Errorf("Expected 2 recovery codes, got %d", count)
+			}
+
+			if err := repo.DeleteEnrollment(ctx, 1); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := repo.GetEnrollment(ctx, 1); err != domain.ErrMFANotEnrolled {
+				t.Errorf("Expected ErrMFANotEnrolled after delete, got %v", err)
+			}
+			if count, _ := repo.CountRecoveryCodes(ctx, 1); count != 0 {
+				t.Errorf("Expected recovery codes to be deleted, got %d", count)
+			}
+		})
+	}
+}
+
+func TestMFARepository_UseStep(t *testing.T) {
+	for name, repo := range mfaRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			if err := repo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: 1, Secret: "SECRET", CreatedAt: now}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := repo.ConfirmEnrollment(ctx, 1, now, 10, nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, tc := range []struct {
+				step     int64
+				expected bool
+			}{
+				{step: 10, expected: false},
+				{step: 11, expected: true},
+				{step: 11, expected: false},
+				{step: 9, expected: false},
+				{step: 12, expected: true},
+			} {
+				fresh, err := repo.UseStep(ctx, 1, tc.step)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if fresh != tc.expected {
+					t.Errorf("Expected step %d accepted=%v, got %v", tc.step, tc.expected, fresh)
+				}
+			}
+
+			if fresh, _ := repo.UseStep(ctx, 2, 100); fresh {
+				t.Error("Expected step of a user without enrollment to be rejected")
+			}
+		})
+	}
+}
+
+func TestMFARepository_RecoveryCodes(t *testing.T) {
+	for name, repo := range mfaRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			if err := repo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: 1, Secret: "SECRET", CreatedAt: now}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := repo.ConfirmEnrollment(ctx, 1, now, 1, []string{"hash-1", "hash-2"}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if used, _ := repo.UseRecoveryCode(ctx, 1, "hash-1", now); !used {
+				t.Fatal("Expected recovery code to be accepted")
+			}
+			if used, _ := repo.UseRecoveryCode(ctx, 1, "hash-1", now); used {
+				t.Error("Expected used recovery code to be rejected")
+			}
+			if used, _ := repo.UseRecoveryCode(ctx, 2, "hash-2", now); used {
+				t.Error("Expected another user's recovery code to be rejected")
+			}
+			if count, _ := repo.CountRecoveryCodes(ctx, 1); count != 1 {
+				t.Errorf("Expected 1 recovery code left, got %d", count)
+			}
+
+			if err := repo.ReplaceRecoveryCodes(ctx, 1, []string{"hash-3", "hash-4", "hash-5"}, now); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if used, _ := repo.UseRecoveryCode(ctx, 1, "hash-2", now); used {
+				t.Error("Expected replaced recovery code to be rejected")
+			}
+			if count, _ := repo.CountRecoveryCodes(ctx, 1); count != 3 {
+				t.Errorf("Expected 3 recovery codes, got %d", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hello-world/internal/domain"
+)
+
+// ErrEmailCollision is returned when accounts have addresses that only
+// differ in case or in the spelling of the domain, so that they cannot be
+// told apart any more. They have to be merged or changed by hand before the
+// migration is run again.
+var ErrEmailCollision = errors.New("several accounts have the same email address")
+
+// migrationHook changes data in ways SQL cannot express. It runs after the
+// SQL of its migration, in the same transaction, and reads the driver and
+// settings from the migrator.
+type migrationHook struct {
+	// id is part of the checksum of the migration, so that changing what an
+	// applied hook does is caught like editing its SQL. Give the hook a new id
+	// whenever its behaviour changes.
+	id  string
+	run func(ctx context.Context, tx *sql.Tx, m *Migrator) error
+	// settings, if set, describes the migrator settings run reads. It is
+	// recorded with the applied migration, as they change what it did.
+	settings func(m *Migrator) string
+}
+
+// migrationHooks holds the hooks of the embedded migrations, by migration name
+var migrationHooks = map[string]migrationHook{
+	"add_user_email_normalized": {id: "normalizeUserEmails/1", run: normalizeUserEmails},
+	"normalize_user_phones": {
+		id:       "normalizeUserPhones/1",
+		run:      normalizeUserPhones,
+		settings: func(m *Migrator) string { return "phone_region=" + m.PhoneRegion },
+	},
+}
+
+// normalizeUserEmails rewrites every email in its normalized form and fills
+// email_normalized. Addresses that cannot be normalized are kept as they are.
+// Soft-deleted accounts are included, as they still hold their address.
+func normalizeUserEmails(ctx context.Context, tx *sql.Tx, m *Migrator) error {
+	type account struct {
+		id    int
+		email string
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, email FROM users ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	var accounts []account
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.id, &a.email); err != nil {
+			rows.Close()
+			return err
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	byKey := make(map[string][]account)
+	var keys []string
+	for _, a := range accounts {
+		key := domain.EmailKey(a.email)
+		if _, seen := byKey[key]; !seen {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], a)
+	}
+
+	var collisions []string
+	for _, key := range keys {
+		if len(byKey[key]) < 2 {
+			continue
+		}
+		holders := make([]string, 0, len(byKey[key]))
+		for _, a := range byKey[key] {
+			holders = append(holders, fmt.Sprintf("%s (id %d)", a.email, a.id))
+		}
+		collisions = append(collisions, strings.Join(holders, ", "))
+	}
+	if len(collisions) > 0 {
+		return fmt.Errorf("%w: %s", ErrEmailCollision, strings.Join(collisions, "; "))
+	}
+
+	update := rebind(m.driver, `UPDATE users SET email = ?, email_normalized = ? WHERE id = ?`)
+	for _, a := range accounts {
+		email, err := domain.NormalizeEmail(a.email)
+		if err != nil {
+			email = a.email
+		}
+		if _, err := tx.ExecContext(ctx, update, email, domain.EmailKey(a.email), a.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeUserPhones rewrites every phone number in E.164 form, reading
+// numbers without a country code as numbers of the migrator's PhoneRegion.
+// Numbers that cannot be normalized are kept as they are; they are refused
+// when a code is sent to them, until the user changes them.
+func normalizeUserPhones(ctx context.Context, tx *sql.Tx, m *Migrator) error {
+	type account struct {
+		id    int
+		phone string
+	}
+
+	if m.PhoneRegion != "" && !domain.IsPhoneRegion(m.PhoneRegion) {
+		return fmt.Errorf("unknown phone region %q", m.PhoneRegion)
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, phone FROM users WHERE phone <> '' ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	var accounts []account
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.id, &a.phone); err != nil {
+			rows.Close()
+			return err
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := rebind(m.driver, `UPDATE users SET phone = ? WHERE id = ?`)
+	for _, a := range accounts {
+		phone, err := domain.NormalizePhone(a.phone, m.PhoneRegion)
+		if err != nil || phone == a.phone {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, update, phone, a.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_user_token_revocations_expires_at;
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP TABLE IF EXISTS user_token_revocations;
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_users_lastname ON users(lastname, id);
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN verification_sent_at TIMESTAMPTZ;
+
+-- Accounts created before verification existed keep working
+UPDATE users SET email_verified_at = created_at;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+	attempt_key TEXT PRIMARY KEY,
+	failures INTEGER NOT NULL,
+	last_failed_at TIMESTAMPTZ NOT NULL,
+	locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failed_at ON login_attempts(last_failed_at);
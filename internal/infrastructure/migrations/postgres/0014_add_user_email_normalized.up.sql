@@ -0,0 +1,4 @@
+-- email_normalized holds domain.EmailKey(email), the lowercased address
+-- accounts are looked up by. The migration fills it in Go, as IDNA cannot be
+-- applied in SQL, and fails listing the accounts if two addresses collide.
+ALTER TABLE users ADD COLUMN email_normalized TEXT;
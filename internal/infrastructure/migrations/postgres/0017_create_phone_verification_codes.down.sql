@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_phone_verification_codes_phone;
+DROP INDEX IF EXISTS idx_phone_verification_codes_user_id;
+DROP TABLE IF EXISTS phone_verification_codes;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS phone_verification_codes (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	phone TEXT NOT NULL,
+	code_hash TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_phone_verification_codes_user_id ON phone_verification_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_phone_verification_codes_phone ON phone_verification_codes(phone, created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	token_id TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	expires_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+	user_id INTEGER PRIMARY KEY,
+	revoked_before DATETIME NOT NULL,
+	expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_token_revocations_expires_at ON user_token_revocations(expires_at);
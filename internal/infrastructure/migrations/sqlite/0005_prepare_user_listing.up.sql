@@ -0,0 +1,9 @@
+-- Timestamps used to be stored with the server's UTC offset, which breaks
+-- ordering them as text. Rewrite them in UTC, the format written from now on.
+UPDATE users SET created_at = strftime('%Y-%m-%d %H:%M:%f', created_at) || '+00:00'
+WHERE strftime('%Y-%m-%d %H:%M:%f', created_at) IS NOT NULL;
+
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', updated_at) || '+00:00'
+WHERE strftime('%Y-%m-%d %H:%M:%f', updated_at) IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_lastname ON users(lastname, id);
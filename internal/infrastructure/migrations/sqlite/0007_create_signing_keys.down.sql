@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_signing_keys_expires_at;
+DROP TABLE IF EXISTS signing_keys;
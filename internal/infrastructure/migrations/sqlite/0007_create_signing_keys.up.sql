@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+	kid TEXT PRIMARY KEY,
+	algorithm TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	retired_at DATETIME,
+	expires_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_expires_at ON signing_keys(expires_at);
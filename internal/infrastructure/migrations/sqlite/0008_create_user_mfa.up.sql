@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	totp_secret TEXT NOT NULL,
+	confirmed_at DATETIME,
+	last_used_step INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	code_hash TEXT NOT NULL,
+	used_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
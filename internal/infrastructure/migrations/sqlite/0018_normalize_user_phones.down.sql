@@ -0,0 +1 @@
+-- The numbers as they were typed are not kept, so they stay normalized.
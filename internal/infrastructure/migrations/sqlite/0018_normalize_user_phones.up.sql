@@ -0,0 +1,4 @@
+-- Stored phone numbers are rewritten in E.164 form. The migration parses
+-- them with libphonenumber in Go, reading numbers without a country code as
+-- numbers of the configured default region; numbers it cannot parse are kept.
+UPDATE users SET phone = TRIM(phone) WHERE phone <> TRIM(phone);
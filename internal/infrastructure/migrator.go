@@ -0,0 +1,429 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations
+var embeddedMigrations embed.FS
+
+// Migration errors
+var (
+	ErrMigrationLocked           = errors.New("migrations are locked by another process")
+	ErrMigrationChecksumMismatch = errors.New("applied migration has been modified")
+	ErrUnknownMigrationVersion   = errors.New("database has a migration this binary does not know")
+)
+
+// migrationFilePattern matches files such as 0001_create_users.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	UpSQL    string
+	DownSQL  string
+	Checksum string
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Settings are the migrator settings the migration was applied with, for
+	// migrations whose result depends on them
+	Settings string
+}
+
+// Migrator applies and rolls back versioned migrations, recording them in
+// the schema_migrations table. A row in schema_migrations_lock acts as a
+// lock so that only one process migrates a database at a time.
+type Migrator struct {
+	db          *sql.DB
+	driver      string
+	migrations  []Migration
+	hooks       map[string]migrationHook
+	owner       string
+	LockTimeout time.Duration
+	// PhoneRegion is the region of stored phone numbers written without a
+	// country code, see domain.NormalizePhone
+	PhoneRegion string
+}
+
+// NewMigrator creates a migrator for the migrations embedded for the given
+// driver, with the Go hooks some of them need
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	fsys, err := fs.Sub(embeddedMigrations, path.Join("migrations", migrationDir(driver)))
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := NewMigratorFromFS(db, driver, fsys)
+	if err != nil {
+		return nil, err
+	}
+	migrator.useHooks(migrationHooks)
+	return migrator, nil
+}
+
+// useHooks runs hooks after the SQL of their migrations and adds their ids
+// to the checksums of these migrations
+func (m *Migrator) useHooks(hooks map[string]migrationHook) {
+	m.hooks = hooks
+	for i, migration := range m.migrations {
+		if hook, ok := hooks[migration.Name]; ok {
+			m.migrations[i].Checksum = migrationChecksum(migration.UpSQL, hook.id)
+		}
+	}
+}
+
+// NewMigratorFromFS creates a migrator for the migrations found in fsys
+func NewMigratorFromFS(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:          db,
+		driver:      driver,
+		migrations:  migrations,
+		owner:       fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		LockTimeout: 30 * time.Second,
+	}, nil
+}
+
+// migrationDir maps a database driver to its migrations directory
+func migrationDir(driver string) string {
+	switch driver {
+	case DriverPostgres:
+		return "postgres"
+	default:
+		return "sqlite"
+	}
+}
+
+// migrationChecksum is the SHA-256 of the up SQL of a migration, followed by
+// the id of its Go hook if it has one
+func migrationChecksum(upSQL, hookID string) string {
+	content := upSQL
+	if hookID != "" {
+		content += "\n-- hook: " + hookID
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys, ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" || migration.DownSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migration.Checksum = migrationChecksum(migration.UpSQL, "")
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func() error {
+		appliedVersions, err := m.verifyApplied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, migration); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the most recent steps migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	rolledBack := 0
+	err := m.withLock(ctx, func() error {
+		appliedVersions, err := m.verifyApplied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(ctx, migration); err != nil {
+				return err
+			}
+			rolledBack++
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	appliedVersions, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := appliedVersions[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.appliedAt
+			status.Settings = row.settings
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ForceUnlock removes a lock left behind by a migration process that died
+func (m *Migrator) ForceUnlock(ctx context.Context) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+	_, err := m.db.ExecContext(ctx, `DELETE FROM schema_migrations_lock WHERE id = 1`)
+	return err
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+	settings  string
+}
+
+// verifyApplied checks that every applied migration is known and unmodified
+func (m *Migrator) verifyApplied(ctx context.Context) (map[int]appliedMigration, error) {
+	appliedVersions, err := m.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, row := range appliedVersions {
+		migration, ok := known[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: version %d", ErrUnknownMigrationVersion, version)
+		}
+		if migration.Checksum != row.checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrMigrationChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	return appliedVersions, nil
+}
+
+func (m *Migrator) appliedMigrations(ctx context.Context) (map[int]appliedMigration, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT version, checksum, applied_at, settings FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var row appliedMigration
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt, &row.settings); err != nil {
+			return nil, err
+		}
+		applied[version] = row
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.UpSQL); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+	var settings string
+	if hook, ok := m.hooks[migration.Name]; ok {
+		if err := hook.run(ctx, tx, m); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		if hook.settings != nil {
+			settings = hook.settings(m)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		rebind(m.driver, `INSERT INTO schema_migrations (version, name, checksum, applied_at, settings) VALUES (?, ?, ?, ?, ?)`),
+		migration.Version, migration.Name, migration.Checksum, time.Now().UTC(), settings,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) rollback(ctx context.Context, migration Migration) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.DownSQL); err != nil {
+		return fmt.Errorf("rollback of migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, rebind(m.driver, `DELETE FROM schema_migrations WHERE version = ?`), migration.Version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL,
+			settings TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INTEGER PRIMARY KEY,
+			owner TEXT NOT NULL,
+			locked_at TIMESTAMP NOT NULL
+		)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	// schema_migrations tables created before settings were recorded lack the column
+	if _, err := m.db.ExecContext(ctx, `SELECT settings FROM schema_migrations WHERE 1 = 0`); err != nil {
+		if _, err := m.db.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN settings TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withLock runs fn while holding the migration lock, waiting up to LockTimeout for it
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	if err := m.ensureTables(ctx); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		_, err := m.db.ExecContext(ctx,
+			rebind(m.driver, `INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, ?, ?)`),
+			m.owner, time.Now().UTC(),
+		)
+		if err == nil {
+			break
+		}
+
+		// The insert fails when another process holds the lock; anything else is a real error
+		var holder string
+		if lookupErr := m.db.QueryRowContext(ctx, `SELECT owner FROM schema_migrations_lock WHERE id = 1`).Scan(&holder); lookupErr != nil {
+			return err
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w (held by %s)", ErrMigrationLocked, holder)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	defer m.db.ExecContext(context.Background(), rebind(m.driver, `DELETE FROM schema_migrations_lock WHERE id = 1 AND owner = ?`), m.owner)
+	return fn()
+}
@@ -0,0 +1,615 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func setupMigrationTestDB(t *testing.T) *sql.DB {
+	db, err := OpenDatabase(DatabaseConfig{Driver: "sqlite3", DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	return db
+}
+
+func testMigrationFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_things.up.sql":   {Data: []byte(`CREATE TABLE things (id INTEGER PRIMARY KEY);`)},
+		"0001_create_things.down.sql": {Data: []byte(`DROP TABLE things;`)},
+		"0002_add_name.up.sql":        {Data: []byte(`ALTER TABLE things ADD COLUMN name TEXT;`)},
+		"0002_add_name.down.sql":      {Data: []byte(`ALTER TABLE things DROP COLUMN name;`)},
+		"README.md":                   {Data: []byte(`not a migration`)},
+	}
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to look up table %s: %v", table, err)
+	}
+	return count > 0
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(testMigrationFS())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Errorf("Expected migrations ordered by version, got %d, %d", migrations[0].Version, migrations[1].Version)
+	}
+	if migrations[1].Name != "add_name" {
+		t.Errorf("Expected name add_name, got %s", migrations[1].Name)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Error("Expected distinct checksums")
+	}
+}
+
+func TestLoadMigrations_MissingDownFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_create_things.up.sql": {Data: []byte(`CREATE TABLE things (id INTEGER PRIMARY KEY);`)},
+	}
+
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Error("Expected error for migration without a down file")
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(migrator.migrations) == 0 {
+		t.Fatal("Expected embedded migrations to be found")
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	ctx := context.Background()
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("Expected 2 migrations applied, got %d", applied)
+	}
+	if !tableExists(t, db, "things") {
+		t.Error("Expected things table to exist")
+	}
+
+	// Running again is a no-op
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if applied != 0 {
+		t.Errorf("Expected no migrations applied on second run, got %d", applied)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	rolledBack, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if rolledBack != 1 {
+		t.Errorf("Expected 1 migration rolled back, got %d", rolledBack)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Expected only the first migration to remain applied, got %+v", statuses)
+	}
+
+	if _, err := migrator.Down(ctx, 5); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tableExists(t, db, "things") {
+		t.Error("Expected things table to be dropped")
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	fsys := testMigrationFS()
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, fsys)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	// Edit an already applied migration
+	fsys["0001_create_things.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE things (id INTEGER PRIMARY KEY, extra TEXT);`)}
+	edited, err := NewMigratorFromFS(db, DriverSQLite, fsys)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+
+	_, err = edited.Up(context.Background())
+	if !errors.Is(err, ErrMigrationChecksumMismatch) {
+		t.Errorf("Expected ErrMigrationChecksumMismatch, got %v", err)
+	}
+}
+
+func TestMigrator_HookChangeIsChecksumMismatch(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	noop := func(ctx context.Context, tx *sql.Tx, m *Migrator) error { return nil }
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	plain := migrator.migrations[1].Checksum
+	migrator.useHooks(map[string]migrationHook{"add_name": {id: "fillNames/1", run: noop}})
+	if migrator.migrations[1].Checksum == plain {
+		t.Error("Expected the hook to be part of the checksum")
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	// Change the hook of an already applied migration
+	changed, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	changed.useHooks(map[string]migrationHook{"add_name": {id: "fillNames/2", run: noop}})
+
+	_, err = changed.Up(context.Background())
+	if !errors.Is(err, ErrMigrationChecksumMismatch) {
+		t.Errorf("Expected ErrMigrationChecksumMismatch, got %v", err)
+	}
+}
+
+func TestMigrator_AddsSettingsToExistingTable(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	// schema_migrations as created before settings were recorded
+	_, err := db.Exec(`CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		checksum TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("Expected 2 migrations applied, got %d", applied)
+	}
+}
+
+func TestMigrator_UnknownAppliedVersion(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	// An older binary only knows the first migration
+	older, err := NewMigratorFromFS(db, DriverSQLite, fstest.MapFS{
+		"0001_create_things.up.sql":   testMigrationFS()["0001_create_things.up.sql"],
+		"0001_create_things.down.sql": testMigrationFS()["0001_create_things.down.sql"],
+	})
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+
+	_, err = older.Up(context.Background())
+	if !errors.Is(err, ErrUnknownMigrationVersion) {
+		t.Errorf("Expected ErrUnknownMigrationVersion, got %v", err)
+	}
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	fsys := testMigrationFS()
+	fsys["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE others (id INTEGER); NOT VALID SQL;`)}
+	fsys["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte(`DROP TABLE others;`)}
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, fsys)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err == nil {
+		t.Fatal("Expected broken migration to fail")
+	}
+	if tableExists(t, db, "others") {
+		t.Error("Expected partial changes of the failed migration to be rolled back")
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !statuses[1].Applied || statuses[2].Applied {
+		t.Errorf("Expected migrations before the broken one to stay applied, got %+v", statuses)
+	}
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigratorFromFS(db, DriverSQLite, testMigrationFS())
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	migrator.LockTimeout = 200 * time.Millisecond
+	ctx := context.Background()
+
+	// Simulate another instance holding the lock
+	if err := migrator.ensureTables(ctx); err != nil {
+		t.Fatalf("Failed to create migration tables: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO schema_migrations_lock (id, owner, locked_at) VALUES (1, 'other-instance', ?)`, time.Now()); err != nil {
+		t.Fatalf("Failed to take lock: %v", err)
+	}
+
+	_, err = migrator.Up(ctx)
+	if !errors.Is(err, ErrMigrationLocked) {
+		t.Fatalf("Expected ErrMigrationLocked, got %v", err)
+	}
+	if tableExists(t, db, "things") {
+		t.Error("Expected no migration to run while locked")
+	}
+
+	if err := migrator.ForceUnlock(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Expected migration to succeed after unlock, got %v", err)
+	}
+
+	// The lock is released after a successful run
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations_lock`).Scan(&count); err != nil {
+		t.Fatalf("Failed to query lock: %v", err)
+	}
+	if count != 0 {
+		t.Error("Expected lock to be released")
+	}
+}
+
+func TestNewDatabase_AdoptsLegacySchema(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "legacy.db")
+
+	// A database created before migrations existed
+	legacy, err := OpenDatabase(DatabaseConfig{Driver: "sqlite3", DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := legacy.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT UNIQUE NOT NULL,
+		password TEXT NOT NULL,
+		firstname TEXT NOT NULL,
+		lastname TEXT NOT NULL,
+		phone TEXT NOT NULL,
+		birthday DATE NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	legacy.Close()
+
+	db, err := NewDatabase(DatabaseConfig{Driver: "sqlite3", DSN: dsn})
+	if err != nil {
+		t.Fatalf("Expected legacy database to be migrated, got %v", err)
+	}
+	defer db.Close()
+
+	if !tableExists(t, db, "refresh_tokens") {
+		t.Error("Expected later migrations to be applied")
+	}
+}
+
+func TestNewDatabase_SkipMigrations(t *testing.T) {
+	db, err := NewDatabase(DatabaseConfig{Driver: "sqlite3", DSN: filepath.Join(t.TempDir(), "test.db"), SkipMigrations: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	if tableExists(t, db, "users") {
+		t.Error("Expected no tables when migrations are skipped")
+	}
+}
+
+func TestMigration_NormalizesUserTimestampsToUTC(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Roll back to the schema before 0005_prepare_user_listing
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	steps := 0
+	for _, status := range statuses {
+		if status.Applied && status.Version >= 5 {
+			steps++
+		}
+	}
+	if _, err := migrator.Down(ctx, steps); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A row written by an older version running at UTC+7
+	_, err = db.Exec(`INSERT INTO users (email, password, firstname, lastname, phone, birthday, created_at, updated_at)
+		VALUES ('old@example.com', 'x', 'John', 'Doe', '', '1990-01-01', '2025-08-27 14:00:00.5+07:00', '2025-08-27 14:00:00+07:00')`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var createdAt, updatedAt string
+	if err := db.QueryRow(`SELECT CAST(created_at AS TEXT), CAST(updated_at AS TEXT) FROM users WHERE email = 'old@example.com'`).Scan(&createdAt, &updatedAt); err != nil {
+		t.Fatalf("Failed to read row: %v", err)
+	}
+	if createdAt != "2025-08-27 07:00:00.500+00:00" {
+		t.Errorf("Expected created_at in UTC, got %s", createdAt)
+	}
+	if updatedAt != "2025-08-27 07:00:00.000+00:00" {
+		t.Errorf("Expected updated_at in UTC, got %s", updatedAt)
+	}
+
+	user, err := NewSQLiteUserRepository(db).GetByEmail(ctx, "old@example.com")
+	if err != nil {
+		t.Fatalf("Failed to read migrated user: %v", err)
+	}
+	if !user.CreatedAt.Equal(time.Date(2025, 8, 27, 7, 0, 0, 500000000, time.UTC)) {
+		t.Errorf("Expected created_at to keep its instant, got %v", user.CreatedAt)
+	}
+}
+
+// migrateBefore applies every embedded migration older than version and
+// rolls back the others
+func migrateBefore(t *testing.T, migrator *Migrator, version int) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	steps := 0
+	for _, status := range statuses {
+		if status.Applied && status.Version >= version {
+			steps++
+		}
+	}
+	if _, err := migrator.Down(ctx, steps); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestMigration_NormalizesUserEmails(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	migrateBefore(t, migrator, 14)
+
+	_, err = db.Exec(`INSERT INTO users (email, password, firstname, lastname, phone, birthday, created_at, updated_at)
+		VALUES (' Bob@Example.COM', 'x', 'Bob', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('info@Bücher.example', 'x', 'Ann', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00')`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	repo := NewSQLiteUserRepository(db)
+	bob, err := repo.GetByEmail(ctx, "bob@example.com")
+	if err != nil {
+		t.Fatalf("Expected the user to be found regardless of case, got %v", err)
+	}
+	if bob.Email != "Bob@example.com" {
+		t.Errorf("Expected the email to be normalized, got %q", bob.Email)
+	}
+	ann, err := repo.GetByEmail(ctx, "info@bücher.example")
+	if err != nil {
+		t.Fatalf("Expected the user to be found by the internationalized domain, got %v", err)
+	}
+	if ann.Email != "info@xn--bcher-kva.example" {
+		t.Errorf("Expected the domain in punycode, got %q", ann.Email)
+	}
+}
+
+func TestMigration_NormalizesUserPhones(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	migrator.PhoneRegion = "TH"
+	migrateBefore(t, migrator, 18)
+
+	_, err = db.Exec(`INSERT INTO users (email, password, firstname, lastname, phone, birthday, created_at, updated_at)
+		VALUES ('local@example.com', 'x', 'Ann', 'Doe', '081-234-5678', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('abroad@example.com', 'x', 'Bob', 'Doe', ' +1 (415) 555-2671', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('invalid@example.com', 'x', 'Cid', 'Doe', '12-34', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('none@example.com', 'x', 'Dee', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00')`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for email, want := range map[string]string{
+		"local@example.com":   "+66812345678",
+		"abroad@example.com":  "+14155552671",
+		"invalid@example.com": "12-34",
+		"none@example.com":    "",
+	} {
+		var phone string
+		if err := db.QueryRow(`SELECT phone FROM users WHERE email = ?`, email).Scan(&phone); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if phone != want {
+			t.Errorf("Expected the phone of %s to be %q, got %q", email, want, phone)
+		}
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Version == 18 && status.Settings != "phone_region=TH" {
+			t.Errorf("Expected the phone region to be recorded, got %q", status.Settings)
+		}
+	}
+}
+
+func TestMigration_RefusesUnknownPhoneRegion(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	migrator.PhoneRegion = "XX"
+
+	if _, err := migrator.Up(context.Background()); err == nil || !strings.Contains(err.Error(), "normalize_user_phones") {
+		t.Errorf("Expected the phone migration to refuse an unknown region, got %v", err)
+	}
+}
+
+func TestMigration_ReportsEmailCollisions(t *testing.T) {
+	db := setupMigrationTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create migrator: %v", err)
+	}
+	migrateBefore(t, migrator, 14)
+
+	_, err = db.Exec(`INSERT INTO users (email, password, firstname, lastname, phone, birthday, created_at, updated_at)
+		VALUES ('bob@example.com', 'x', 'Bob', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('alice@example.com', 'x', 'Alice', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00'),
+			('Bob@EXAMPLE.com', 'x', 'Bob', 'Doe', '', '1990-01-01', '2025-01-01 00:00:00+00:00', '2025-01-01 00:00:00+00:00')`)
+	if err != nil {
+		t.Fatalf("Failed to insert legacy rows: %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if !errors.Is(err, ErrEmailCollision) {
+		t.Fatalf("Expected ErrEmailCollision, got %v", err)
+	}
+	if want := "bob@example.com (id 1), Bob@EXAMPLE.com (id 3)"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected the error to list %q, got %q", want, err.Error())
+	}
+
+	// Nothing is changed until the collision is resolved
+	var columns int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'email_normalized'`).Scan(&columns); err != nil {
+		t.Fatalf("Failed to read the schema: %v", err)
+	}
+	if columns != 0 {
+		t.Error("Expected the failed migration to be rolled back")
+	}
+}
@@ -0,0 +1,191 @@
+package infrastructure
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"hello-world/internal/domain"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported password hashing algorithms
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// argon2idPrefix starts every argon2id hash, in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+const argon2idPrefix = "$argon2id$"
+
+// errMalformedHash is returned for stored hashes that cannot be parsed
+var errMalformedHash = errors.New("malformed password hash")
+
+// Argon2idParams are the cost parameters of argon2id hashes
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the OWASP recommendation for argon2id
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHashConfig holds the settings used to hash new passwords
+type PasswordHashConfig struct {
+	// Algorithm is PasswordHashArgon2id or PasswordHashBcrypt; it defaults
+	// to argon2id
+	Algorithm string
+	// BcryptCost defaults to bcrypt.DefaultCost
+	BcryptCost int
+	// Argon2id parameters left at zero take their value from DefaultArgon2idParams
+	Argon2id Argon2idParams
+}
+
+// PasswordHasher implements domain.PasswordHasher. It hashes new passwords
+// with the configured algorithm and checks hashes made with either algorithm.
+type PasswordHasher struct {
+	config PasswordHashConfig
+}
+
+// NewPasswordHasher creates a hasher for the configured algorithm and parameters
+func NewPasswordHasher(config PasswordHashConfig) (*PasswordHasher, error) {
+	if config.Algorithm == "" {
+		config.Algorithm = PasswordHashArgon2id
+	}
+	if config.BcryptCost == 0 {
+		config.BcryptCost = bcrypt.DefaultCost
+	}
+	if config.Argon2id.Memory == 0 {
+		config.Argon2id.Memory = DefaultArgon2idParams.Memory
+	}
+	if config.Argon2id.Iterations == 0 {
+		config.Argon2id.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if config.Argon2id.Parallelism == 0 {
+		config.Argon2id.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	if config.Argon2id.SaltLength == 0 {
+		config.Argon2id.SaltLength = DefaultArgon2idParams.SaltLength
+	}
+	if config.Argon2id.KeyLength == 0 {
+		config.Argon2id.KeyLength = DefaultArgon2idParams.KeyLength
+	}
+
+	switch config.Algorithm {
+	case PasswordHashArgon2id:
+	case PasswordHashBcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", config.Algorithm)
+	}
+	return &PasswordHasher{config: config}, nil
+}
+
+// Hash hashes a plain text password with the configured algorithm
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == PasswordHashBcrypt {
+		hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedBytes), nil
+	}
+
+	params := h.config.Argon2id
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare checks a password against an argon2id or bcrypt hash
+func (h *PasswordHasher) Compare(hash, password string) error {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return domain.ErrInvalidCredentials
+		}
+		return err
+	}
+
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return domain.ErrInvalidCredentials
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the hash differs in algorithm or cost from
+// the hashes made now
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	if h.config.Algorithm == PasswordHashBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.config.BcryptCost
+	}
+
+	params, _, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	current := h.config.Argon2id
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		uint32(len(key)) != current.KeyLength
+}
+
+// parseArgon2idHash splits an argon2id hash into its parameters, salt and key
+func parseArgon2idHash(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return params, nil, nil, errMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil ||
+		params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errMalformedHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,128 @@
+package infrastructure
+
+import (
+	"strings"
+	"testing"
+
+	"hello-world/internal/domain"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keep argon2id cheap enough for tests
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+func newTestPasswordHasher(t *testing.T, config PasswordHashConfig) *PasswordHasher {
+	t.Helper()
+	hasher, err := NewPasswordHasher(config)
+	if err != nil {
+		t.Fatalf("Failed to create password hasher: %v", err)
+	}
+	return hasher
+}
+
+func TestPasswordHasher_HashAndCompare(t *testing.T) {
+	hashers := map[string]*PasswordHasher{
+		"argon2id": newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2id: testArgon2idParams}),
+		"bcrypt":   newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost}),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			password := "testpassword123"
+			hash, err := hasher.Hash(password)
+			if err != nil {
+				t.Fatalf("Failed to hash password: %v", err)
+			}
+			if hash == "" || strings.Contains(hash, password) {
+				t.Fatalf("Expected an opaque hash, got %q", hash)
+			}
+
+			if err := hasher.Compare(hash, password); err != nil {
+				t.Errorf("Expected no error for correct password, got: %v", err)
+			}
+			if err := hasher.Compare(hash, "wrongpassword"); err != domain.ErrInvalidCredentials {
+				t.Errorf("Expected ErrInvalidCredentials for incorrect password, got: %v", err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Error("Expected a fresh hash not to need rehashing")
+			}
+
+			// Salted, so the same password hashes differently every time
+			if again, _ := hasher.Hash(password); again == hash {
+				t.Error("Expected hashes of the same password to differ")
+			}
+		})
+	}
+}
+
+func TestPasswordHasher_Argon2idFormat(t *testing.T) {
+	hasher := newTestPasswordHasher(t, PasswordHashConfig{Argon2id: testArgon2idParams})
+
+	hash, err := hasher.Hash("testpassword123")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Expected an argon2id hash in PHC format, got %q", hash)
+	}
+}
+
+func TestPasswordHasher_ComparesEitherAlgorithm(t *testing.T) {
+	argon := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2id: testArgon2idParams})
+	bcryptHasher := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost})
+
+	bcryptHash, _ := bcryptHasher.Hash("password123")
+	if err := argon.Compare(bcryptHash, "password123"); err != nil {
+		t.Errorf("Expected the argon2id hasher to check bcrypt hashes, got %v", err)
+	}
+	argonHash, _ := argon.Hash("password123")
+	if err := bcryptHasher.Compare(argonHash, "password123"); err != nil {
+		t.Errorf("Expected the bcrypt hasher to check argon2id hashes, got %v", err)
+	}
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	current := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2id: testArgon2idParams})
+	weaker := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2id: Argon2idParams{Memory: 32, Iterations: 1, Parallelism: 1}})
+	oldBcrypt := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost})
+	strongerBcrypt := newTestPasswordHasher(t, PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost + 1})
+
+	weakerHash, _ := weaker.Hash("password123")
+	bcryptHash, _ := oldBcrypt.Hash("password123")
+
+	if !current.NeedsRehash(weakerHash) {
+		t.Error("Expected a hash with other argon2id parameters to need rehashing")
+	}
+	if !current.NeedsRehash(bcryptHash) {
+		t.Error("Expected a bcrypt hash to need rehashing when argon2id is configured")
+	}
+	if !strongerBcrypt.NeedsRehash(bcryptHash) {
+		t.Error("Expected a bcrypt hash with a lower cost to need rehashing")
+	}
+	if !oldBcrypt.NeedsRehash(weakerHash) {
+		t.Error("Expected an argon2id hash to need rehashing when bcrypt is configured")
+	}
+}
+
+func TestPasswordHasher_MalformedHash(t *testing.T) {
+	hasher := newTestPasswordHasher(t, PasswordHashConfig{Argon2id: testArgon2idParams})
+
+	for _, hash := range []string{"", "plain", "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=64,t=1,p=1$!!$a2V5"} {
+		if err := hasher.Compare(hash, "password"); err == nil {
+			t.Errorf("Expected an error for malformed hash %q", hash)
+		}
+		if !hasher.NeedsRehash(hash) {
+			t.Errorf("Expected malformed hash %q to need rehashing", hash)
+		}
+	}
+}
+
+func TestNewPasswordHasher_Invalid(t *testing.T) {
+	if _, err := NewPasswordHasher(PasswordHashConfig{Algorithm: "md5"}); err == nil {
+		t.Error("Expected an unsupported algorithm to be rejected")
+	}
+	if _, err := NewPasswordHasher(PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MaxCost + 1}); err == nil {
+		t.Error("Expected an out of range bcrypt cost to be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"hello-world/internal/domain"
+)
+
+// LoadPasswordListFile reads a list of breached or common passwords, one per
+// line. Blank lines and lines starting with # are skipped.
+func LoadPasswordListFile(path string) (domain.PasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list := domain.NewPasswordList()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list.Add(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return list, nil
+}
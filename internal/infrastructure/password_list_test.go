@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPasswordListFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	if err := os.WriteFile(path, []byte("# common passwords\r\nPassword1\r\n\r\nletmein\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password list: %v", err)
+	}
+
+	list, err := LoadPasswordListFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("Expected 2 passwords, got %d", len(list))
+	}
+	for _, password := range []string{"password1", "LETMEIN"} {
+		if !list.Contains(password) {
+			t.Errorf("Expected %q to be on the list", password)
+		}
+	}
+	if list.Contains("# common passwords") {
+		t.Error("Expected comments to be skipped")
+	}
+
+	if _, err := LoadPasswordListFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
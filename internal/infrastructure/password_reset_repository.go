@@ -0,0 +1,99 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// SQLPasswordResetRepository implements domain.PasswordResetRepository using
+// the SQL database (SQLite or PostgreSQL)
+type SQLPasswordResetRepository struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLPasswordResetRepository creates a new SQL password reset token repository
+func NewSQLPasswordResetRepository(db *sql.DB, driver string) domain.PasswordResetRepository {
+	return &SQLPasswordResetRepository{db: db, driver: driver}
+}
+
+// Create inserts a new password reset token into the database
+func (r *SQLPasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	args := []interface{}{token.UserID, token.TokenHash, token.ExpiresAt.UTC(), token.CreatedAt.UTC()}
+
+	if r.driver == DriverPostgres {
+		return r.db.QueryRowContext(ctx, rebind(r.driver, query+` RETURNING id`), args...).Scan(&token.ID)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	token.ID = int(id)
+	return nil
+}
+
+// GetByHash retrieves a password reset token by the hash of its value
+func (r *SQLPasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens WHERE token_hash = ?
+	`
+
+	token := &domain.PasswordResetToken{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, rebind(r.driver, query), tokenHash).Scan(
+		&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &usedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvalidResetToken
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return token, nil
+}
+
+// MarkUsed flags a password reset token as used, unless it has already been used
+func (r *SQLPasswordResetRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	query := `UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, rebind(r.driver, query), usedAt.UTC(), id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// MarkUnused makes a password reset token usable again
+func (r *SQLPasswordResetRepository) MarkUnused(ctx context.Context, id int) error {
+	query := `UPDATE password_reset_tokens SET used_at = NULL WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, rebind(r.driver, query), id)
+	return err
+}
+
+// InvalidateAllForUser marks every unused password reset token of a user as used
+func (r *SQLPasswordResetRepository) InvalidateAllForUser(ctx context.Context, userID int, usedAt time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`
+	_, err := r.db.ExecContext(ctx, rebind(r.driver, query), usedAt.UTC(), userID)
+	return err
+}
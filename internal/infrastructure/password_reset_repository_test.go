@@ -0,0 +1,116 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// passwordResetRepositories returns every password reset repository implementation under test
+func passwordResetRepositories(t *testing.T) map[string]domain.PasswordResetRepository {
+	db := setupRefreshTokenTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	return map[string]domain.PasswordResetRepository{
+		"SQLite": NewSQLPasswordResetRepository(db, DriverSQLite),
+		"Memory": NewMemoryPasswordResetRepository(),
+	}
+}
+
+func TestPasswordResetRepository_CreateAndGetByHash(t *testing.T) {
+	for name, repo := range passwordResetRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+
+			token := &domain.PasswordResetToken{UserID: 1, TokenHash: "hash-1", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+			if err := repo.Create(ctx, token); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if token.ID == 0 {
+				t.Fatal("Expected token ID to be assigned")
+			}
+
+			stored, err := repo.GetByHash(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stored.ID != token.ID || stored.UserID != 1 || !stored.ExpiresAt.Equal(token.ExpiresAt) || stored.UsedAt != nil {
+				t.Errorf("Expected stored token %+v, got %+v", token, stored)
+			}
+
+			if _, err := repo.GetByHash(ctx, "unknown"); err != domain.ErrInvalidResetToken {
+				t.Errorf("Expected ErrInvalidResetToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPasswordResetRepository_MarkUsed(t *testing.T) {
+	for name, repo := range passwordResetRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			token := &domain.PasswordResetToken{UserID: 1, TokenHash: "hash-1", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+			if err := repo.Create(ctx, token); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if marked, _ := repo.MarkUsed(ctx, token.ID, now); !marked {
+				t.Fatal("Expected unused token to be marked")
+			}
+			if marked, _ := repo.MarkUsed(ctx, token.ID, now); marked {
+				t.Error("Expected used token not to be marked again")
+			}
+			stored, err := repo.GetByHash(ctx, "hash-1")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stored.UsedAt == nil {
+				t.Error("Expected token to be used")
+			}
+
+			if err := repo.MarkUnused(ctx, token.ID); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if marked, _ := repo.MarkUsed(ctx, token.ID, now); !marked {
+				t.Error("Expected a token marked unused to be marked again")
+			}
+		})
+	}
+}
+
+func TestPasswordResetRepository_InvalidateAllForUser(t *testing.T) {
+	for name, repo := range passwordResetRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			for _, token := range []*domain.PasswordResetToken{
+				{UserID: 1, TokenHash: "hash-1", ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+				{UserID: 1, TokenHash: "hash-2", ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+				{UserID: 2, TokenHash: "hash-3", ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+			} {
+				if err := repo.Create(ctx, token); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+
+			if err := repo.InvalidateAllForUser(ctx, 1, now); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for hash, expectUsed := range map[string]bool{"hash-1": true, "hash-2": true, "hash-3": false} {
+				stored, err := repo.GetByHash(ctx, hash)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if (stored.UsedAt != nil) != expectUsed {
+					t.Errorf("Expected %s used=%v, got %v", hash, expectUsed, stored.UsedAt != nil)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// SQLPhoneVerificationRepository implements domain.PhoneVerificationRepository
+// using the SQL database (SQLite or PostgreSQL)
+type SQLPhoneVerificationRepository struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewSQLPhoneVerificationRepository creates a new SQL phone verification code repository
+func NewSQLPhoneVerificationRepository(db *sql.DB, driver string) domain.PhoneVerificationRepository {
+	return &SQLPhoneVerificationRepository{db: db, driver: driver}
+}
+
+// Create inserts a new phone verification code, unless max codes were sent
+// to the phone number since the given time
+func (r *SQLPhoneVerificationRepository) Create(ctx context.Context, code *domain.PhoneVerificationCode, since time.Time, max int) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	countQuery := `SELECT COUNT(*) FROM phone_verification_codes WHERE phone = ? AND created_at > ?`
+	var sent int
+	if err := tx.QueryRowContext(ctx, rebind(r.driver, countQuery), code.Phone, since.UTC()).Scan(&sent); err != nil {
+		return false, err
+	}
+	if sent >= max {
+		return false, nil
+	}
+
+	query := `
+		INSERT INTO phone_verification_codes (user_id, phone, code_hash, attempts, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	args := []interface{}{code.UserID, code.Phone, code.CodeHash, code.Attempts, code.ExpiresAt.UTC(), code.CreatedAt.UTC()}
+
+	if r.driver == DriverPostgres {
+		if err := tx.QueryRowContext(ctx, rebind(r.driver, query+` RETURNING id`), args...).Scan(&code.ID); err != nil {
+			return false, err
+		}
+	} else {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return false, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return false, err
+		}
+		code.ID = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetLatest retrieves the phone verification code last sent to a user
+func (r *SQLPhoneVerificationRepository) GetLatest(ctx context.Context, userID int) (*domain.PhoneVerificationCode, error) {
+	query := `
+		SELECT id, user_id, phone, code_hash, attempts, expires_at, used_at, created_at
+		FROM phone_verification_codes WHERE user_id = ?
+		ORDER BY id DESC LIMIT 1
+	`
+
+	code := &domain.PhoneVerificationCode{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, rebind(r.driver, query), userID).Scan(
+		&code.ID, &code.UserID, &code.Phone, &code.CodeHash, &code.Attempts, &code.ExpiresAt, &usedAt, &code.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvalidPhoneCode
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+	return code, nil
+}
+
+// RecordAttempt counts an attempt at entering a phone verification code,
+// unless max attempts were already made
+func (r *SQLPhoneVerificationRepository) RecordAttempt(ctx context.Context, id int, max int) (bool, error) {
+	query := `UPDATE phone_verification_codes SET attempts = attempts + 1 WHERE id = ? AND attempts < ?`
+	return r.execAffectingCode(ctx, query, id, max)
+}
+
+// MarkUsed flags a phone verification code as used, unless it has already been used
+func (r *SQLPhoneVerificationRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	query := `UPDATE phone_verification_codes SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	return r.execAffectingCode(ctx, query, usedAt.UTC(), id)
+}
+
+// execAffectingCode runs a statement and reports whether it changed a code
+func (r *SQLPhoneVerificationRepository) execAffectingCode(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	result, err := r.db.ExecContext(ctx, rebind(r.driver, query), args...)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
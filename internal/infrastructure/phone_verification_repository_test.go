@@ -0,0 +1,162 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// phoneVerificationRepositories returns every phone verification code repository implementation under test
+func phoneVerificationRepositories(t *testing.T) map[string]domain.PhoneVerificationRepository {
+	db := setupRefreshTokenTestDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	return map[string]domain.PhoneVerificationRepository{
+		"SQLite": NewSQLPhoneVerificationRepository(db, DriverSQLite),
+		"Memory": NewMemoryPhoneVerificationRepository(),
+	}
+}
+
+func newTestPhoneCode(userID int, phone, hash string, createdAt time.Time) *domain.PhoneVerificationCode {
+	return &domain.PhoneVerificationCode{
+		UserID:    userID,
+		Phone:     phone,
+		CodeHash:  hash,
+		ExpiresAt: createdAt.Add(10 * time.Minute),
+		CreatedAt: createdAt,
+	}
+}
+
+func TestPhoneVerificationRepository_CreateAndGetLatest(t *testing.T) {
+	for name, repo := range phoneVerificationRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+
+			if _, err := repo.GetLatest(ctx, 1); err != domain.ErrInvalidPhoneCode {
+				t.Errorf("Expected ErrInvalidPhoneCode before any code is sent, got %v", err)
+			}
+
+			first := newTestPhoneCode(1, "+66812345678", "hash-1", now)
+			second := newTestPhoneCode(1, "+66812345678", "hash-2", now)
+			for _, code := range []*domain.PhoneVerificationCode{first, second} {
+				created, err := repo.Create(ctx, code, now.Add(-time.Hour), 5)
+				if err != nil || !created {
+					t.Fatalf("Expected the code to be created, got %v, %v", created, err)
+				}
+			}
+			if first.ID == 0 || second.ID == first.ID {
+				t.Fatalf("Expected distinct IDs to be assigned, got %d and %d", first.ID, second.ID)
+			}
+
+			latest, err := repo.GetLatest(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if latest.ID != second.ID || latest.CodeHash != "hash-2" || latest.Phone != "+66812345678" ||
+				!latest.ExpiresAt.Equal(second.ExpiresAt) || latest.Attempts != 0 || latest.UsedAt != nil {
+				t.Errorf("Expected the latest code %+v, got %+v", second, latest)
+			}
+		})
+	}
+}
+
+func TestPhoneVerificationRepository_CreateLimitsSendsPerNumber(t *testing.T) {
+	for name, repo := range phoneVerificationRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now().UTC().Truncate(time.Second)
+			since := now.Add(-time.Hour)
+
+			// Sent before the window, so not counted
+			if _, err := repo.Create(ctx, newTestPhoneCode(1, "+66812345678", "old", now.Add(-2*time.Hour)), since, 2); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for i, hash := range []string{"hash-1", "hash-2"} {
+				if created, err := repo.Create(ctx, newTestPhoneCode(i+1, "+66812345678", hash, now), since, 2); err != nil || !created {
+					t.Fatalf("Expected code %d to be created, got %v, %v", i+1, created, err)
+				}
+			}
+
+			// The limit applies to the number, whichever account asks
+			code := newTestPhoneCode(3, "+66812345678", "hash-3", now)
+			created, err := repo.Create(ctx, code, since, 2)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if created {
+				t.Fatal("Expected a third code in the window to be refused")
+			}
+			if _, err := repo.GetLatest(ctx, 3); err != domain.ErrInvalidPhoneCode {
+				t.Errorf("Expected the refused code not to be stored, got %v", err)
+			}
+
+			if created, err := repo.Create(ctx, newTestPhoneCode(3, "+14155552671", "hash-4", now), since, 2); err != nil || !created {
+				t.Errorf("Expected another number to have its own limit, got %v, %v", created, err)
+			}
+		})
+	}
+}
+
+func TestPhoneVerificationRepository_RecordAttempt(t *testing.T) {
+	for name, repo := range phoneVerificationRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			code := newTestPhoneCode(1, "+66812345678", "hash-1", now)
+			if _, err := repo.Create(ctx, code, now.Add(-time.Hour), 5); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for i := 1; i <= 2; i++ {
+				if counted, err := repo.RecordAttempt(ctx, code.ID, 2); err != nil || !counted {
+					t.Fatalf("Expected attempt %d to be counted, got %v, %v", i, counted, err)
+				}
+			}
+			if counted, err := repo.RecordAttempt(ctx, code.ID, 2); err != nil || counted {
+				t.Errorf("Expected attempts past the limit to be refused, got %v, %v", counted, err)
+			}
+
+			stored, err := repo.GetLatest(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stored.Attempts != 2 {
+				t.Errorf("Expected 2 attempts, got %d", stored.Attempts)
+			}
+		})
+	}
+}
+
+func TestPhoneVerificationRepository_MarkUsed(t *testing.T) {
+	for name, repo := range phoneVerificationRepositories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			code := newTestPhoneCode(1, "+66812345678", "hash-1", now)
+			if _, err := repo.Create(ctx, code, now.Add(-time.Hour), 5); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			marked, err := repo.MarkUsed(ctx, code.ID, now)
+			if err != nil || !marked {
+				t.Fatalf("Expected code to be marked used, got %v, %v", marked, err)
+			}
+			if marked, _ := repo.MarkUsed(ctx, code.ID, now); marked {
+				t.Error("Expected a used code not to be marked again")
+			}
+
+			stored, err := repo.GetLatest(ctx, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stored.UsedAt == nil {
+				t.Error("Expected UsedAt to be set")
+			}
+		})
+	}
+}
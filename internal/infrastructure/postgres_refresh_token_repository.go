@@ -0,0 +1,100 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// PostgresRefreshTokenRepository implements domain.RefreshTokenRepository using PostgreSQL
+type PostgresRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresRefreshTokenRepository creates a new PostgreSQL refresh token repository
+func NewPostgresRefreshTokenRepository(db *sql.DB) *PostgresRefreshTokenRepository {
+	return &PostgresRefreshTokenRepository{db: db}
+}
+
+// Create inserts a new refresh token into the database
+func (r *PostgresRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+	if err != nil {
+		if isPgUniqueViolation(err) {
+			return domain.ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *PostgresRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens WHERE token_hash = $1
+	`
+
+	token := &domain.RefreshToken{}
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+		&token.ExpiresAt, &usedAt, &revokedAt, &token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed flags a refresh token as rotated, unless it has already been used
+func (r *PostgresRefreshTokenRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE id = $2 AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, usedAt, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every refresh token descending from the same login
+func (r *PostgresRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, revokedAt, familyID)
+	return err
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user
+func (r *PostgresRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, revokedAt, userID)
+	return err
+}
@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"hello-world/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// pgUniqueViolation is the PostgreSQL error code for unique constraint violations
+const pgUniqueViolation = "23505"
+
+// PostgresUserRepository implements domain.UserRepository using PostgreSQL
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresUserRepository creates a new PostgreSQL user repository
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+// Create inserts a new user into the database
+func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+
+	query := `
+		INSERT INTO users (email, email_normalized, password, firstname, lastname, phone, birthday, role, created_at, updated_at, email_verified_at, phone_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		user.Email, domain.EmailKey(user.Email), user.Password, user.FirstName, user.LastName,
+		user.Phone, user.Birthday, user.Role, user.CreatedAt, user.UpdatedAt, nullableTime(user.EmailVerifiedAt),
+		nullableTime(user.PhoneVerifiedAt),
+	).Scan(&user.ID)
+
+	if err != nil {
+		if isPgUniqueViolation(err) {
+			return domain.ErrUserAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByEmail retrieves a user by email, regardless of its case
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return getUser(ctx, r.db, DriverPostgres, `email_normalized = ? AND deleted_at IS NULL`, domain.EmailKey(email))
+}
+
+// GetByID retrieves a user by ID
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
+	return getUser(ctx, r.db, DriverPostgres, `id = ? AND deleted_at IS NULL`, id)
+}
+
+// Update updates an existing user, except for its password and token version
+func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
+	query := `
+		UPDATE users SET
+			email = $1, email_normalized = $2, firstname = $3, lastname = $4,
+			phone = $5, birthday = $6, role = $7, updated_at = $8, email_verified_at = $9,
+			pending_email = $10, phone_verified_at = $11
+		WHERE id = $12 AND deleted_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		user.Email, domain.EmailKey(user.Email), user.FirstName, user.LastName,
+		user.Phone, user.Birthday, user.Role, user.UpdatedAt, nullableTime(user.EmailVerifiedAt),
+		nullableString(user.PendingEmail), nullableTime(user.PhoneVerifiedAt), user.ID,
+	)
+
+	if err != nil && isPgUniqueViolation(err) {
+		return domain.ErrUserAlreadyExists
+	}
+	return err
+}
+
+// Delete removes a user by ID
+func (r *PostgresUserRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM users WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// Exists checks if a user with the given email exists, regardless of its case
+func (r *PostgresUserRepository) Exists(ctx context.Context, email string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email_normalized = $1 AND deleted_at IS NULL)`
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, domain.EmailKey(email)).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// List returns one page of users matching the query
+func (r *PostgresUserRepository) List(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	return listUsers(ctx, r.db, DriverPostgres, query)
+}
+
+// SoftDelete marks an active user as deleted
+func (r *PostgresUserRepository) SoftDelete(ctx context.Context, id int, deletedAt time.Time) error {
+	return softDeleteUser(ctx, r.db, DriverPostgres, id, deletedAt)
+}
+
+// GetDeletedByID retrieves a soft-deleted user by ID
+func (r *PostgresUserRepository) GetDeletedByID(ctx context.Context, id int) (*domain.User, error) {
+	return getUser(ctx, r.db, DriverPostgres, `id = ? AND deleted_at IS NOT NULL`, id)
+}
+
+// Restore clears the deletion mark of a soft-deleted user
+func (r *PostgresUserRepository) Restore(ctx context.Context, id int) error {
+	return restoreUser(ctx, r.db, DriverPostgres, id)
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the cut-off
+func (r *PostgresUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return purgeDeletedUsers(ctx, r.db, DriverPostgres, deletedBefore)
+}
+
+// RecordVerificationSent notes that a verification email is sent, unless one was sent after notBefore
+func (r *PostgresUserRepository) RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error) {
+	return recordVerificationSent(ctx, r.db, DriverPostgres, id, sentAt, notBefore)
+}
+
+// ReplacePasswordHash swaps the password hash, unless the password changed since oldHash was read
+func (r *PostgresUserRepository) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	return replacePasswordHash(ctx, r.db, DriverPostgres, id, oldHash, newHash)
+}
+
+// UpdatePassword stores a new password hash and bumps the token version, returning the new one
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	return updatePassword(ctx, r.db, DriverPostgres, id, hash, updatedAt)
+}
+
+// isPgUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isPgUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,107 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteRefreshTokenRepository implements domain.RefreshTokenRepository using SQLite
+type SQLiteRefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRefreshTokenRepository creates a new SQLite refresh token repository
+func NewSQLiteRefreshTokenRepository(db *sql.DB) *SQLiteRefreshTokenRepository {
+	return &SQLiteRefreshTokenRepository{db: db}
+}
+
+// Create inserts a new refresh token into the database
+func (r *SQLiteRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt,
+	)
+	if err != nil {
+		if isSQLiteUniqueViolation(err) {
+			return domain.ErrInvalidRefreshToken
+		}
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *SQLiteRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens WHERE token_hash = ?
+	`
+
+	token := &domain.RefreshToken{}
+	var usedAt, revokedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+		&token.ExpiresAt, &usedAt, &revokedAt, &token.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+
+	return token, nil
+}
+
+// MarkUsed flags a refresh token as rotated, unless it has already been used
+func (r *SQLiteRefreshTokenRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	query := `UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, usedAt, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// RevokeFamily revokes every refresh token descending from the same login
+func (r *SQLiteRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, revokedAt, familyID)
+	return err
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user
+func (r *SQLiteRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, revokedAt, userID)
+	return err
+}
@@ -0,0 +1,136 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+func setupRefreshTokenTestDB(t *testing.T) *sql.DB {
+	db, err := NewDatabase(DatabaseConfig{Driver: "sqlite3", DSN: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func newTestRefreshToken(hash, familyID string) *domain.RefreshToken {
+	now := time.Now()
+	return &domain.RefreshToken{
+		UserID:    1,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	}
+}
+
+func TestSQLiteRefreshTokenRepository_CreateAndGetByHash(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	token := newTestRefreshToken("hash-1", "family-1")
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if token.ID == 0 {
+		t.Error("Expected token ID to be set after creation")
+	}
+
+	stored, err := repo.GetByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stored.FamilyID != "family-1" || stored.UserID != 1 {
+		t.Errorf("Unexpected stored token: %+v", stored)
+	}
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		t.Error("Expected new token to be neither used nor revoked")
+	}
+}
+
+func TestSQLiteRefreshTokenRepository_GetByHash_NotFound(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRefreshTokenRepository(db)
+
+	_, err := repo.GetByHash(context.Background(), "missing")
+	if err != domain.ErrInvalidRefreshToken {
+		t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestSQLiteRefreshTokenRepository_MarkUsed(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	token := newTestRefreshToken("hash-1", "family-1")
+	if err := repo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+
+	marked, err := repo.MarkUsed(ctx, token.ID, time.Now())
+	if err != nil || !marked {
+		t.Fatalf("Expected first MarkUsed to succeed, got %v, %v", marked, err)
+	}
+
+	marked, err = repo.MarkUsed(ctx, token.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if marked {
+		t.Error("Expected second MarkUsed to report the token as already used")
+	}
+
+	stored, err := repo.GetByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stored.UsedAt == nil {
+		t.Error("Expected used_at to be set")
+	}
+}
+
+func TestSQLiteRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	for _, token := range []*domain.RefreshToken{
+		newTestRefreshToken("hash-1", "family-1"),
+		newTestRefreshToken("hash-2", "family-1"),
+		newTestRefreshToken("hash-3", "family-2"),
+	} {
+		if err := repo.Create(ctx, token); err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+	}
+
+	if err := repo.RevokeFamily(ctx, "family-1", time.Now()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for hash, expectRevoked := range map[string]bool{"hash-1": true, "hash-2": true, "hash-3": false} {
+		stored, err := repo.GetByHash(ctx, hash)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if (stored.RevokedAt != nil) != expectRevoked {
+			t.Errorf("Token %s: expected revoked=%v", hash, expectRevoked)
+		}
+	}
+}
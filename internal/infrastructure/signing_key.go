@@ -0,0 +1,173 @@
+package infrastructure
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"hello-world/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms supported for access tokens
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing
+const minRSAKeyBits = 2048
+
+// SigningKey is a key access tokens are signed and verified with. ID is
+// written to the kid header of every token it signs.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACSigningKey creates an HS256 key from a shared secret. The key ID is
+// derived from a hash of the secret, so every instance sharing the secret
+// agrees on it without revealing the secret.
+func NewHMACSigningKey(secret []byte) *SigningKey {
+	sum := sha256.Sum256(secret)
+	return &SigningKey{
+		ID:        "hs256-" + hex.EncodeToString(sum[:8]),
+		Algorithm: AlgorithmHS256,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// NewRSASigningKey creates an RS256 key. The key ID is the RFC 7638
+// thumbprint of the public key.
+func NewRSASigningKey(privateKey *rsa.PrivateKey) (*SigningKey, error) {
+	if privateKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA signing keys must have at least %d bits, got %d", minRSAKeyBits, privateKey.N.BitLen())
+	}
+	key := &SigningKey{
+		Algorithm: AlgorithmRS256,
+		method:    jwt.SigningMethodRS256,
+		signKey:   privateKey,
+		verifyKey: &privateKey.PublicKey,
+	}
+	key.ID = key.thumbprint()
+	return key, nil
+}
+
+// NewEd25519SigningKey creates an EdDSA key. The key ID is the RFC 7638
+// thumbprint of the public key.
+func NewEd25519SigningKey(privateKey ed25519.PrivateKey) *SigningKey {
+	key := &SigningKey{
+		Algorithm: AlgorithmEdDSA,
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   privateKey,
+		verifyKey: privateKey.Public(),
+	}
+	key.ID = key.thumbprint()
+	return key
+}
+
+// ParseSigningKeyPEM parses an RSA (PKCS #1 or PKCS #8) or Ed25519 (PKCS #8)
+// private key. The algorithm follows from the key type.
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q, expected a private key", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return NewRSASigningKey(key)
+	case ed25519.PrivateKey:
+		return NewEd25519SigningKey(key), nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T, expected RSA or Ed25519", privateKey)
+	}
+}
+
+// LoadSigningKeyFile reads a PEM encoded private key from path
+func LoadSigningKeyFile(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseSigningKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// PublicJWK returns the public half of the key as a JSON Web Key. Shared
+// secrets have no public half, so it returns false for HS256 keys.
+func (k *SigningKey) PublicJWK() (domain.JSONWebKey, bool) {
+	jwk := domain.JSONWebKey{KeyID: k.ID, Algorithm: k.Algorithm, Use: "sig"}
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return domain.JSONWebKey{}, false
+	}
+	return jwk, true
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint of the public key
+func (k *SigningKey) thumbprint() string {
+	jwk, _ := k.PublicJWK()
+
+	// The required members in lexicographic order, without whitespace
+	var members interface{}
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.Exponent, jwk.KeyType, jwk.Modulus}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,196 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// encryptedKeyPrefix marks private_key values encrypted with the key
+// encryption key. Neither PEM nor base64 contain a colon, so unencrypted
+// values written before a key encryption key was configured are told apart.
+const encryptedKeyPrefix = "aes-gcm:"
+
+// SQLSigningKeyStore implements SigningKeyStore using the SQL database
+// (SQLite or PostgreSQL), so every instance sharing the database signs with
+// the same key ring. Key material is encrypted with AES-GCM when a key
+// encryption key is given.
+type SQLSigningKeyStore struct {
+	db     *sql.DB
+	driver string
+	aead   cipher.AEAD
+}
+
+// NewSQLSigningKeyStore creates a new SQL signing key store. With a 32 byte
+// keyEncryptionKey, key material is encrypted at rest and keys stored
+// unencrypted before are encrypted right away; without one, it is stored
+// as is.
+func NewSQLSigningKeyStore(db *sql.DB, driver string, keyEncryptionKey []byte) (*SQLSigningKeyStore, error) {
+	s := &SQLSigningKeyStore{db: db, driver: driver}
+	if keyEncryptionKey == nil {
+		return s, nil
+	}
+
+	block, err := aes.NewCipher(keyEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encryption key: %w", err)
+	}
+	if s.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+	if err := s.encryptStoredKeys(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to encrypt stored signing keys: %w", err)
+	}
+	return s, nil
+}
+
+// encryptStoredKeys encrypts the key material stored unencrypted
+func (s *SQLSigningKeyStore) encryptStoredKeys(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT kid, private_key FROM signing_keys WHERE private_key <> ''`)
+	if err != nil {
+		return err
+	}
+	plaintext := make(map[string]string)
+	for rows.Next() {
+		var kid, privateKey string
+		if err := rows.Scan(&kid, &privateKey); err != nil {
+			rows.Close()
+			return err
+		}
+		if !strings.HasPrefix(privateKey, encryptedKeyPrefix) {
+			plaintext[kid] = privateKey
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	updateSQL := rebind(s.driver, `UPDATE signing_keys SET private_key = ? WHERE kid = ?`)
+	for kid, privateKey := range plaintext {
+		sealed, err := s.seal(kid, privateKey)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, updateSQL, sealed, kid); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// seal encrypts the key material of key kid, binding it to the kid so it
+// cannot be moved to another row
+func (s *SQLSigningKeyStore) seal(kid, privateKey string) (string, error) {
+	if s.aead == nil || privateKey == "" {
+		return privateKey, nil
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(privateKey), []byte(kid))
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts key material written by seal. Unencrypted key material is
+// returned as is.
+func (s *SQLSigningKeyStore) open(kid, stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, encryptedKeyPrefix)
+	if !ok {
+		return stored, nil
+	}
+	if s.aead == nil {
+		return "", fmt.Errorf("signing key %s is encrypted and no key encryption key is configured", kid)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", fmt.Errorf("signing key %s is corrupt", kid)
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	privateKey, err := s.aead.Open(nil, nonce, ciphertext, []byte(kid))
+	if err != nil {
+		return "", fmt.Errorf("signing key %s cannot be decrypted with the configured key encryption key", kid)
+	}
+	return string(privateKey), nil
+}
+
+// List returns every stored key, oldest first
+func (s *SQLSigningKeyStore) List(ctx context.Context) ([]StoredSigningKey, error) {
+	query := `
+		SELECT kid, algorithm, private_key, created_at, retired_at, expires_at
+		FROM signing_keys
+		ORDER BY created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []StoredSigningKey
+	for rows.Next() {
+		var key StoredSigningKey
+		var retiredAt, expiresAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &retiredAt, &expiresAt); err != nil {
+			return nil, err
+		}
+		if key.PrivateKey, err = s.open(key.ID, key.PrivateKey); err != nil {
+			return nil, err
+		}
+		if retiredAt.Valid {
+			key.RetiredAt = &retiredAt.Time
+		}
+		if expiresAt.Valid {
+			key.ExpiresAt = &expiresAt.Time
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Activate retires the active keys and stores key as the new active key in
+// one transaction
+func (s *SQLSigningKeyStore) Activate(ctx context.Context, key StoredSigningKey, expiresAt time.Time) error {
+	privateKey, err := s.seal(key.ID, key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	retireSQL := `UPDATE signing_keys SET retired_at = ?, expires_at = ? WHERE retired_at IS NULL`
+	if _, err := tx.ExecContext(ctx, rebind(s.driver, retireSQL), key.CreatedAt.UTC(), expiresAt.UTC()); err != nil {
+		return err
+	}
+
+	insertSQL := `INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES (?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, rebind(s.driver, insertSQL), key.ID, key.Algorithm, privateKey, key.CreatedAt.UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EraseExpired deletes the key material of keys that expired before now
+func (s *SQLSigningKeyStore) EraseExpired(ctx context.Context, now time.Time) error {
+	query := `UPDATE signing_keys SET private_key = '' WHERE expires_at <= ? AND private_key <> ''`
+	_, err := s.db.ExecContext(ctx, rebind(s.driver, query), now.UTC())
+	return err
+}
@@ -0,0 +1,108 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeyEncryptionKey is a valid key encryption key for the signing key store
+var testKeyEncryptionKey = bytes.Repeat([]byte{7}, 32)
+
+// storedPrivateKey reads the private_key column of kid as written
+func storedPrivateKey(t *testing.T, db *sql.DB, kid string) string {
+	var privateKey string
+	if err := db.QueryRow(`SELECT private_key FROM signing_keys WHERE kid = ?`, kid).Scan(&privateKey); err != nil {
+		t.Fatalf("Failed to read signing key: %v", err)
+	}
+	return privateKey
+}
+
+func TestSQLSigningKeyStore_EncryptsKeyMaterial(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	store, err := NewSQLSigningKeyStore(db, DriverSQLite, testKeyEncryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	key := StoredSigningKey{ID: "kid-1", Algorithm: AlgorithmHS256, PrivateKey: "c2VjcmV0", CreatedAt: time.Now()}
+	if err := store.Activate(ctx, key, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if stored := storedPrivateKey(t, db, "kid-1"); !strings.HasPrefix(stored, encryptedKeyPrefix) || strings.Contains(stored, key.PrivateKey) {
+		t.Errorf("Expected the key material to be encrypted, got %q", stored)
+	}
+	keys, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].PrivateKey != key.PrivateKey {
+		t.Errorf("Expected the key material to be decrypted, got %+v", keys)
+	}
+
+	// Key material moved to another row does not decrypt
+	if _, err := db.Exec(`INSERT INTO signing_keys (kid, algorithm, private_key, created_at) VALUES ('kid-2', ?, ?, ?)`,
+		AlgorithmHS256, storedPrivateKey(t, db, "kid-1"), time.Now().UTC()); err != nil {
+		t.Fatalf("Failed to insert signing key: %v", err)
+	}
+	if _, err := store.List(ctx); err == nil {
+		t.Error("Expected key material copied to another key to be rejected")
+	}
+}
+
+func TestSQLSigningKeyStore_EncryptsUnencryptedKeys(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	plain, err := NewSQLSigningKeyStore(db, DriverSQLite, nil)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	key := StoredSigningKey{ID: "kid-1", Algorithm: AlgorithmHS256, PrivateKey: "c2VjcmV0", CreatedAt: time.Now()}
+	if err := plain.Activate(ctx, key, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stored := storedPrivateKey(t, db, "kid-1"); stored != key.PrivateKey {
+		t.Fatalf("Expected the key material to be stored as is without a key encryption key, got %q", stored)
+	}
+
+	// Configuring a key encryption key encrypts the keys stored before
+	store, err := NewSQLSigningKeyStore(db, DriverSQLite, testKeyEncryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if stored := storedPrivateKey(t, db, "kid-1"); !strings.HasPrefix(stored, encryptedKeyPrefix) {
+		t.Errorf("Expected the key material to be encrypted, got %q", stored)
+	}
+	if keys, err := store.List(ctx); err != nil || len(keys) != 1 || keys[0].PrivateKey != key.PrivateKey {
+		t.Errorf("Expected the key material to be decrypted, got %+v (%v)", keys, err)
+	}
+
+	// Without the key encryption key, or with another one, the keys cannot be read
+	if _, err := plain.List(ctx); err == nil {
+		t.Error("Expected encrypted keys to be rejected without a key encryption key")
+	}
+	other, err := NewSQLSigningKeyStore(db, DriverSQLite, bytes.Repeat([]byte{8}, 32))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if _, err := other.List(ctx); err == nil {
+		t.Error("Expected encrypted keys to be rejected with another key encryption key")
+	}
+}
+
+func TestNewSQLSigningKeyStore_InvalidKeyEncryptionKey(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	if _, err := NewSQLSigningKeyStore(db, DriverSQLite, []byte("too short")); err == nil {
+		t.Error("Expected a key encryption key of the wrong length to be rejected")
+	}
+}
@@ -0,0 +1,163 @@
+package infrastructure
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func generateRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+func generateEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	return key
+}
+
+func pkcs8PEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestParseSigningKeyPEM(t *testing.T) {
+	rsaKey := generateRSAKey(t, 2048)
+	ed25519Key := generateEd25519Key(t)
+
+	tests := []struct {
+		name              string
+		pem               []byte
+		expectedAlgorithm string
+		expectedKeyType   string
+	}{
+		{
+			name:              "RSA PKCS #1",
+			pem:               pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			expectedAlgorithm: AlgorithmRS256,
+			expectedKeyType:   "RSA",
+		},
+		{
+			name:              "RSA PKCS #8",
+			pem:               pkcs8PEM(t, rsaKey),
+			expectedAlgorithm: AlgorithmRS256,
+			expectedKeyType:   "RSA",
+		},
+		{
+			name:              "Ed25519 PKCS #8",
+			pem:               pkcs8PEM(t, ed25519Key),
+			expectedAlgorithm: AlgorithmEdDSA,
+			expectedKeyType:   "OKP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseSigningKeyPEM(tt.pem)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if key.Algorithm != tt.expectedAlgorithm {
+				t.Errorf("Expected algorithm %s, got %s", tt.expectedAlgorithm, key.Algorithm)
+			}
+			if key.ID == "" {
+				t.Error("Expected a key ID")
+			}
+
+			jwk, ok := key.PublicJWK()
+			if !ok {
+				t.Fatal("Expected a public JWK")
+			}
+			if jwk.KeyType != tt.expectedKeyType || jwk.KeyID != key.ID || jwk.Algorithm != tt.expectedAlgorithm || jwk.Use != "sig" {
+				t.Errorf("Unexpected JWK: %+v", jwk)
+			}
+		})
+	}
+}
+
+func TestParseSigningKeyPEM_Invalid(t *testing.T) {
+	publicDER, err := x509.MarshalPKIXPublicKey(generateEd25519Key(t).Public())
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pem  []byte
+	}{
+		{name: "Not PEM", pem: []byte("not a key")},
+		{name: "Public key", pem: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})},
+		{name: "Corrupt private key", pem: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")})},
+		{name: "RSA key too small", pem: pkcs8PEM(t, generateRSAKey(t, 1024))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSigningKeyPEM(tt.pem); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadSigningKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	if err := os.WriteFile(path, pkcs8PEM(t, generateEd25519Key(t)), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	key, err := LoadSigningKeyFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if key.Algorithm != AlgorithmEdDSA {
+		t.Errorf("Expected algorithm %s, got %s", AlgorithmEdDSA, key.Algorithm)
+	}
+
+	if _, err := LoadSigningKeyFile(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestSigningKey_Thumbprint(t *testing.T) {
+	// Example from RFC 7638, section 3.1
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatalf("Failed to decode modulus: %v", err)
+	}
+	key := &SigningKey{Algorithm: AlgorithmRS256, verifyKey: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}}
+
+	if thumbprint := key.thumbprint(); thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expected the RFC 7638 thumbprint, got %s", thumbprint)
+	}
+}
+
+func TestNewHMACSigningKey(t *testing.T) {
+	key := NewHMACSigningKey([]byte("test-secret-key-that-is-32-bytes!"))
+
+	if key.Algorithm != AlgorithmHS256 {
+		t.Errorf("Expected algorithm %s, got %s", AlgorithmHS256, key.Algorithm)
+	}
+	if key.ID != NewHMACSigningKey([]byte("test-secret-key-that-is-32-bytes!")).ID {
+		t.Error("Expected the key ID to be derived from the secret")
+	}
+	if _, ok := key.PublicJWK(); ok {
+		t.Error("Expected no public JWK for a shared secret")
+	}
+}
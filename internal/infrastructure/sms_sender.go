@@ -0,0 +1,98 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// Supported SMS drivers
+const (
+	SMSDriverWebhook = "webhook"
+	SMSDriverLog     = "log" // text messages are written out instead of sent, for development
+)
+
+// smsWebhookTimeout bounds a request to the SMS webhook
+const smsWebhookTimeout = 10 * time.Second
+
+// WebhookSMSConfig holds the settings used to hand text messages to an SMS
+// gateway over HTTP
+type WebhookSMSConfig struct {
+	URL string
+	// Token, if set, is sent as a bearer token in the Authorization header
+	Token string
+}
+
+// WebhookSMSSender implements domain.SMSSender by posting every message as
+// JSON, {"to": "+14155552671", "body": "..."}, to a gateway's webhook. Any
+// 2xx response counts as accepted.
+type WebhookSMSSender struct {
+	config WebhookSMSConfig
+	client *http.Client
+}
+
+// NewWebhookSMSSender creates an SMS sender that posts to the configured webhook
+func NewWebhookSMSSender(config WebhookSMSConfig) *WebhookSMSSender {
+	return &WebhookSMSSender{config: config, client: &http.Client{Timeout: smsWebhookTimeout}}
+}
+
+// Send posts the text message to the webhook
+func (s *WebhookSMSSender) Send(ctx context.Context, message domain.SMSMessage) error {
+	payload, err := json.Marshal(struct {
+		To   string `json:"to"`
+		Body string `json:"body"`
+	}{To: message.To, Body: message.Body})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("SMS webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogSMSSender implements domain.SMSSender by writing text messages to a
+// writer, such as a file or the console, so flows that text codes can be
+// tried locally
+type LogSMSSender struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogSMSSender creates an SMS sender that writes every message to w
+func NewLogSMSSender(w io.Writer) *LogSMSSender {
+	return &LogSMSSender{w: w}
+}
+
+// Send writes the text message out
+func (s *LogSMSSender) Send(ctx context.Context, message domain.SMSMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.w, "----- sms -----\nTo: %s\n\n%s\n----- end of sms -----\n", message.To, message.Body)
+	return err
+}
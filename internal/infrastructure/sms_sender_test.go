@@ -0,0 +1,66 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hello-world/internal/domain"
+)
+
+func TestLogSMSSender_Send(t *testing.T) {
+	var buf bytes.Buffer
+	sender := NewLogSMSSender(&buf)
+
+	if err := sender.Send(context.Background(), domain.SMSMessage{To: "+66812345678", Body: "Your code is 123456"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"To: +66812345678", "Your code is 123456"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, buf.String())
+		}
+	}
+}
+
+func TestWebhookSMSSender_Send(t *testing.T) {
+	var received struct {
+		To   string `json:"to"`
+		Body string `json:"body"`
+	}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode webhook payload: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSMSSender(WebhookSMSConfig{URL: server.URL, Token: "gateway-token"})
+	if err := sender.Send(context.Background(), domain.SMSMessage{To: "+66812345678", Body: "Your code is 123456"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if received.To != "+66812345678" || received.Body != "Your code is 123456" {
+		t.Errorf("Expected the message to be posted, got %+v", received)
+	}
+	if authorization != "Bearer gateway-token" {
+		t.Errorf("Expected the token as a bearer token, got %q", authorization)
+	}
+}
+
+func TestWebhookSMSSender_SendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sender := NewWebhookSMSSender(WebhookSMSConfig{URL: server.URL})
+	if err := sender.Send(context.Background(), domain.SMSMessage{To: "+66812345678", Body: "Your code is 123456"}); err == nil {
+		t.Error("Expected a failed webhook request to be reported")
+	}
+}
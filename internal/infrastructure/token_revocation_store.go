@@ -0,0 +1,223 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"hello-world/internal/domain"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// userRevocation records a "log out everywhere" cut-off for a user
+type userRevocation struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// SQLTokenRevocationStore implements domain.TokenRevocationStore using the
+// SQL database (SQLite or PostgreSQL). Revocations are written through to the
+// database and kept in an in-memory cache, so checking a token on every
+// request does not hit the database.
+// The cache is reloaded by the background pruner, which also picks up
+// revocations made by other instances sharing the database.
+type SQLTokenRevocationStore struct {
+	db     *sql.DB
+	driver string
+
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[int]userRevocation
+
+	stop chan struct{}
+	done chan struct{}
+	now  func() time.Time
+}
+
+// NewSQLTokenRevocationStore creates a new revocation store and loads the cache
+func NewSQLTokenRevocationStore(db *sql.DB, driver string) (*SQLTokenRevocationStore, error) {
+	s := &SQLTokenRevocationStore{
+		db:     db,
+		driver: driver,
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]userRevocation),
+		now:    time.Now,
+	}
+
+	if err := s.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RevokeToken revokes a single access token until it expires
+func (s *SQLTokenRevocationStore) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (token_id, user_id, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(token_id) DO NOTHING
+	`
+	if _, err := s.db.ExecContext(ctx, rebind(s.driver, query), tokenID, userID, expiresAt.UTC()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens[tokenID] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeUserTokens revokes every access token of a user issued before the
+// cut-off, rounded down to the precision of iat
+func (s *SQLTokenRevocationStore) RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	issuedBefore = issuedBefore.Truncate(IssuedAtPrecision)
+	query := `
+		INSERT INTO user_token_revocations (user_id, revoked_before, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET revoked_before = excluded.revoked_before, expires_at = excluded.expires_at
+	`
+	if _, err := s.db.ExecContext(ctx, rebind(s.driver, query), userID, issuedBefore.UTC(), expiresAt.UTC()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.users[userID] = userRevocation{revokedBefore: issuedBefore, expiresAt: expiresAt}
+	s.mu.Unlock()
+	return nil
+}
+
+// IsRevoked reports whether the token was revoked individually or by a user-wide cut-off
+func (s *SQLTokenRevocationStore) IsRevoked(ctx context.Context, claims *domain.TokenClaims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if claims.TokenID != "" {
+		if _, revoked := s.tokens[claims.TokenID]; revoked {
+			return true, nil
+		}
+	}
+
+	if revocation, ok := s.users[claims.UserID]; ok {
+		// A login right after logging out everywhere is issued at or after
+		// the cut-off and stays valid
+		if claims.IssuedAt.Before(revocation.revokedBefore) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// PruneExpired removes entries for tokens that have expired anyway
+func (s *SQLTokenRevocationStore) PruneExpired(ctx context.Context) error {
+	now := s.now()
+
+	if _, err := s.db.ExecContext(ctx, rebind(s.driver, `DELETE FROM revoked_tokens WHERE expires_at <= ?`), now.UTC()); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, rebind(s.driver, `DELETE FROM user_token_revocations WHERE expires_at <= ?`), now.UTC()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tokenID, expiresAt := range s.tokens {
+		if !now.Before(expiresAt) {
+			delete(s.tokens, tokenID)
+		}
+	}
+	for userID, revocation := range s.users {
+		if !now.Before(revocation.expiresAt) {
+			delete(s.users, userID)
+		}
+	}
+	return nil
+}
+
+// Reload replaces the cache with the unexpired entries stored in the database
+func (s *SQLTokenRevocationStore) Reload(ctx context.Context) error {
+	now := s.now().UTC()
+	tokens := make(map[string]time.Time)
+	users := make(map[int]userRevocation)
+
+	rows, err := s.db.QueryContext(ctx, rebind(s.driver, `SELECT token_id, expires_at FROM revoked_tokens WHERE expires_at > ?`), now)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var tokenID string
+		var expiresAt time.Time
+		if err := rows.Scan(&tokenID, &expiresAt); err != nil {
+			rows.Close()
+			return err
+		}
+		tokens[tokenID] = expiresAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = s.db.QueryContext(ctx, rebind(s.driver, `SELECT user_id, revoked_before, expires_at FROM user_token_revocations WHERE expires_at > ?`), now)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var userID int
+		var revocation userRevocation
+		if err := rows.Scan(&userID, &revocation.revokedBefore, &revocation.expiresAt); err != nil {
+			rows.Close()
+			return err
+		}
+		users[userID] = revocation
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.users = users
+	s.mu.Unlock()
+	return nil
+}
+
+// StartPruner prunes expired entries and refreshes the cache every interval
+// until Close is called
+func (s *SQLTokenRevocationStore) StartPruner(interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				ctx := context.Background()
+				if err := s.PruneExpired(ctx); err != nil {
+					log.Printf("Failed to prune revoked tokens: %v", err)
+				}
+				if err := s.Reload(ctx); err != nil {
+					log.Printf("Failed to reload revoked tokens: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the background pruner, if it was started
+func (s *SQLTokenRevocationStore) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	return nil
+}
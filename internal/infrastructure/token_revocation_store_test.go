@@ -0,0 +1,210 @@
+package infrastructure
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+func TestSQLTokenRevocationStore_RevokeToken(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	claims := &domain.TokenClaims{UserID: 1, TokenID: "jti-1", IssuedAt: time.Now()}
+	if revoked, _ := store.IsRevoked(ctx, claims); revoked {
+		t.Fatal("Expected token not to be revoked yet")
+	}
+
+	if err := store.RevokeToken(ctx, "jti-1", 1, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, claims); !revoked {
+		t.Error("Expected token to be revoked")
+	}
+
+	other := &domain.TokenClaims{UserID: 1, TokenID: "jti-2", IssuedAt: time.Now()}
+	if revoked, _ := store.IsRevoked(ctx, other); revoked {
+		t.Error("Expected other token not to be revoked")
+	}
+}
+
+func TestSQLTokenRevocationStore_RevokeUserTokens(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	cutoff := time.Now().Truncate(time.Second).Add(400 * time.Millisecond)
+	if err := store.RevokeUserTokens(ctx, 1, cutoff, cutoff.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		claims   *domain.TokenClaims
+		expected bool
+	}{
+		{"Issued before cut-off", &domain.TokenClaims{UserID: 1, IssuedAt: cutoff.Add(-time.Minute)}, true},
+		{"Issued after cut-off", &domain.TokenClaims{UserID: 1, IssuedAt: cutoff.Add(time.Minute)}, false},
+		{"Issued earlier in the second of the cut-off", &domain.TokenClaims{UserID: 1, IssuedAt: cutoff.Add(-200 * time.Millisecond)}, true},
+		{"Issued later in the second of the cut-off", &domain.TokenClaims{UserID: 1, IssuedAt: cutoff.Add(200 * time.Millisecond)}, false},
+		{"Issued at the cut-off", &domain.TokenClaims{UserID: 1, IssuedAt: cutoff}, false},
+		{"Other user", &domain.TokenClaims{UserID: 2, IssuedAt: cutoff.Add(-time.Minute)}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			revoked, err := store.IsRevoked(ctx, tc.claims)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if revoked != tc.expected {
+				t.Errorf("Expected revoked=%v, got %v", tc.expected, revoked)
+			}
+		})
+	}
+}
+
+func TestSQLTokenRevocationStore_ReloadFromDatabase(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	first, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if err := first.RevokeToken(context.Background(), "jti-1", 1, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// A second instance sharing the database loads the revocation on start
+	second, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if revoked, _ := second.IsRevoked(context.Background(), &domain.TokenClaims{TokenID: "jti-1"}); !revoked {
+		t.Error("Expected revocation to be loaded from the database")
+	}
+}
+
+func TestSQLTokenRevocationStore_RevokesTokenIssuedInTheSecondOfLogoutAll(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	// A token refreshed 200 ms before the victim logs out everywhere
+	second := time.Now().Truncate(time.Second)
+	authService := NewJWTAuthService(testJWTConfig)
+	authService.now = func() time.Time { return second.Add(100 * time.Millisecond) }
+	token, err := authService.GenerateToken(1, "test@example.com", domain.RoleUser, 0)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	authService.now = func() time.Time { return second.Add(300 * time.Millisecond) }
+	claims, err := authService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+
+	cutoff := second.Add(300 * time.Millisecond)
+	if err := store.RevokeUserTokens(ctx, 1, cutoff, cutoff.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if revoked, _ := store.IsRevoked(ctx, claims); !revoked {
+		t.Error("Expected the token to be revoked")
+	}
+
+	// The cut-off keeps its precision in the database
+	reloaded, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	if revoked, _ := reloaded.IsRevoked(ctx, claims); !revoked {
+		t.Error("Expected the token to be revoked after reloading")
+	}
+}
+
+func TestSQLTokenRevocationStore_PruneExpired(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	now := time.Now()
+	store.RevokeToken(ctx, "expired", 1, now.Add(-time.Minute))
+	store.RevokeToken(ctx, "active", 1, now.Add(time.Hour))
+	store.RevokeUserTokens(ctx, 2, now.Add(-2*time.Hour), now.Add(-time.Hour))
+
+	if err := store.PruneExpired(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM revoked_tokens").Scan(&count); err != nil {
+		t.Fatalf("Failed to count revoked tokens: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 revoked token to remain, got %d", count)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM user_token_revocations").Scan(&count); err != nil {
+		t.Fatalf("Failed to count user revocations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected user revocations to be pruned, got %d", count)
+	}
+
+	if revoked, _ := store.IsRevoked(ctx, &domain.TokenClaims{TokenID: "expired"}); revoked {
+		t.Error("Expected expired entry to be removed from the cache")
+	}
+	if revoked, _ := store.IsRevoked(ctx, &domain.TokenClaims{TokenID: "active"}); !revoked {
+		t.Error("Expected active entry to remain in the cache")
+	}
+}
+
+func TestSQLTokenRevocationStore_StartPrunerAndClose(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	store, err := NewSQLTokenRevocationStore(db, DriverSQLite)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	store.RevokeToken(context.Background(), "expired", 1, time.Now().Add(-time.Minute))
+	store.StartPruner(10 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM revoked_tokens").Scan(&count); err != nil {
+		t.Fatalf("Failed to count revoked tokens: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected background pruner to remove expired entries, got %d", count)
+	}
+}
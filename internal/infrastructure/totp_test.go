@@ -0,0 +1,123 @@
+package infrastructure
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// rfc6238Secret is the SHA1 test key of RFC 6238, appendix B
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPProvider_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	provider := &TOTPProvider{}
+	for _, tt := range tests {
+		step, ok := provider.Verify(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("Expected code %s to be valid at %d", tt.code, tt.unix)
+			continue
+		}
+		if step != tt.unix/totpPeriod {
+			t.Errorf("Expected step %d, got %d", tt.unix/totpPeriod, step)
+		}
+	}
+}
+
+func TestTOTPProvider_Verify_Skew(t *testing.T) {
+	provider := NewTOTPProvider("Test")
+	at := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name     string
+		offset   time.Duration
+		expected bool
+	}{
+		{name: "Previous step", offset: -30 * time.Second, expected: true},
+		{name: "Next step", offset: 30 * time.Second, expected: true},
+		{name: "Two steps ago", offset: -60 * time.Second, expected: false},
+		{name: "Two steps ahead", offset: 60 * time.Second, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := provider.Verify(rfc6238Secret, "081804", at.Add(tt.offset)); ok != tt.expected {
+				t.Errorf("Expected valid=%v, got %v", tt.expected, ok)
+			}
+		})
+	}
+
+	for _, code := range []string{"", "12345", "1234567", "abcdef", "081805"} {
+		if _, ok := provider.Verify(rfc6238Secret, code, at); ok {
+			t.Errorf("Expected code %q to be rejected", code)
+		}
+	}
+	if _, ok := provider.Verify("not base32!", "081804", at); ok {
+		t.Error("Expected invalid secret to be rejected")
+	}
+}
+
+func TestTOTPProvider_GenerateSecret(t *testing.T) {
+	provider := NewTOTPProvider("Test")
+
+	secret, err := provider.GenerateSecret()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("Expected base32 secret, got %v", err)
+	}
+	if len(key) != totpSecretBytes {
+		t.Errorf("Expected %d byte secret, got %d", totpSecretBytes, len(key))
+	}
+
+	other, _ := provider.GenerateSecret()
+	if other == secret {
+		t.Error("Expected secrets to be random")
+	}
+}
+
+func TestTOTPProvider_ProvisioningURI(t *testing.T) {
+	provider := NewTOTPProvider("Go Chi API")
+
+	uri, err := url.Parse(provider.ProvisioningURI("JBSWY3DPEHPK3PXP", "user@example.com"))
+	if err != nil {
+		t.Fatalf("Failed to parse URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Errorf("Expected otpauth://totp URI, got %s", uri)
+	}
+	if uri.Path != "/Go Chi API:user@example.com" {
+		t.Errorf("Expected label with issuer and account, got %s", uri.Path)
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != "Go Chi API" {
+		t.Errorf("Expected secret and issuer parameters, got %s", uri.RawQuery)
+	}
+
+	png, err := provider.QRCode(uri.String())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(png), "\x89PNG") {
+		t.Error("Expected a PNG image")
+	}
+}
+
+func TestTOTPProvider_Interface(t *testing.T) {
+	var _ domain.TOTPProvider = NewTOTPProvider("Test")
+}
@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"hello-world/internal/domain"
+)
+
+// listUsers returns one page of users using keyset pagination. It is shared
+// by the SQL repositories; queries are written with ? placeholders and
+// rebound for the driver.
+func listUsers(ctx context.Context, db *sql.DB, driver string, query domain.UserListQuery) (*domain.UserPage, error) {
+	where, args := userListConditions(driver, query.Filter)
+
+	var total int
+	countSQL := `SELECT COUNT(*) FROM users` + whereClause(where)
+	if err := db.QueryRowContext(ctx, rebind(driver, countSQL), args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	column := "created_at"
+	if query.SortBy == domain.UserSortLastName {
+		column = "lastname"
+	}
+	direction, operator := "ASC", ">"
+	if query.Descending {
+		direction, operator = "DESC", "<"
+	}
+
+	if query.After != nil {
+		// Row values keep the comparison on (column, id) usable by the index
+		where = append(where, "("+column+", id) "+operator+" (?, ?)")
+		if query.SortBy == domain.UserSortLastName {
+			args = append(args, query.After.LastName, query.After.ID)
+		} else {
+			args = append(args, query.After.CreatedAt.UTC(), query.After.ID)
+		}
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	pageSQL := `
+		SELECT ` + userColumns + `
+		FROM users` + whereClause(where) + `
+		ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+		LIMIT ?
+	`
+	args = append(args, query.Limit+1)
+
+	rows, err := db.QueryContext(ctx, rebind(driver, pageSQL), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0, query.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.UserPage{Users: users, Total: total}
+	if len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+		page.NextCursor = domain.NewUserCursor(query, page.Users[query.Limit-1]).Encode()
+	}
+	return page, nil
+}
+
+// userListConditions translates a filter into WHERE conditions and their arguments
+func userListConditions(driver string, filter domain.UserListFilter) ([]string, []interface{}) {
+	var where []string
+	var args []interface{}
+
+	if filter.Deleted {
+		where = append(where, "deleted_at IS NOT NULL")
+	} else {
+		where = append(where, "deleted_at IS NULL")
+	}
+
+	if filter.EmailContains != "" {
+		// SQLite's LIKE is already case-insensitive for ASCII
+		operator := "LIKE"
+		if driver == DriverPostgres {
+			operator = "ILIKE"
+		}
+		where = append(where, `email `+operator+` ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.EmailContains)+"%")
+	}
+	if filter.CreatedFrom != nil {
+		where = append(where, "created_at >= ?")
+		args = append(args, filter.CreatedFrom.UTC())
+	}
+	if filter.CreatedTo != nil {
+		where = append(where, "created_at < ?")
+		args = append(args, filter.CreatedTo.UTC())
+	}
+
+	return where, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// escapeLike escapes the LIKE wildcards in s so it is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
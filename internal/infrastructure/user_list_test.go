@@ -0,0 +1,84 @@
+package infrastructure
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+func TestListUsers_UsesCreatedAtIndex(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	testCases := []struct {
+		name  string
+		where string
+		args  []interface{}
+	}{
+		{name: "First page"},
+		{name: "With cursor", where: " WHERE (created_at, id) > (?, ?)", args: []interface{}{time.Now().UTC(), 1}},
+	}
+
+	for _, tc := range testCases {
+		rows, err := db.Query(`EXPLAIN QUERY PLAN SELECT id FROM users`+tc.where+` ORDER BY created_at ASC, id ASC LIMIT 21`, tc.args...)
+		if err != nil {
+			t.Fatalf("Failed to explain query: %v", err)
+		}
+
+		var plan []string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatalf("Failed to read query plan: %v", err)
+			}
+			plan = append(plan, detail)
+		}
+		rows.Close()
+
+		joined := strings.Join(plan, "; ")
+		if !strings.Contains(joined, "idx_users_created_at") {
+			t.Errorf("%s: expected query plan to use idx_users_created_at, got %s", tc.name, joined)
+		}
+		if strings.Contains(joined, "TEMP B-TREE") {
+			t.Errorf("%s: expected no separate sort step, got %s", tc.name, joined)
+		}
+	}
+}
+
+func TestListUsers_InvalidCursorForOtherSort(t *testing.T) {
+	query := domain.UserListQuery{
+		SortBy: domain.UserSortLastName,
+		After:  &domain.UserCursor{SortBy: domain.UserSortCreatedAt, ID: 1},
+	}
+	if err := query.Validate(); err != domain.ErrInvalidCursor {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`a%b_c\d`); got != `a\%b\_c\\d` {
+		t.Errorf("Expected escaped wildcards, got %s", got)
+	}
+}
+
+func TestSQLiteUserRepository_List_LiteralWildcards(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteUserRepository(db)
+	ctx := context.Background()
+	repo.Create(ctx, newConformanceUser("percent%sign@example.com"))
+	repo.Create(ctx, newConformanceUser("plain@example.com"))
+
+	page, err := repo.List(ctx, domain.UserListQuery{SortBy: domain.UserSortCreatedAt, Limit: 10, Filter: domain.UserListFilter{EmailContains: "%"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if page.Total != 1 || len(page.Users) != 1 || page.Users[0].Email != "percent%sign@example.com" {
+		t.Errorf("Expected only the email containing %%, got %d users", page.Total)
+	}
+}
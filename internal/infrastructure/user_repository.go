@@ -3,11 +3,12 @@ package infrastructure
 import (
 	"context"
 	"database/sql"
-	"strings"
+	"errors"
+	"time"
 
 	"hello-world/internal/domain"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // SQLiteUserRepository implements domain.UserRepository using SQLite
@@ -20,20 +21,26 @@ func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
 	return &SQLiteUserRepository{db: db}
 }
 
-// Create inserts a new user into the database
+// Create inserts a new user into the database.
+// Timestamps are stored in UTC so that they sort correctly as text.
 func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+
 	query := `
-		INSERT INTO users (email, password, firstname, lastname, phone, birthday, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (email, email_normalized, password, firstname, lastname, phone, birthday, role, created_at, updated_at, email_verified_at, phone_verified_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(ctx, query,
-		user.Email, user.Password, user.FirstName, user.LastName,
-		user.Phone, user.Birthday, user.CreatedAt, user.UpdatedAt,
+		user.Email, domain.EmailKey(user.Email), user.Password, user.FirstName, user.LastName,
+		user.Phone, user.Birthday, user.Role, user.CreatedAt.UTC(), user.UpdatedAt.UTC(), nullableTime(user.EmailVerifiedAt),
+		nullableTime(user.PhoneVerifiedAt),
 	)
 
 	if err != nil {
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		if isSQLiteUniqueViolation(err) {
 			return domain.ErrUserAlreadyExists
 		}
 		return err
@@ -48,66 +55,35 @@ func (r *SQLiteUserRepository) Create(ctx context.Context, user *domain.User) er
 	return nil
 }
 
-// GetByEmail retrieves a user by email
+// GetByEmail retrieves a user by email, regardless of its case
 func (r *SQLiteUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	query := `
-		SELECT id, email, password, firstname, lastname, phone, birthday, created_at, updated_at 
-		FROM users WHERE email = ?
-	`
-
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.Phone, &user.Birthday, &user.CreatedAt, &user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, domain.ErrUserNotFound
-		}
-		return nil, err
-	}
-
-	return user, nil
+	return getUser(ctx, r.db, DriverSQLite, `email_normalized = ? AND deleted_at IS NULL`, domain.EmailKey(email))
 }
 
 // GetByID retrieves a user by ID
 func (r *SQLiteUserRepository) GetByID(ctx context.Context, id int) (*domain.User, error) {
-	query := `
-		SELECT id, email, password, firstname, lastname, phone, birthday, created_at, updated_at 
-		FROM users WHERE id = ?
-	`
-
-	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
-		&user.Phone, &user.Birthday, &user.CreatedAt, &user.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, domain.ErrUserNotFound
-		}
-		return nil, err
-	}
-
-	return user, nil
+	return getUser(ctx, r.db, DriverSQLite, `id = ? AND deleted_at IS NULL`, id)
 }
 
-// Update updates an existing user
+// Update updates an existing user, except for its password and token version
 func (r *SQLiteUserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users SET 
-			email = ?, password = ?, firstname = ?, lastname = ?, 
-			phone = ?, birthday = ?, updated_at = ? 
-		WHERE id = ?
+			email = ?, email_normalized = ?, firstname = ?, lastname = ?,
+			phone = ?, birthday = ?, role = ?, updated_at = ?, email_verified_at = ?,
+			pending_email = ?, phone_verified_at = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
-		user.Email, user.Password, user.FirstName, user.LastName,
-		user.Phone, user.Birthday, user.UpdatedAt, user.ID,
+		user.Email, domain.EmailKey(user.Email), user.FirstName, user.LastName,
+		user.Phone, user.Birthday, user.Role, user.UpdatedAt.UTC(), nullableTime(user.EmailVerifiedAt),
+		nullableString(user.PendingEmail), nullableTime(user.PhoneVerifiedAt), user.ID,
 	)
 
+	if err != nil && isSQLiteUniqueViolation(err) {
+		return domain.ErrUserAlreadyExists
+	}
 	return err
 }
 
@@ -118,13 +94,59 @@ func (r *SQLiteUserRepository) Delete(ctx context.Context, id int) error {
 	return err
 }
 
-// Exists checks if a user with the given email exists
+// Exists checks if a user with the given email exists, regardless of its case
 func (r *SQLiteUserRepository) Exists(ctx context.Context, email string) (bool, error) {
-	query := `SELECT COUNT(*) FROM users WHERE email = ?`
+	query := `SELECT COUNT(*) FROM users WHERE email_normalized = ? AND deleted_at IS NULL`
 	var count int
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, domain.EmailKey(email)).Scan(&count)
 	if err != nil {
 		return false, err
 	}
 	return count > 0, nil
 }
+
+// List returns one page of users matching the query
+func (r *SQLiteUserRepository) List(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	return listUsers(ctx, r.db, DriverSQLite, query)
+}
+
+// SoftDelete marks an active user as deleted
+func (r *SQLiteUserRepository) SoftDelete(ctx context.Context, id int, deletedAt time.Time) error {
+	return softDeleteUser(ctx, r.db, DriverSQLite, id, deletedAt)
+}
+
+// GetDeletedByID retrieves a soft-deleted user by ID
+func (r *SQLiteUserRepository) GetDeletedByID(ctx context.Context, id int) (*domain.User, error) {
+	return getUser(ctx, r.db, DriverSQLite, `id = ? AND deleted_at IS NOT NULL`, id)
+}
+
+// Restore clears the deletion mark of a soft-deleted user
+func (r *SQLiteUserRepository) Restore(ctx context.Context, id int) error {
+	return restoreUser(ctx, r.db, DriverSQLite, id)
+}
+
+// PurgeDeleted permanently removes users soft-deleted before the cut-off
+func (r *SQLiteUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return purgeDeletedUsers(ctx, r.db, DriverSQLite, deletedBefore)
+}
+
+// RecordVerificationSent notes that a verification email is sent, unless one was sent after notBefore
+func (r *SQLiteUserRepository) RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error) {
+	return recordVerificationSent(ctx, r.db, DriverSQLite, id, sentAt, notBefore)
+}
+
+// ReplacePasswordHash swaps the password hash, unless the password changed since oldHash was read
+func (r *SQLiteUserRepository) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	return replacePasswordHash(ctx, r.db, DriverSQLite, id, oldHash, newHash)
+}
+
+// UpdatePassword stores a new password hash and bumps the token version, returning the new one
+func (r *SQLiteUserRepository) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	return updatePassword(ctx, r.db, DriverSQLite, id, hash, updatedAt)
+}
+
+// isSQLiteUniqueViolation reports whether err is a SQLite unique constraint violation
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
@@ -0,0 +1,603 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// embeddedPostgresDSN is set by TestMain when the tests are built with the
+// embeddedpg tag and an embedded PostgreSQL server is running
+var embeddedPostgresDSN string
+
+// postgresTestDSN returns the DSN of the PostgreSQL server used by the
+// conformance tests, skipping the test when none is available
+func postgresTestDSN(t *testing.T) string {
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		return dsn
+	}
+	if embeddedPostgresDSN != "" {
+		return embeddedPostgresDSN
+	}
+	t.Skip("Set TEST_POSTGRES_DSN or build with -tags embeddedpg to run PostgreSQL tests")
+	return ""
+}
+
+func newConformanceUser(email string) *domain.User {
+	now := time.Now().UTC().Truncate(time.Second)
+	return &domain.User{
+		Email:     email,
+		Password:  "hashed_password",
+		FirstName: "John",
+		LastName:  "Doe",
+		Phone:     "+1234567890",
+		Birthday:  time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// runUserRepositoryConformance checks the behaviour every domain.UserRepository
+// implementation must share. newRepo must return a repository with no users.
+func runUserRepositoryConformance(t *testing.T, newRepo func(t *testing.T) domain.UserRepository) {
+	ctx := context.Background()
+
+	t.Run("Create assigns an ID", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("create@example.com")
+
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if user.ID == 0 {
+			t.Error("Expected user ID to be set after creation")
+		}
+	})
+
+	t.Run("Create rejects a duplicate email", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(ctx, newConformanceUser("dup@example.com")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		err := repo.Create(ctx, newConformanceUser("dup@example.com"))
+		if err != domain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("Update stores the email and phone verification times", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("verify@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		stored, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stored.IsEmailVerified() || stored.IsPhoneVerified() {
+			t.Fatal("Expected a new user to be unverified")
+		}
+
+		verifiedAt := time.Now().UTC().Truncate(time.Second)
+		stored.EmailVerifiedAt = &verifiedAt
+		stored.PhoneVerifiedAt = &verifiedAt
+		if err := repo.Update(ctx, stored); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		stored, err = repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stored.EmailVerifiedAt == nil || !stored.EmailVerifiedAt.Equal(verifiedAt) {
+			t.Errorf("Expected email verified at %v, got %v", verifiedAt, stored.EmailVerifiedAt)
+		}
+		if stored.PhoneVerifiedAt == nil || !stored.PhoneVerifiedAt.Equal(verifiedAt) {
+			t.Errorf("Expected phone verified at %v, got %v", verifiedAt, stored.PhoneVerifiedAt)
+		}
+	})
+
+	t.Run("RecordVerificationSent throttles", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("throttle@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		sentAt := time.Now().UTC().Truncate(time.Second)
+		recorded, err := repo.RecordVerificationSent(ctx, user.ID, sentAt, sentAt.Add(-time.Minute))
+		if err != nil || !recorded {
+			t.Fatalf("Expected the first email to be recorded, got %v, %v", recorded, err)
+		}
+		recorded, err = repo.RecordVerificationSent(ctx, user.ID, sentAt.Add(30*time.Second), sentAt.Add(-30*time.Second))
+		if err != nil || recorded {
+			t.Errorf("Expected a second email within the interval to be refused, got %v, %v", recorded, err)
+		}
+		recorded, err = repo.RecordVerificationSent(ctx, user.ID, sentAt.Add(time.Minute), sentAt)
+		if err != nil || !recorded {
+			t.Errorf("Expected an email after the interval to be recorded, got %v, %v", recorded, err)
+		}
+
+		recorded, err = repo.RecordVerificationSent(ctx, 999, sentAt, sentAt)
+		if err != nil || recorded {
+			t.Errorf("Expected nothing to be recorded for an unknown user, got %v, %v", recorded, err)
+		}
+	})
+
+	t.Run("ReplacePasswordHash only replaces the current hash", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("rehash@example.com")
+		user.FirstName = "Jane"
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		replaced, err := repo.ReplacePasswordHash(ctx, user.ID, "stale_hash", "new_hash")
+		if err != nil || replaced {
+			t.Errorf("Expected a stale hash not to be replaced, got %v, %v", replaced, err)
+		}
+		replaced, err = repo.ReplacePasswordHash(ctx, user.ID, "hashed_password", "new_hash")
+		if err != nil || !replaced {
+			t.Fatalf("Expected the hash to be replaced, got %v, %v", replaced, err)
+		}
+
+		stored, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stored.Password != "new_hash" || stored.FirstName != "Jane" {
+			t.Errorf("Expected only the hash to change, got %+v", stored)
+		}
+	})
+
+	t.Run("UpdatePassword bumps the token version; Update leaves both alone", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("version@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		stale := *user
+
+		version, err := repo.UpdatePassword(ctx, user.ID, "new_hash", user.UpdatedAt.Add(time.Minute))
+		if err != nil || version != 1 {
+			t.Fatalf("Expected token version 1, got %d, %v", version, err)
+		}
+
+		// Writing back a copy read before the change must not undo it
+		stale.FirstName = "Jane"
+		if err := repo.Update(ctx, &stale); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		stored, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if stored.Password != "new_hash" || stored.TokenVersion != 1 || stored.FirstName != "Jane" {
+			t.Errorf("Expected the new hash, token version 1 and the new name, got %+v", stored)
+		}
+
+		if _, err := repo.UpdatePassword(ctx, 999, "new_hash", time.Now()); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound, got %v", err)
+		}
+	})
+
+	t.Run("GetByEmail and GetByID return the stored user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("get@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		byEmail, err := repo.GetByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatalf("Unexpected error from GetByEmail: %v", err)
+		}
+		byID, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error from GetByID: %v", err)
+		}
+
+		for _, found := range []*domain.User{byEmail, byID} {
+			if found.ID != user.ID {
+				t.Errorf("Expected ID %d, got %d", user.ID, found.ID)
+			}
+			if found.Email != user.Email || found.Password != user.Password {
+				t.Errorf("Expected email %s and stored password, got %s", user.Email, found.Email)
+			}
+			if found.Role != domain.RoleUser {
+				t.Errorf("Expected default role %s, got %s", domain.RoleUser, found.Role)
+			}
+			if found.FirstName != user.FirstName || found.LastName != user.LastName || found.Phone != user.Phone {
+				t.Errorf("Expected profile fields to round-trip, got %+v", found)
+			}
+			if found.Birthday.Format("2006-01-02") != "1990-01-15" {
+				t.Errorf("Expected birthday 1990-01-15, got %s", found.Birthday.Format("2006-01-02"))
+			}
+			if !found.CreatedAt.Equal(user.CreatedAt) {
+				t.Errorf("Expected created_at %v, got %v", user.CreatedAt, found.CreatedAt)
+			}
+		}
+	})
+
+	t.Run("Missing users are not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.GetByEmail(ctx, "missing@example.com"); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound from GetByEmail, got %v", err)
+		}
+		if _, err := repo.GetByID(ctx, 999); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound from GetByID, got %v", err)
+		}
+	})
+
+	t.Run("Update persists changes", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("update@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		user.FirstName = "Jane"
+		user.Phone = "+0987654321"
+		user.Role = domain.RoleAdmin
+		user.PendingEmail = "pending@example.com"
+		user.UpdatedAt = user.UpdatedAt.Add(time.Minute)
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		found, err := repo.GetByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if found.FirstName != "Jane" || found.Phone != "+0987654321" || found.Role != domain.RoleAdmin {
+			t.Errorf("Expected updated profile, got %+v", found)
+		}
+		if found.PendingEmail != "pending@example.com" {
+			t.Errorf("Expected pending email pending@example.com, got %q", found.PendingEmail)
+		}
+
+		// Clearing the pending email stores NULL, read back as ""
+		found.PendingEmail = ""
+		if err := repo.Update(ctx, found); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cleared, _ := repo.GetByID(ctx, user.ID); cleared.PendingEmail != "" {
+			t.Errorf("Expected pending email to be cleared, got %q", cleared.PendingEmail)
+		}
+		if !found.UpdatedAt.Equal(user.UpdatedAt) {
+			t.Errorf("Expected updated_at %v, got %v", user.UpdatedAt, found.UpdatedAt)
+		}
+	})
+
+	t.Run("Update rejects a duplicate email", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(ctx, newConformanceUser("taken@example.com")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		user := newConformanceUser("other@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		user.Email = "taken@example.com"
+		if err := repo.Update(ctx, user); err != domain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists, got %v", err)
+		}
+	})
+
+	t.Run("Delete removes the user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("delete@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := repo.Delete(ctx, user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, user.ID); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound after delete, got %v", err)
+		}
+	})
+
+	t.Run("Exists reports stored emails", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Create(ctx, newConformanceUser("exists@example.com")); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		exists, err := repo.Exists(ctx, "exists@example.com")
+		if err != nil || !exists {
+			t.Errorf("Expected stored email to exist, got %v (err: %v)", exists, err)
+		}
+		exists, err = repo.Exists(ctx, "missing@example.com")
+		if err != nil || exists {
+			t.Errorf("Expected unknown email not to exist, got %v (err: %v)", exists, err)
+		}
+	})
+
+	t.Run("Emails differing only in case belong to one account", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("Bob@Example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		found, err := repo.GetByEmail(ctx, "bob@example.com")
+		if err != nil || found.ID != user.ID {
+			t.Fatalf("Expected the user to be found regardless of case, got %v (err: %v)", found, err)
+		}
+		if found.Email != "Bob@Example.com" {
+			t.Errorf("Expected the email to be returned as stored, got %q", found.Email)
+		}
+		if exists, err := repo.Exists(ctx, " BOB@EXAMPLE.COM "); err != nil || !exists {
+			t.Errorf("Expected the email to exist regardless of case, got %v (err: %v)", exists, err)
+		}
+
+		if err := repo.Create(ctx, newConformanceUser("bob@example.com")); err != domain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists on Create, got %v", err)
+		}
+		other := newConformanceUser("other@example.com")
+		if err := repo.Create(ctx, other); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		other.Email = "BOB@example.com"
+		if err := repo.Update(ctx, other); err != domain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists on Update, got %v", err)
+		}
+
+		// A user may change the case of their own address
+		user.Email = "bob@example.com"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Errorf("Expected no error changing the case of the own email, got %v", err)
+		}
+	})
+
+	t.Run("SoftDelete hides the user but keeps its email taken", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("soft@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		deletedAt := time.Now().UTC().Truncate(time.Second)
+		if err := repo.SoftDelete(ctx, user.ID, deletedAt); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := repo.SoftDelete(ctx, user.ID, deletedAt); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound when deleting twice, got %v", err)
+		}
+
+		if _, err := repo.GetByID(ctx, user.ID); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound from GetByID, got %v", err)
+		}
+		if _, err := repo.GetByEmail(ctx, user.Email); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound from GetByEmail, got %v", err)
+		}
+		if exists, err := repo.Exists(ctx, user.Email); err != nil || exists {
+			t.Errorf("Expected deleted user not to exist, got %v (err: %v)", exists, err)
+		}
+		if err := repo.Create(ctx, newConformanceUser(user.Email)); err != domain.ErrUserAlreadyExists {
+			t.Errorf("Expected ErrUserAlreadyExists while the email is reserved, got %v", err)
+		}
+
+		deleted, err := repo.GetDeletedByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error from GetDeletedByID: %v", err)
+		}
+		if deleted.DeletedAt == nil || !deleted.DeletedAt.Equal(deletedAt) {
+			t.Errorf("Expected deleted_at %v, got %v", deletedAt, deleted.DeletedAt)
+		}
+	})
+
+	t.Run("Restore brings a deleted user back", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newConformanceUser("restore@example.com")
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if err := repo.Restore(ctx, user.ID); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound when restoring an active user, got %v", err)
+		}
+		if err := repo.SoftDelete(ctx, user.ID, time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := repo.Restore(ctx, user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		found, err := repo.GetByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if found.DeletedAt != nil {
+			t.Errorf("Expected no deletion mark after restore, got %v", found.DeletedAt)
+		}
+		if _, err := repo.GetDeletedByID(ctx, user.ID); err != domain.ErrUserNotFound {
+			t.Errorf("Expected ErrUserNotFound from GetDeletedByID, got %v", err)
+		}
+	})
+
+	t.Run("PurgeDeleted removes users deleted before the cut-off", func(t *testing.T) {
+		repo := newRepo(t)
+		cutoff := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+		var ids []int
+		for i, deletedAt := range []*time.Time{timePtr(cutoff.Add(-time.Hour)), timePtr(cutoff.Add(time.Hour)), nil} {
+			user := newConformanceUser(fmt.Sprintf("purge%d@example.com", i))
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if deletedAt != nil {
+				if err := repo.SoftDelete(ctx, user.ID, *deletedAt); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			ids = append(ids, user.ID)
+		}
+
+		purged, err := repo.PurgeDeleted(ctx, cutoff)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if purged != 1 {
+			t.Errorf("Expected 1 purged user, got %d", purged)
+		}
+
+		if _, err := repo.GetDeletedByID(ctx, ids[0]); err != domain.ErrUserNotFound {
+			t.Errorf("Expected the expired user to be purged, got %v", err)
+		}
+		if _, err := repo.GetDeletedByID(ctx, ids[1]); err != nil {
+			t.Errorf("Expected the recently deleted user to be kept, got %v", err)
+		}
+		if _, err := repo.GetByID(ctx, ids[2]); err != nil {
+			t.Errorf("Expected the active user to be kept, got %v", err)
+		}
+		if err := repo.Create(ctx, newConformanceUser("purge0@example.com")); err != nil {
+			t.Errorf("Expected the purged email to be free again, got %v", err)
+		}
+	})
+
+	t.Run("List pages through users in order", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		lastNames := []string{"Evans", "Brown", "Davis", "Adams", "Clark"}
+		for i, lastName := range lastNames {
+			user := newConformanceUser(fmt.Sprintf("user%d@example.com", i))
+			user.LastName = lastName
+			user.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		}
+		deleted := newConformanceUser("deleted@example.com")
+		deleted.LastName = "Fisher"
+		deleted.CreatedAt = base.Add(-time.Hour)
+		if err := repo.Create(ctx, deleted); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := repo.SoftDelete(ctx, deleted.ID, time.Now()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		testCases := []struct {
+			name     string
+			query    domain.UserListQuery
+			expected []string
+		}{
+			{
+				name:     "created_at ascending",
+				query:    domain.UserListQuery{SortBy: domain.UserSortCreatedAt, Limit: 2},
+				expected: []string{"Evans", "Brown", "Davis", "Adams", "Clark"},
+			},
+			{
+				name:     "created_at descending",
+				query:    domain.UserListQuery{SortBy: domain.UserSortCreatedAt, Descending: true, Limit: 2},
+				expected: []string{"Clark", "Adams", "Davis", "Brown", "Evans"},
+			},
+			{
+				name:     "lastname ascending",
+				query:    domain.UserListQuery{SortBy: domain.UserSortLastName, Limit: 3},
+				expected: []string{"Adams", "Brown", "Clark", "Davis", "Evans"},
+			},
+			{
+				name: "created_at range",
+				query: domain.UserListQuery{SortBy: domain.UserSortCreatedAt, Limit: 10, Filter: domain.UserListFilter{
+					CreatedFrom: timePtr(base.Add(time.Hour)),
+					CreatedTo:   timePtr(base.Add(3 * time.Hour)),
+				}},
+				expected: []string{"Brown", "Davis"},
+			},
+			{
+				name:     "email substring",
+				query:    domain.UserListQuery{Limit: 10, Filter: domain.UserListFilter{EmailContains: "USER3@"}},
+				expected: []string{"Adams"},
+			},
+			{
+				name:     "deleted users only",
+				query:    domain.UserListQuery{Limit: 10, Filter: domain.UserListFilter{Deleted: true}},
+				expected: []string{"Fisher"},
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var got []string
+				query := tc.query
+				for pages := 0; ; pages++ {
+					if pages > len(lastNames) {
+						t.Fatal("Pagination did not terminate")
+					}
+					if err := query.Validate(); err != nil {
+						t.Fatalf("Invalid query: %v", err)
+					}
+					page, err := repo.List(ctx, query)
+					if err != nil {
+						t.Fatalf("Unexpected error: %v", err)
+					}
+					if page.Total != len(tc.expected) {
+						t.Errorf("Expected total %d, got %d", len(tc.expected), page.Total)
+					}
+					for _, user := range page.Users {
+						got = append(got, user.LastName)
+					}
+					if page.NextCursor == "" {
+						break
+					}
+					query.After, err = domain.DecodeUserCursor(page.NextCursor)
+					if err != nil {
+						t.Fatalf("Invalid next cursor: %v", err)
+					}
+				}
+
+				if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+					t.Errorf("Expected %v, got %v", tc.expected, got)
+				}
+			})
+		}
+	})
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestSQLiteUserRepository_Conformance(t *testing.T) {
+	runUserRepositoryConformance(t, func(t *testing.T) domain.UserRepository {
+		db := setupRefreshTokenTestDB(t)
+		t.Cleanup(func() { db.Close() })
+		return NewSQLiteUserRepository(db)
+	})
+}
+
+func TestPostgresUserRepository_Conformance(t *testing.T) {
+	dsn := postgresTestDSN(t)
+
+	runUserRepositoryConformance(t, func(t *testing.T) domain.UserRepository {
+		db, err := NewDatabase(DatabaseConfig{Driver: DriverPostgres, DSN: dsn})
+		if err != nil {
+			t.Fatalf("Failed to open PostgreSQL database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := db.Exec(`TRUNCATE users, refresh_tokens, revoked_tokens, user_token_revocations RESTART IDENTITY`); err != nil {
+			t.Fatalf("Failed to reset PostgreSQL database: %v", err)
+		}
+		return NewPostgresUserRepository(db)
+	})
+}
+
+func TestMemoryUserRepository_Conformance(t *testing.T) {
+	runUserRepositoryConformance(t, func(t *testing.T) domain.UserRepository {
+		return NewMemoryUserRepository()
+	})
+}
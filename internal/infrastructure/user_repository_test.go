@@ -22,13 +22,21 @@ func setupTestDB(t *testing.T) *sql.DB {
 	CREATE TABLE users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		email TEXT UNIQUE NOT NULL,
+		email_normalized TEXT UNIQUE,
 		password TEXT NOT NULL,
 		firstname TEXT NOT NULL,
 		lastname TEXT NOT NULL,
 		phone TEXT NOT NULL,
 		birthday DATETIME NOT NULL,
+		role TEXT NOT NULL DEFAULT 'user',
 		created_at DATETIME NOT NULL,
-		updated_at DATETIME NOT NULL
+		updated_at DATETIME NOT NULL,
+		deleted_at DATETIME,
+		email_verified_at DATETIME,
+		verification_sent_at DATETIME,
+		token_version INTEGER NOT NULL DEFAULT 0,
+		pending_email TEXT,
+		phone_verified_at DATETIME
 	)`
 
 	_, err = db.Exec(createTable)
@@ -348,3 +356,46 @@ func TestSQLiteUserRepository_Exists(t *testing.T) {
 		t.Error("Expected user to exist")
 	}
 }
+
+func TestSQLiteUserRepository_PurgeDeletedRemovesOwnedRows(t *testing.T) {
+	db := setupRefreshTokenTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteUserRepository(db)
+	tokenRepo := NewSQLiteRefreshTokenRepository(db)
+	ctx := context.Background()
+
+	user := newConformanceUser("purged@example.com")
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	token := newTestRefreshToken("purged-hash", "purged-family")
+	token.UserID = user.ID
+	if err := tokenRepo.Create(ctx, token); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+	mfaRepo := NewSQLMFARepository(db, DriverSQLite)
+	if err := mfaRepo.SaveEnrollment(ctx, &domain.MFAEnrollment{UserID: user.ID, Secret: "SECRET", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save MFA enrollment: %v", err)
+	}
+	if err := mfaRepo.ConfirmEnrollment(ctx, user.ID, time.Now(), 1, []string{"code-hash"}); err != nil {
+		t.Fatalf("Failed to confirm MFA enrollment: %v", err)
+	}
+
+	if err := repo.SoftDelete(ctx, user.ID, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if _, err := repo.PurgeDeleted(ctx, time.Now()); err != nil {
+		t.Fatalf("Failed to purge users: %v", err)
+	}
+
+	if _, err := tokenRepo.GetByHash(ctx, "purged-hash"); err != domain.ErrInvalidRefreshToken {
+		t.Errorf("Expected the purged user's refresh token to be removed, got %v", err)
+	}
+	if _, err := mfaRepo.GetEnrollment(ctx, user.ID); err != domain.ErrMFANotEnrolled {
+		t.Errorf("Expected the purged user's MFA enrollment to be removed, got %v", err)
+	}
+	if count, err := mfaRepo.CountRecoveryCodes(ctx, user.ID); err != nil || count != 0 {
+		t.Errorf("Expected the purged user's recovery codes to be removed, got %d (err: %v)", count, err)
+	}
+}
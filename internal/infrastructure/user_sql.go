@@ -0,0 +1,188 @@
+package infrastructure
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// userColumns lists the users columns in the order scanUser reads them
+const userColumns = `id, email, password, firstname, lastname, phone, birthday, role, created_at, updated_at, deleted_at, email_verified_at, token_version, pending_email, phone_verified_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser reads a row selected with userColumns
+func scanUser(row rowScanner) (*domain.User, error) {
+	user := &domain.User{}
+	var deletedAt, emailVerifiedAt, phoneVerifiedAt sql.NullTime
+	var pendingEmail sql.NullString
+	if err := row.Scan(
+		&user.ID, &user.Email, &user.Password, &user.FirstName, &user.LastName,
+		&user.Phone, &user.Birthday, &user.Role, &user.CreatedAt, &user.UpdatedAt, &deletedAt, &emailVerifiedAt, &user.TokenVersion, &pendingEmail,
+		&phoneVerifiedAt,
+	); err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if phoneVerifiedAt.Valid {
+		user.PhoneVerifiedAt = &phoneVerifiedAt.Time
+	}
+	user.PendingEmail = pendingEmail.String
+	return user, nil
+}
+
+// getUser returns the single user matching the condition, or ErrUserNotFound
+func getUser(ctx context.Context, db *sql.DB, driver, condition string, args ...interface{}) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + condition
+	user, err := scanUser(db.QueryRowContext(ctx, rebind(driver, query), args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// softDeleteUser marks an active user as deleted
+func softDeleteUser(ctx context.Context, db *sql.DB, driver string, id int, deletedAt time.Time) error {
+	query := `UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+	return execAffectingUser(ctx, db, rebind(driver, query), deletedAt.UTC(), id)
+}
+
+// restoreUser clears the deletion mark of a soft-deleted user
+func restoreUser(ctx context.Context, db *sql.DB, driver string, id int) error {
+	query := `UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	return execAffectingUser(ctx, db, rebind(driver, query), id)
+}
+
+// nullableTime converts an optional timestamp to a UTC value that can be
+// stored in a nullable column
+func nullableTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+// nullableString stores an empty string as NULL
+func nullableString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// recordVerificationSent stamps when a verification email was sent to an
+// active user, unless one was already sent after notBefore. The check and
+// the update are one statement, so concurrent requests cannot both pass.
+func recordVerificationSent(ctx context.Context, db *sql.DB, driver string, id int, sentAt, notBefore time.Time) (bool, error) {
+	query := `
+		UPDATE users SET verification_sent_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+			AND (verification_sent_at IS NULL OR verification_sent_at <= ?)
+	`
+	result, err := db.ExecContext(ctx, rebind(driver, query), sentAt.UTC(), id, notBefore.UTC())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// replacePasswordHash swaps the password hash of an active user, unless the
+// password changed since oldHash was read
+func replacePasswordHash(ctx context.Context, db *sql.DB, driver string, id int, oldHash, newHash string) (bool, error) {
+	query := `UPDATE users SET password = ? WHERE id = ? AND password = ? AND deleted_at IS NULL`
+	result, err := db.ExecContext(ctx, rebind(driver, query), newHash, id, oldHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// updatePassword stores the password hash of an active user and bumps its
+// token version in place, so concurrent bumps add up
+func updatePassword(ctx context.Context, db *sql.DB, driver string, id int, hash string, updatedAt time.Time) (int, error) {
+	query := `UPDATE users SET password = ?, updated_at = ?, token_version = token_version + 1
+		WHERE id = ? AND deleted_at IS NULL RETURNING token_version`
+	var version int
+	if err := db.QueryRowContext(ctx, rebind(driver, query), hash, updatedAt.UTC(), id).Scan(&version); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrUserNotFound
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// execAffectingUser runs a statement that must change exactly one user
+func execAffectingUser(ctx context.Context, db *sql.DB, query string, args ...interface{}) error {
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// userOwnedTables lists the tables whose rows reference users(id) ON DELETE CASCADE
+var userOwnedTables = []string{"refresh_tokens", "user_mfa", "mfa_recovery_codes", "password_reset_tokens", "phone_verification_codes"}
+
+// purgeDeletedUsers permanently removes users soft-deleted before the cut-off.
+// The rows they own are removed explicitly because SQLite does not enforce
+// ON DELETE CASCADE by default.
+func purgeDeletedUsers(ctx context.Context, db *sql.DB, driver string, deletedBefore time.Time) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	cutoff := deletedBefore.UTC()
+	for _, table := range userOwnedTables {
+		ownedSQL := `
+			DELETE FROM ` + table + ` WHERE user_id IN (
+				SELECT id FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?
+			)
+		`
+		if _, err := tx.ExecContext(ctx, rebind(driver, ownedSQL), cutoff); err != nil {
+			return 0, err
+		}
+	}
+
+	usersSQL := `DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+	result, err := tx.ExecContext(ctx, rebind(driver, usersSQL), cutoff)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(purged), nil
+}
@@ -0,0 +1,211 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+
+	"github.com/go-chi/chi"
+)
+
+// @Summary List Users
+// @Description List users page by page. Pass next_cursor from the previous page as cursor to get the next one.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor returned as next_cursor by the previous page"
+// @Param email query string false "Only users whose email contains this text (case-insensitive)"
+// @Param created_from query string false "Only users created at or after this date (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Only users created before this date (YYYY-MM-DD or RFC 3339)"
+// @Param sort query string false "created_at or lastname, prefixed with - for descending order" default(created_at)
+// @Param deleted query bool false "List soft-deleted users instead of active ones"
+// @Success 200 {object} dto.UserListResponse
+// @Failure 400 {object} dto.ValidationErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	query, fieldErrors := h.mapper.ParseListUsersQuery(r.URL.Query())
+	if len(fieldErrors) > 0 {
+		h.sendValidationErrorResponse(w, http.StatusBadRequest, fieldErrors)
+		return
+	}
+
+	page, err := h.userService.ListUsers(r.Context(), query)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INVALID_CURSOR", "INVALID_SORT":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
+	response := h.mapper.ToUserListResponse(page)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Set User Role
+// @Description Change a user's role. Tokens already issued keep the old role until they expire.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param role body dto.SetRoleRequest true "New role"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) SetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req dto.SetRoleRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	role, err := domain.ParseRole(req.Role)
+	if err != nil {
+		h.sendErrorResponseWithCode(w, http.StatusBadRequest, domain.ErrInvalidRole.Message, domain.ErrInvalidRole.Code)
+		return
+	}
+
+	user, err := h.userService.SetRole(r.Context(), userID, role)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "INVALID_ROLE":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to update user role")
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// @Summary Delete User
+// @Description Delete a user's account and end all of its sessions. The account can be restored until the grace period ends, after which it is purged.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id} [delete]
+func (h *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	h.deleteAccount(w, r, userID)
+}
+
+// @Summary Restore User
+// @Description Restore a deleted account during its grace period
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 410 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/restore [post]
+func (h *UserHandler) RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userService.RestoreAccount(r.Context(), userID)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "RESTORE_PERIOD_EXPIRED":
+				h.sendErrorResponseWithCode(w, http.StatusGone, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to restore user")
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// @Summary Unlock User
+// @Description Let a user whose account or second factor was locked after too many failed logins or wrong codes log in again right away
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.loginThrottle.Unlock(r.Context(), userID); err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "User unlocked", nil)
+}
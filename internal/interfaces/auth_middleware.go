@@ -10,13 +10,17 @@ import (
 
 // AuthMiddleware handles JWT authentication
 type AuthMiddleware struct {
-	authService domain.AuthService
+	authService     domain.AuthService
+	revocationStore domain.TokenRevocationStore
+	userService     domain.UserService
 }
 
 // NewAuthMiddleware creates a new AuthMiddleware
-func NewAuthMiddleware(authService domain.AuthService) *AuthMiddleware {
+func NewAuthMiddleware(authService domain.AuthService, revocationStore domain.TokenRevocationStore, userService domain.UserService) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		authService:     authService,
+		revocationStore: revocationStore,
+		userService:     userService,
 	}
 }
 
@@ -41,16 +45,92 @@ func (m *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
+		revoked, err := m.revocationStore.IsRevoked(r.Context(), claims)
+		if err != nil {
+			m.sendUnauthorizedResponse(w, "Invalid token")
+			return
+		}
+		if revoked {
+			m.sendUnauthorizedResponse(w, "Token has been revoked")
+			return
+		}
+
+		user, err := m.userService.GetUserByID(r.Context(), claims.UserID)
+		if err != nil {
+			m.sendUnauthorizedResponse(w, "Invalid token")
+			return
+		}
+		// The token version is bumped when the password changes
+		if claims.TokenVersion != user.TokenVersion {
+			m.sendUnauthorizedResponse(w, "Token has been revoked")
+			return
+		}
+
 		// Add user info to context
 		ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 		ctx = context.WithValue(ctx, "email", claims.Email)
+		ctx = context.WithValue(ctx, "token_claims", claims)
+		ctx = context.WithValue(ctx, "user", user)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireRole returns middleware that only lets through users who currently
+// have one of the given roles. The role is read from the stored user rather
+// than the token, so a demoted user loses access at once. It must run after
+// Middleware.
+func (m *AuthMiddleware) RequireRole(roles ...domain.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value("user").(*domain.User)
+			if !ok {
+				m.sendUnauthorizedResponse(w, "Authorization header required")
+				return
+			}
+
+			if !user.HasRole(roles...) {
+				m.sendForbiddenResponse(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerifiedEmail returns middleware that only lets through users who
+// have verified their email address. It must run after Middleware.
+func (m *AuthMiddleware) RequireVerifiedEmail() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value("user").(*domain.User)
+			if !ok {
+				m.sendUnauthorizedResponse(w, "Authorization header required")
+				return
+			}
+			if !user.IsEmailVerified() {
+				m.sendErrorResponse(w, http.StatusForbidden, domain.ErrEmailNotVerified)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (m *AuthMiddleware) sendUnauthorizedResponse(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
 	w.Write([]byte(`{"error":"` + message + `"}`))
 }
+
+func (m *AuthMiddleware) sendForbiddenResponse(w http.ResponseWriter) {
+	m.sendErrorResponse(w, http.StatusForbidden, domain.ErrForbidden)
+}
+
+func (m *AuthMiddleware) sendErrorResponse(w http.ResponseWriter, statusCode int, err domain.DomainError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(`{"error":"` + err.Message + `","code":"` + err.Code + `"}`))
+}
@@ -13,19 +13,36 @@ type MockAuthService struct {
 	ValidateTokenFunc func(token string) (*domain.TokenClaims, error)
 }
 
-func (m *MockAuthService) HashPassword(password string) (string, error) {
-	return "hashed_" + password, nil
+func (m *MockAuthService) PublicKeys() []domain.JSONWebKey {
+	return nil
 }
 
-func (m *MockAuthService) ComparePassword(hashedPassword, password string) error {
-	if hashedPassword == "hashed_"+password {
-		return nil
-	}
-	return domain.ErrInvalidCredentials
+func (m *MockAuthService) GenerateToken(userID int, email string, role domain.Role, tokenVersion int) (string, error) {
+	return "valid_token", nil
 }
 
-func (m *MockAuthService) GenerateToken(userID int, email string) (string, error) {
-	return "valid_token", nil
+func (m *MockAuthService) GenerateMFAChallenge(userID int) (string, error) {
+	return "valid_mfa_token", nil
+}
+
+func (m *MockAuthService) ValidateMFAChallenge(token string) (*domain.TokenClaims, error) {
+	return nil, domain.ErrInvalidMFAToken
+}
+
+func (m *MockAuthService) GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	return "valid_verification_token", nil
+}
+
+func (m *MockAuthService) ValidateEmailVerificationToken(token string) (int, string, error) {
+	return 0, "", domain.ErrInvalidVerificationToken
+}
+
+func (m *MockAuthService) GenerateEmailRevertToken(userID int, email string) (string, error) {
+	return "valid_revert_token", nil
+}
+
+func (m *MockAuthService) ValidateEmailRevertToken(token string) (int, string, error) {
+	return 0, "", domain.ErrInvalidEmailChangeToken
 }
 
 func (m *MockAuthService) ValidateToken(token string) (*domain.TokenClaims, error) {
@@ -49,7 +66,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 		},
 	}
 
-	middleware := NewAuthMiddleware(mockAuth)
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
 
 	// Create a test handler
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -83,7 +100,7 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 
 func TestAuthMiddleware_MissingToken(t *testing.T) {
 	mockAuth := &MockAuthService{}
-	middleware := NewAuthMiddleware(mockAuth)
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
 
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("Handler should not be called")
@@ -110,7 +127,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 		},
 	}
 
-	middleware := NewAuthMiddleware(mockAuth)
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
 
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("Handler should not be called")
@@ -133,7 +150,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 
 func TestAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 	mockAuth := &MockAuthService{}
-	middleware := NewAuthMiddleware(mockAuth)
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
 
 	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("Handler should not be called")
@@ -179,9 +196,143 @@ func TestAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	mockAuth := &MockAuthService{
+		ValidateTokenFunc: func(token string) (*domain.TokenClaims, error) {
+			return &domain.TokenClaims{UserID: 1, Email: "test@example.com", TokenID: token}, nil
+		},
+	}
+	revocationStore := &MockRevocationStore{Revoked: map[string]bool{"revoked_token": true}}
+	middleware := NewAuthMiddleware(mockAuth, revocationStore, &MockUserService{})
+
+	handler := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		token          string
+		expectedStatus int
+	}{
+		{token: "active_token", expectedStatus: http.StatusOK},
+		{token: "revoked_token", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.token, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_StaleTokenVersion(t *testing.T) {
+	// MockUserService returns users at token version 0
+	mockAuth := &MockAuthService{
+		ValidateTokenFunc: func(token string) (*domain.TokenClaims, error) {
+			version := 0
+			if token == "stale_token" {
+				version = 1
+			}
+			return &domain.TokenClaims{UserID: 1, Email: "test@example.com", TokenVersion: version}, nil
+		},
+	}
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
+
+	handler := middleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		token          string
+		expectedStatus int
+	}{
+		{token: "current_token", expectedStatus: http.StatusOK},
+		{token: "stale_token", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.token, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/protected", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_RequireRole(t *testing.T) {
+	// Every token claims the admin role; only the stored user decides
+	mockAuth := &MockAuthService{
+		ValidateTokenFunc: func(token string) (*domain.TokenClaims, error) {
+			userID := 1
+			if token == "admin" {
+				userID = adminUserID
+			}
+			return &domain.TokenClaims{UserID: userID, Email: "test@example.com", Role: domain.RoleAdmin}, nil
+		},
+	}
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
+
+	handler := middleware.Middleware(middleware.RequireRole(domain.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	testCases := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "Admin", token: "admin", expectedStatus: http.StatusOK},
+		{name: "Demoted admin", token: "demoted", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_RequireRole_WithoutClaims(t *testing.T) {
+	middleware := NewAuthMiddleware(&MockAuthService{}, &MockRevocationStore{}, &MockUserService{})
+
+	handler := middleware.RequireRole(domain.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Handler should not be called")
+	}))
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", rr.Code)
+	}
+}
+
 func TestNewAuthMiddleware(t *testing.T) {
 	mockAuth := &MockAuthService{}
-	middleware := NewAuthMiddleware(mockAuth)
+	middleware := NewAuthMiddleware(mockAuth, &MockRevocationStore{}, &MockUserService{})
 
 	if middleware == nil {
 		t.Error("Expected middleware to be created")
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// NullableString is a JSON string field that distinguishes between being
+// absent, being explicitly set to null and carrying a value
+type NullableString struct {
+	Set   bool
+	Null  bool
+	Value string
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called when the
+// field is present in the payload, which is how Set is detected.
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		n.Null = true
+		n.Value = ""
+		return nil
+	}
+	n.Null = false
+	return json.Unmarshal(data, &n.Value)
+}
@@ -4,24 +4,30 @@ import "time"
 
 // UserResponse represents the user data returned to the client
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"firstname"`
-	LastName  string    `json:"lastname"`
-	Phone     string    `json:"phone"`
-	Birthday  time.Time `json:"birthday"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              int        `json:"id"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"firstname"`
+	LastName        string     `json:"lastname"`
+	Phone           string     `json:"phone"`
+	Birthday        time.Time  `json:"birthday"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	PendingEmail    string     `json:"pending_email,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
 }
 
-// CreateUserRequest represents user creation request
+// CreateUserRequest represents user creation request. The password is
+// checked against the password policy instead of its tag.
 type CreateUserRequest struct {
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=6"`
-	FirstName string `json:"firstname" validate:"required"`
-	LastName  string `json:"lastname" validate:"required"`
-	Phone     string `json:"phone" validate:"required"`
-	Birthday  string `json:"birthday" validate:"required"`
+	Password  string `json:"password" validate:"required"`
+	FirstName string `json:"firstname" validate:"required,max=100" label:"First name"`
+	LastName  string `json:"lastname" validate:"required,max=100" label:"Last name"`
+	Phone     string `json:"phone" validate:"required,phone"`
+	Birthday  string `json:"birthday" validate:"required,birthday"`
 }
 
 // UpdateUserRequest represents user update request
@@ -32,6 +38,15 @@ type UpdateUserRequest struct {
 	Birthday  string `json:"birthday,omitempty"`
 }
 
+// PatchUserRequest represents a partial profile update.
+// Omitted fields are left unchanged; no field can be cleared.
+type PatchUserRequest struct {
+	FirstName NullableString `json:"firstname" swaggertype:"string" validate:"required,max=100" label:"First name"`
+	LastName  NullableString `json:"lastname" swaggertype:"string" validate:"required,max=100" label:"Last name"`
+	Phone     NullableString `json:"phone" swaggertype:"string" validate:"required,phone"`
+	Birthday  NullableString `json:"birthday" swaggertype:"string" validate:"required,birthday"`
+}
+
 // LoginRequest represents login request
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -40,8 +55,140 @@ type LoginRequest struct {
 
 // LoginResponse represents login response
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	User         UserResponse `json:"user"`
+}
+
+// RefreshTokenRequest represents a token refresh request
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest represents a logout request. The refresh token is optional;
+// when given, it is revoked together with the access token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// TokenResponse represents a rotated token pair
+type TokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SetRoleRequest represents an admin request to change a user's role
+type SetRoleRequest struct {
+	Role string `json:"role" validate:"required" enums:"user,admin"`
+}
+
+// UserListResponse represents one page of users
+type UserListResponse struct {
+	Users      []UserResponse `json:"users"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Total      int            `json:"total"`
+}
+
+// JSONWebKey represents a public token verification key (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty" example:"OKP"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg" example:"EdDSA"`
+	Use       string `json:"use" example:"sig"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty" example:"Ed25519"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSResponse represents a JSON Web Key Set
+type JWKSResponse struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// SigningKeyResponse describes a token signing key without its secret material
+type SigningKeyResponse struct {
+	ID        string     `json:"kid"`
+	Algorithm string     `json:"alg" example:"HS256"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SigningKeyListResponse lists the signing keys that still verify tokens
+type SigningKeyListResponse struct {
+	Keys []SigningKeyResponse `json:"keys"`
+}
+
+// MFAChallengeResponse tells a client to complete the login with a second factor
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+// MFALoginRequest completes a login with a code from the authenticator app or a recovery code
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required" label:"MFA token"`
+	Code     string `json:"code" validate:"required" example:"123456"`
+}
+
+// MFACodeRequest carries a code from the authenticator app or a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+// MFAStatusResponse describes the current user's two-factor authentication
+type MFAStatusResponse struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// MFAEnrollmentResponse is what an authenticator app needs for a pending enrollment
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri" example:"otpauth://totp/Go%20Chi%20API:user@example.com?secret=..."`
+	// QRCode is a base64 encoded PNG image of the provisioning URI
+	QRCode string `json:"qr_code"`
+}
+
+// RecoveryCodesResponse lists new recovery codes. They are shown only once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ForgotPasswordRequest asks for a password reset link
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangePasswordRequest replaces the current user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// ResendVerificationRequest asks for a new email verification link
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ChangeEmailRequest asks to change the current user's email address
+type ChangeEmailRequest struct {
+	Password string `json:"password" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+}
+
+// VerifyPhoneRequest carries the code texted to the current user's phone number
+type VerifyPhoneRequest struct {
+	Code string `json:"code" validate:"required" example:"123456"`
 }
 
 // APIResponse represents a generic API response
@@ -56,14 +203,18 @@ type ErrorResponse struct {
 	Code  string `json:"code,omitempty"`
 }
 
-// ValidationErrorResponse represents validation error response
+// ValidationErrorResponse lists every invalid field of a request
 type ValidationErrorResponse struct {
 	Error  string            `json:"error"`
+	Code   string            `json:"code,omitempty"`
 	Fields []ValidationError `json:"fields"`
 }
 
-// ValidationError represents a field validation error
+// ValidationError represents a field validation error. Rule names the
+// validation rule, or for password fields the password policy rule, that
+// was broken.
 type ValidationError struct {
 	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
 	Message string `json:"message"`
 }
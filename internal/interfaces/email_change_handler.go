@@ -0,0 +1,126 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// @Summary Change Email
+// @Description Ask to change the current user's email address. A confirmation link is sent to the new address and a notice with a revert link to the current one; the email only changes once the link is opened.
+// @Description A wrong password gets 400 INCORRECT_PASSWORD. Whether another account uses the new address is only checked on confirmation. At most one confirmation email is sent per verification resend interval.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body dto.ChangeEmailRequest true "Current password and new email address"
+// @Success 202 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /me/email [post]
+func (h *UserHandler) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	var req dto.ChangeEmailRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.emailChangeService.RequestEmailChange(r.Context(), userID, req.Password, req.Email); err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INCORRECT_PASSWORD", "EMAIL_UNCHANGED", "INVALID_EMAIL", "INVALID_EMAIL_ADDRESS":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			case "VERIFICATION_THROTTLED":
+				h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+			case "USER_NOT_FOUND":
+				h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusAccepted, "A confirmation link has been sent to the new email address", nil)
+}
+
+// @Summary Confirm Email Change
+// @Description Switch to the new email address with the token from the confirmation link. The link stops working once another address is requested or the change is reverted.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token from the confirmation link"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /email/confirm [get]
+func (h *UserHandler) ConfirmEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	user, err := h.emailChangeService.ConfirmEmailChange(r.Context(), token)
+	if err != nil {
+		h.sendEmailChangeError(w, err)
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// @Summary Revert Email Change
+// @Description Restore the email address the revert link was sent to and cancel any pending change. The user is logged out everywhere.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token from the revert link"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /email/revert [get]
+func (h *UserHandler) RevertEmailChangeHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	user, err := h.emailChangeService.RevertEmailChange(r.Context(), token)
+	if err != nil {
+		h.sendEmailChangeError(w, err)
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// sendEmailChangeError maps the errors of confirming or reverting an email change
+func (h *UserHandler) sendEmailChangeError(w http.ResponseWriter, err error) {
+	if domainErr, ok := err.(domain.DomainError); ok {
+		switch domainErr.Code {
+		case "INVALID_EMAIL_CHANGE_TOKEN":
+			h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+		case "USER_ALREADY_EXISTS":
+			h.sendErrorResponseWithCode(w, http.StatusConflict, domainErr.Message, domainErr.Code)
+		default:
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to change email")
+}
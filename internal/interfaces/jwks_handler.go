@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/mapper"
+)
+
+// JWKSHandler publishes the public keys access tokens can be verified with
+type JWKSHandler struct {
+	authService domain.AuthService
+	mapper      *mapper.UserMapper
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler(authService domain.AuthService) *JWKSHandler {
+	return &JWKSHandler{
+		authService: authService,
+		mapper:      mapper.NewUserMapper(),
+	}
+}
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens, selected by the kid in the token header. Empty when tokens are signed with a shared secret.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.JWKSResponse
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	response := h.mapper.ToJWKSResponse(h.authService.PublicKeys())
+	w.Header().Set("Content-Type", "application/json")
+	// Let verifiers cache the keys for a while instead of fetching them per token
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(response)
+}
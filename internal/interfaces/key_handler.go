@@ -0,0 +1,73 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+	"hello-world/internal/interfaces/mapper"
+)
+
+// KeyHandler lets administrators inspect and rotate the token signing keys
+type KeyHandler struct {
+	keyService domain.SigningKeyService
+	mapper     *mapper.UserMapper
+}
+
+// NewKeyHandler creates a new KeyHandler
+func NewKeyHandler(keyService domain.SigningKeyService) *KeyHandler {
+	return &KeyHandler{
+		keyService: keyService,
+		mapper:     mapper.NewUserMapper(),
+	}
+}
+
+// @Summary List Signing Keys
+// @Description List the active signing key and the retired keys that still verify tokens. Secret material is never returned.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.SigningKeyListResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/keys [get]
+func (h *KeyHandler) ListKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keyService.ListSigningKeys(r.Context())
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to list signing keys")
+		return
+	}
+
+	response := h.mapper.ToSigningKeyListResponse(keys)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Rotate Signing Key
+// @Description Generate a new signing key for new tokens. The previous key keeps verifying the tokens it signed until they have expired.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} dto.SigningKeyResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Router /admin/keys/rotate [post]
+func (h *KeyHandler) RotateKeyHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := h.keyService.RotateSigningKey(r.Context())
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to rotate signing key")
+		return
+	}
+
+	response := h.mapper.ToSigningKeyResponse(*key)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *KeyHandler) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(dto.ErrorResponse{Error: message})
+}
@@ -1,6 +1,10 @@
 package mapper
 
 import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"hello-world/internal/domain"
@@ -18,14 +22,19 @@ func NewUserMapper() *UserMapper {
 // ToUserResponse converts a domain User to a UserResponse DTO
 func (m *UserMapper) ToUserResponse(user *domain.User) dto.UserResponse {
 	return dto.UserResponse{
-		ID:        user.ID,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Phone:     user.Phone,
-		Birthday:  user.Birthday,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Phone:           user.Phone,
+		Birthday:        user.Birthday,
+		Role:            string(user.Role),
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		PendingEmail:    user.PendingEmail,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		DeletedAt:       user.DeletedAt,
 	}
 }
 
@@ -37,6 +46,109 @@ func (m *UserMapper) ToLoginResponse(token string, user *domain.User) dto.LoginR
 	}
 }
 
+// ToTokenResponse converts a domain TokenPair to a TokenResponse DTO
+func (m *UserMapper) ToTokenResponse(pair *domain.TokenPair) dto.TokenResponse {
+	return dto.TokenResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+	}
+}
+
+// ToJWKSResponse converts domain JSON Web Keys to a JWKSResponse DTO
+func (m *UserMapper) ToJWKSResponse(keys []domain.JSONWebKey) dto.JWKSResponse {
+	response := dto.JWKSResponse{Keys: make([]dto.JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, dto.JSONWebKey{
+			KeyType:   key.KeyType,
+			KeyID:     key.KeyID,
+			Algorithm: key.Algorithm,
+			Use:       key.Use,
+			N:         key.Modulus,
+			E:         key.Exponent,
+			Curve:     key.Curve,
+			X:         key.X,
+		})
+	}
+	return response
+}
+
+// ToSigningKeyResponse converts domain SigningKeyInfo to a SigningKeyResponse DTO
+func (m *UserMapper) ToSigningKeyResponse(key domain.SigningKeyInfo) dto.SigningKeyResponse {
+	return dto.SigningKeyResponse{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		Active:    key.Active,
+		CreatedAt: key.CreatedAt,
+		RetiredAt: key.RetiredAt,
+		ExpiresAt: key.ExpiresAt,
+	}
+}
+
+// ToSigningKeyListResponse converts domain SigningKeyInfos to a SigningKeyListResponse DTO
+func (m *UserMapper) ToSigningKeyListResponse(keys []domain.SigningKeyInfo) dto.SigningKeyListResponse {
+	response := dto.SigningKeyListResponse{Keys: make([]dto.SigningKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, m.ToSigningKeyResponse(key))
+	}
+	return response
+}
+
+// ToMFAChallengeResponse converts an MFA challenge to response DTO
+func (m *UserMapper) ToMFAChallengeResponse(challenge string) dto.MFAChallengeResponse {
+	return dto.MFAChallengeResponse{MFARequired: true, MFAToken: challenge}
+}
+
+// ToMFAStatusResponse converts domain MFA status to response DTO
+func (m *UserMapper) ToMFAStatusResponse(status *domain.MFAStatus) dto.MFAStatusResponse {
+	return dto.MFAStatusResponse{
+		Enabled:           status.Enabled,
+		EnabledAt:         status.EnabledAt,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	}
+}
+
+// ToMFAEnrollmentResponse converts a domain MFA setup to response DTO
+func (m *UserMapper) ToMFAEnrollmentResponse(setup *domain.MFASetup) dto.MFAEnrollmentResponse {
+	return dto.MFAEnrollmentResponse{
+		Secret:          setup.Secret,
+		ProvisioningURI: setup.ProvisioningURI,
+		QRCode:          base64.StdEncoding.EncodeToString(setup.QRCode),
+	}
+}
+
+// ToUserListResponse converts a domain UserPage to a UserListResponse DTO
+func (m *UserMapper) ToUserListResponse(page *domain.UserPage) dto.UserListResponse {
+	users := make([]dto.UserResponse, 0, len(page.Users))
+	for _, user := range page.Users {
+		users = append(users, m.ToUserResponse(user))
+	}
+	return dto.UserListResponse{
+		Users:      users,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+}
+
+// ToPasswordValidationErrors converts password policy violations to
+// validation errors on the given password field
+func (m *UserMapper) ToPasswordValidationErrors(field string, err *domain.PasswordPolicyError) []dto.ValidationError {
+	fieldErrors := make([]dto.ValidationError, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		fieldErrors = append(fieldErrors, dto.ValidationError{Field: field, Rule: violation.Rule, Message: violation.Message})
+	}
+	return fieldErrors
+}
+
+// ToValidationErrors converts the field violations of a domain validation
+// error to validation errors
+func (m *UserMapper) ToValidationErrors(err *domain.ValidationError) []dto.ValidationError {
+	fieldErrors := make([]dto.ValidationError, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		fieldErrors = append(fieldErrors, dto.ValidationError{Field: violation.Field, Rule: violation.Rule, Message: violation.Message})
+	}
+	return fieldErrors
+}
+
 // ParseCreateUserRequest converts a CreateUserRequest DTO to domain parameters
 func (m *UserMapper) ParseCreateUserRequest(req dto.CreateUserRequest) (email, password, firstName, lastName, phone string, birthday time.Time, err error) {
 	birthday, err = time.Parse("2006-01-02", req.Birthday)
@@ -63,3 +175,125 @@ func (m *UserMapper) ParseUpdateUserRequest(req dto.UpdateUserRequest) (firstNam
 
 	return firstName, lastName, phone, birthday, nil
 }
+
+// ParsePatchUserRequest converts a PatchUserRequest DTO to a domain update.
+// It reports every invalid field instead of stopping at the first one.
+func (m *UserMapper) ParsePatchUserRequest(req dto.PatchUserRequest) (domain.UserUpdate, []dto.ValidationError) {
+	var update domain.UserUpdate
+	var fieldErrors []dto.ValidationError
+
+	if req.FirstName.Set {
+		if req.FirstName.Null || req.FirstName.Value == "" {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "firstname", Message: "First name cannot be empty"})
+		} else {
+			firstName := req.FirstName.Value
+			update.FirstName = &firstName
+		}
+	}
+
+	if req.LastName.Set {
+		if req.LastName.Null || req.LastName.Value == "" {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "lastname", Message: "Last name cannot be empty"})
+		} else {
+			lastName := req.LastName.Value
+			update.LastName = &lastName
+		}
+	}
+
+	if req.Phone.Set {
+		if req.Phone.Null || req.Phone.Value == "" {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "phone", Message: "Phone number cannot be empty"})
+		} else {
+			phone := req.Phone.Value
+			update.Phone = &phone
+		}
+	}
+
+	if req.Birthday.Set {
+		if req.Birthday.Null || req.Birthday.Value == "" {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "birthday", Message: "Birthday cannot be empty"})
+		} else {
+			parsedBirthday, err := time.Parse("2006-01-02", req.Birthday.Value)
+			if err != nil {
+				fieldErrors = append(fieldErrors, dto.ValidationError{Field: "birthday", Message: domain.ErrInvalidBirthday.Message})
+			} else {
+				update.Birthday = &parsedBirthday
+			}
+		}
+	}
+
+	return update, fieldErrors
+}
+
+// ParseListUsersQuery converts the query string of a user listing to a domain query.
+// sort takes a field name, prefixed with "-" for descending order; dates are
+// either YYYY-MM-DD or RFC 3339 timestamps.
+func (m *UserMapper) ParseListUsersQuery(values url.Values) (domain.UserListQuery, []dto.ValidationError) {
+	var query domain.UserListQuery
+	var fieldErrors []dto.ValidationError
+
+	if limit := values.Get("limit"); limit != "" {
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil || parsedLimit < 1 {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "limit", Message: "Limit must be a positive integer"})
+		} else {
+			query.Limit = parsedLimit
+		}
+	}
+
+	if sort := values.Get("sort"); sort != "" {
+		query.Descending = strings.HasPrefix(sort, "-")
+		query.SortBy = domain.UserSortField(strings.TrimPrefix(sort, "-"))
+		if !query.SortBy.IsValid() {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "sort", Message: domain.ErrInvalidSortField.Message})
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := domain.DecodeUserCursor(cursor)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "cursor", Message: domain.ErrInvalidCursor.Message})
+		} else {
+			query.After = after
+		}
+	}
+
+	query.Filter.EmailContains = values.Get("email")
+
+	if deleted := values.Get("deleted"); deleted != "" {
+		parsedDeleted, err := strconv.ParseBool(deleted)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: "deleted", Message: "Deleted must be true or false"})
+		} else {
+			query.Filter.Deleted = parsedDeleted
+		}
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{name: "created_from", target: &query.Filter.CreatedFrom},
+		{name: "created_to", target: &query.Filter.CreatedTo},
+	} {
+		value := values.Get(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseDateOrTimestamp(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.ValidationError{Field: param.name, Message: "Date must be YYYY-MM-DD or an RFC 3339 timestamp"})
+			continue
+		}
+		*param.target = &parsed
+	}
+
+	return query, fieldErrors
+}
+
+func parseDateOrTimestamp(value string) (time.Time, error) {
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
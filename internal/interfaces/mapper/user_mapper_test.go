@@ -1,6 +1,8 @@
 package mapper
 
 import (
+	"encoding/json"
+	"net/url"
 	"testing"
 	"time"
 
@@ -262,6 +264,195 @@ func TestUserMapper_ParseUpdateUserRequest(t *testing.T) {
 	}
 }
 
+func TestUserMapper_ParsePatchUserRequest(t *testing.T) {
+	mapper := NewUserMapper()
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedFields []string
+		check          func(t *testing.T, update domain.UserUpdate)
+	}{
+		{
+			name: "Omitted fields are left untouched",
+			body: `{"firstname": "Jane"}`,
+			check: func(t *testing.T, update domain.UserUpdate) {
+				if update.FirstName == nil || *update.FirstName != "Jane" {
+					t.Errorf("Expected first name Jane, got %v", update.FirstName)
+				}
+				if update.LastName != nil || update.Phone != nil || update.Birthday != nil {
+					t.Error("Expected omitted fields to be nil")
+				}
+			},
+		},
+		{
+			name:           "Null phone and birthday are refused",
+			body:           `{"phone": null, "birthday": null}`,
+			expectedFields: []string{"phone", "birthday"},
+			check: func(t *testing.T, update domain.UserUpdate) {
+				if update.Phone != nil || update.Birthday != nil {
+					t.Error("Expected the phone and birthday to be left untouched")
+				}
+			},
+		},
+		{
+			name:           "Empty phone and birthday are refused",
+			body:           `{"phone": "", "birthday": ""}`,
+			expectedFields: []string{"phone", "birthday"},
+		},
+		{
+			name: "Valid birthday is parsed",
+			body: `{"birthday": "1992-05-15"}`,
+			check: func(t *testing.T, update domain.UserUpdate) {
+				expected := time.Date(1992, 5, 15, 0, 0, 0, 0, time.UTC)
+				if update.Birthday == nil || !update.Birthday.Equal(expected) {
+					t.Errorf("Expected birthday %v, got %v", expected, update.Birthday)
+				}
+			},
+		},
+		{
+			name:           "Every invalid field is reported",
+			body:           `{"firstname": null, "lastname": "", "birthday": "invalid-date"}`,
+			expectedFields: []string{"firstname", "lastname", "birthday"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req dto.PatchUserRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Failed to decode request: %v", err)
+			}
+
+			update, fieldErrors := mapper.ParsePatchUserRequest(req)
+
+			if len(fieldErrors) != len(tt.expectedFields) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.expectedFields), fieldErrors)
+			}
+			for i, field := range tt.expectedFields {
+				if fieldErrors[i].Field != field {
+					t.Errorf("Expected error for field %s, got %s", field, fieldErrors[i].Field)
+				}
+			}
+
+			if tt.check != nil {
+				tt.check(t, update)
+			}
+		})
+	}
+}
+
+func TestUserMapper_ParseListUsersQuery(t *testing.T) {
+	mapper := NewUserMapper()
+	cursor := domain.UserCursor{SortBy: domain.UserSortLastName, Descending: true, LastName: "Doe", ID: 3}.Encode()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedFields []string
+		check          func(t *testing.T, query domain.UserListQuery)
+	}{
+		{
+			name:  "Empty query uses defaults",
+			query: "",
+			check: func(t *testing.T, query domain.UserListQuery) {
+				if query.Limit != 0 || query.SortBy != "" || query.After != nil {
+					t.Errorf("Expected zero query, got %+v", query)
+				}
+			},
+		},
+		{
+			name:  "All parameters",
+			query: "limit=5&sort=-lastname&cursor=" + cursor + "&email=example&deleted=true&created_from=2025-01-01&created_to=2025-02-01T00:00:00Z",
+			check: func(t *testing.T, query domain.UserListQuery) {
+				if query.Limit != 5 {
+					t.Errorf("Expected limit 5, got %d", query.Limit)
+				}
+				if query.SortBy != domain.UserSortLastName || !query.Descending {
+					t.Errorf("Expected descending lastname sort, got %s (descending %v)", query.SortBy, query.Descending)
+				}
+				if query.After == nil || query.After.ID != 3 {
+					t.Errorf("Expected cursor at user 3, got %+v", query.After)
+				}
+				if query.Filter.EmailContains != "example" {
+					t.Errorf("Expected email filter example, got %s", query.Filter.EmailContains)
+				}
+				if !query.Filter.Deleted {
+					t.Error("Expected deleted users to be listed")
+				}
+				if query.Filter.CreatedFrom == nil || !query.Filter.CreatedFrom.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("Expected created_from 2025-01-01, got %v", query.Filter.CreatedFrom)
+				}
+				if query.Filter.CreatedTo == nil || !query.Filter.CreatedTo.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("Expected created_to 2025-02-01, got %v", query.Filter.CreatedTo)
+				}
+			},
+		},
+		{
+			name:           "Every invalid parameter is reported",
+			query:          "limit=0&sort=email&cursor=garbage&deleted=maybe&created_from=yesterday&created_to=01/02/2025",
+			expectedFields: []string{"limit", "sort", "cursor", "deleted", "created_from", "created_to"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("Failed to parse query: %v", err)
+			}
+
+			query, fieldErrors := mapper.ParseListUsersQuery(values)
+
+			if len(fieldErrors) != len(tt.expectedFields) {
+				t.Fatalf("Expected %d field errors, got %v", len(tt.expectedFields), fieldErrors)
+			}
+			for i, field := range tt.expectedFields {
+				if fieldErrors[i].Field != field {
+					t.Errorf("Expected error for field %s, got %s", field, fieldErrors[i].Field)
+				}
+			}
+
+			if tt.check != nil {
+				tt.check(t, query)
+			}
+		})
+	}
+}
+
+func TestUserMapper_ToUserListResponse(t *testing.T) {
+	mapper := NewUserMapper()
+
+	response := mapper.ToUserListResponse(&domain.UserPage{
+		Users:      []*domain.User{{ID: 1, Email: "a@example.com"}, {ID: 2, Email: "b@example.com"}},
+		NextCursor: "next",
+		Total:      10,
+	})
+
+	if len(response.Users) != 2 || response.Users[1].Email != "b@example.com" {
+		t.Errorf("Expected both users in order, got %+v", response.Users)
+	}
+	if response.NextCursor != "next" || response.Total != 10 {
+		t.Errorf("Expected cursor next and total 10, got %s and %d", response.NextCursor, response.Total)
+	}
+}
+
+func TestUserMapper_ToPasswordValidationErrors(t *testing.T) {
+	mapper := NewUserMapper()
+
+	fieldErrors := mapper.ToPasswordValidationErrors("password", &domain.PasswordPolicyError{Violations: []domain.PasswordViolation{
+		{Rule: domain.PasswordRuleMinLength, Message: "Too short"},
+		{Rule: domain.PasswordRuleBanned, Message: "Too common"},
+	}})
+
+	if len(fieldErrors) != 2 {
+		t.Fatalf("Expected 2 errors, got %+v", fieldErrors)
+	}
+	if fieldErrors[1].Field != "password" || fieldErrors[1].Rule != domain.PasswordRuleBanned || fieldErrors[1].Message != "Too common" {
+		t.Errorf("Expected the banned rule on the password field, got %+v", fieldErrors[1])
+	}
+}
+
 func TestNewUserMapper(t *testing.T) {
 	mapper := NewUserMapper()
 	if mapper == nil {
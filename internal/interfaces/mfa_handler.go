@@ -0,0 +1,225 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// @Summary Complete MFA Login
+// @Description Complete a login that answered 202 with a code from the authenticator app or an unused recovery code. The MFA token works once, expires after a few minutes and stops working after a few wrong codes.
+// @Description Too many wrong codes lock the user's second factor for a while (MFA_LOCKED).
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param login body dto.MFALoginRequest true "MFA token and code"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /login/mfa [post]
+func (h *UserHandler) MFALoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFALoginRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	token, user, err := h.mfaService.CompleteLogin(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INVALID_MFA_TOKEN", "INVALID_MFA_CODE":
+				h.sendErrorResponseWithCode(w, http.StatusUnauthorized, domainErr.Message, domainErr.Code)
+			case "MFA_LOCKED":
+				h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Login failed")
+		return
+	}
+	if err := h.loginThrottle.RecordSuccess(r.Context(), user.Email, h.clientIP(r)); err != nil {
+		log.Printf("Failed to reset failed logins: %v", err)
+	}
+
+	h.sendLoginResponse(w, r, token, user)
+}
+
+// @Summary Get MFA Status
+// @Description Show whether two-factor authentication is enabled for the current user
+// @Tags mfa
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MFAStatusResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /me/mfa [get]
+func (h *UserHandler) MFAStatusHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	status, err := h.mfaService.GetStatus(r.Context(), userID)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to get MFA status")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.mapper.ToMFAStatusResponse(status))
+}
+
+// @Summary Enroll in MFA
+// @Description Start setting up TOTP two-factor authentication. Add the secret to an authenticator app, by scanning the QR code or importing the provisioning URI, then confirm with POST /me/mfa/confirm. Starting over replaces an unconfirmed enrollment.
+// @Tags mfa
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MFAEnrollmentResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Router /me/mfa/enroll [post]
+func (h *UserHandler) MFAEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	setup, err := h.mfaService.BeginEnrollment(r.Context(), userID)
+	if err != nil {
+		h.sendMFAErrorResponse(w, err, "Failed to start MFA enrollment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.mapper.ToMFAEnrollmentResponse(setup))
+}
+
+// @Summary Confirm MFA Enrollment
+// @Description Enable two-factor authentication with a first code from the authenticator app. The response lists single-use recovery codes; they are shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body dto.MFACodeRequest true "Code from the authenticator app"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /me/mfa/confirm [post]
+func (h *UserHandler) MFAConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.parseMFACodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.ConfirmEnrollment(r.Context(), userID, code)
+	if err != nil {
+		h.sendMFAErrorResponse(w, err, "Failed to confirm MFA enrollment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+// @Summary Disable MFA
+// @Description Turn two-factor authentication off. Requires a code from the authenticator app or an unused recovery code.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body dto.MFACodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /me/mfa/disable [post]
+func (h *UserHandler) MFADisableHandler(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.parseMFACodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Disable(r.Context(), userID, code); err != nil {
+		h.sendMFAErrorResponse(w, err, "Failed to disable MFA")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// @Summary Regenerate Recovery Codes
+// @Description Replace all recovery codes with new ones. Requires a code from the authenticator app or an unused recovery code.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body dto.MFACodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /me/mfa/recovery-codes [post]
+func (h *UserHandler) MFARecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.parseMFACodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, code)
+	if err != nil {
+		h.sendMFAErrorResponse(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.RecoveryCodesResponse{RecoveryCodes: recoveryCodes})
+}
+
+// parseMFACodeRequest reads the current user and the code from the request,
+// sending an error response if either is missing
+func (h *UserHandler) parseMFACodeRequest(w http.ResponseWriter, r *http.Request) (int, string, bool) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return 0, "", false
+	}
+
+	var req dto.MFACodeRequest
+	if !h.decodeRequest(w, r, &req) {
+		return 0, "", false
+	}
+	return userID, req.Code, true
+}
+
+// sendMFAErrorResponse maps two-factor authentication errors of the /me/mfa endpoints
+func (h *UserHandler) sendMFAErrorResponse(w http.ResponseWriter, err error, fallback string) {
+	if domainErr, ok := err.(domain.DomainError); ok {
+		switch domainErr.Code {
+		case "INVALID_MFA_CODE", "MFA_NOT_ENROLLED":
+			h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+		case "MFA_ALREADY_ENABLED":
+			h.sendErrorResponseWithCode(w, http.StatusConflict, domainErr.Message, domainErr.Code)
+		case "USER_NOT_FOUND":
+			h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+		case "MFA_LOCKED":
+			h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+		default:
+			h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+		}
+		return
+	}
+	h.sendErrorResponse(w, http.StatusInternalServerError, fallback)
+}
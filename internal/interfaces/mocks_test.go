@@ -2,24 +2,61 @@ package interfaces
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"hello-world/internal/domain"
 )
 
+// unverifiedUserID is the user whose email MockUserService reports as not verified
+const unverifiedUserID = 2
+
+// adminUserID is the user MockUserService reports as an admin
+const adminUserID = 3
+
 // MockUserService for testing
 type MockUserService struct{}
 
 func (m *MockUserService) Register(ctx context.Context, email, password, firstName, lastName, phone string, birthday time.Time) (*domain.User, error) {
+	if password == weakPassword {
+		var invalid domain.ValidationError
+		invalid.AddPasswordPolicy("password", weakPasswordError)
+		return nil, &invalid
+	}
 	return &domain.User{ID: 1, Email: email}, nil
 }
 
-func (m *MockUserService) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
-	return "test_token", &domain.User{ID: 1, Email: email}, nil
+// weakPassword is refused by the mock services with weakPasswordError
+const weakPassword = "weak"
+
+var weakPasswordError = &domain.PasswordPolicyError{Violations: []domain.PasswordViolation{
+	{Rule: domain.PasswordRuleMinLength, Message: "Password must be at least 8 characters long"},
+	{Rule: domain.PasswordRuleDigit, Message: "Password must contain a digit"},
+}}
+
+func (m *MockUserService) Login(ctx context.Context, email, password string) (*domain.LoginResult, error) {
+	if password == "wrongpassword" {
+		return nil, domain.ErrInvalidCredentials
+	}
+	switch email {
+	case "mfa@example.com":
+		return &domain.LoginResult{MFAChallenge: "test_mfa_token"}, nil
+	case "unverified@example.com":
+		return nil, domain.ErrEmailNotVerified
+	}
+	return &domain.LoginResult{Token: "test_token", User: &domain.User{ID: 1, Email: email}}, nil
 }
 
 func (m *MockUserService) GetUserByID(ctx context.Context, userID int) (*domain.User, error) {
-	return &domain.User{ID: userID, Email: "test@example.com"}, nil
+	user := &domain.User{ID: userID, Email: "test@example.com", Role: domain.RoleUser}
+	if userID == adminUserID {
+		user.Role = domain.RoleAdmin
+	}
+	if userID != unverifiedUserID {
+		verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		user.EmailVerifiedAt = &verifiedAt
+	}
+	return user, nil
 }
 
 func (m *MockUserService) GetUserProfile(ctx context.Context, userID int) (*domain.User, error) {
@@ -30,21 +67,366 @@ func (m *MockUserService) UpdateUser(ctx context.Context, userID int, firstName,
 	return &domain.User{ID: userID, FirstName: firstName, LastName: lastName}, nil
 }
 
-// MockAuthServiceForRouter for testing router specifically (different from middleware mock)
-type MockAuthServiceForRouter struct{}
+// ChangePassword accepts "password123" as the current password
+func (m *MockUserService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (*domain.LoginResult, error) {
+	if currentPassword != "password123" {
+		return nil, domain.ErrIncorrectPassword
+	}
+	if newPassword == weakPassword {
+		return nil, weakPasswordError
+	}
+	return &domain.LoginResult{Token: "changed_password_token", User: &domain.User{ID: userID, Email: "test@example.com"}}, nil
+}
 
-func (m *MockAuthServiceForRouter) HashPassword(password string) (string, error) {
-	return "hashed_" + password, nil
+func (m *MockUserService) UpdateProfile(ctx context.Context, userID int, update domain.UserUpdate) (*domain.User, error) {
+	user := &domain.User{ID: userID, FirstName: "John", LastName: "Doe", Phone: "1234567890"}
+	update.Apply(user)
+	return user, nil
 }
 
-func (m *MockAuthServiceForRouter) ComparePassword(hashedPassword, password string) error {
+func (m *MockUserService) SetRole(ctx context.Context, userID int, role domain.Role) (*domain.User, error) {
+	if userID == 404 {
+		return nil, domain.ErrUserNotFound
+	}
+	return &domain.User{ID: userID, Email: "test@example.com", Role: role}, nil
+}
+
+func (m *MockUserService) ListUsers(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return &domain.UserPage{
+		Users:      []*domain.User{{ID: 1, Email: "test@example.com", Role: domain.RoleUser}},
+		NextCursor: "next",
+		Total:      2,
+	}, nil
+}
+
+func (m *MockUserService) DeleteAccount(ctx context.Context, userID int) error {
+	if userID == 404 {
+		return domain.ErrUserNotFound
+	}
 	return nil
 }
 
-func (m *MockAuthServiceForRouter) GenerateToken(userID int, email string) (string, error) {
+func (m *MockUserService) RestoreAccount(ctx context.Context, userID int) (*domain.User, error) {
+	switch userID {
+	case 404:
+		return nil, domain.ErrUserNotFound
+	case 410:
+		return nil, domain.ErrRestorePeriodExpired
+	}
+	return &domain.User{ID: userID, Email: "test@example.com", Role: domain.RoleUser}, nil
+}
+
+func (m *MockUserService) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// MockAuthServiceForRouter for testing router specifically (different from middleware mock)
+type MockAuthServiceForRouter struct{}
+
+func (m *MockAuthServiceForRouter) PublicKeys() []domain.JSONWebKey {
+	return []domain.JSONWebKey{{KeyType: "OKP", KeyID: "test-key", Algorithm: "EdDSA", Use: "sig", Curve: "Ed25519", X: "test"}}
+}
+
+func (m *MockAuthServiceForRouter) GenerateToken(userID int, email string, role domain.Role, tokenVersion int) (string, error) {
 	return "test_token", nil
 }
 
+func (m *MockAuthServiceForRouter) GenerateMFAChallenge(userID int) (string, error) {
+	return "test_mfa_token", nil
+}
+
+func (m *MockAuthServiceForRouter) ValidateMFAChallenge(token string) (*domain.TokenClaims, error) {
+	return &domain.TokenClaims{UserID: 1, TokenID: token}, nil
+}
+
+func (m *MockAuthServiceForRouter) GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	return "test_verification_token", nil
+}
+
+func (m *MockAuthServiceForRouter) ValidateEmailVerificationToken(token string) (int, string, error) {
+	return 1, "test@example.com", nil
+}
+
+func (m *MockAuthServiceForRouter) GenerateEmailRevertToken(userID int, email string) (string, error) {
+	return "test_revert_token", nil
+}
+
+func (m *MockAuthServiceForRouter) ValidateEmailRevertToken(token string) (int, string, error) {
+	return 1, "test@example.com", nil
+}
+
 func (m *MockAuthServiceForRouter) ValidateToken(token string) (*domain.TokenClaims, error) {
-	return &domain.TokenClaims{UserID: 1, Email: "test@example.com"}, nil
+	role := domain.RoleUser
+	if token == "admin_token" {
+		return &domain.TokenClaims{UserID: adminUserID, Email: "admin@example.com", Role: domain.RoleAdmin}, nil
+	}
+	if token == "demoted_token" {
+		// Issued while the user was an admin
+		role = domain.RoleAdmin
+	}
+	if token == "unverified_token" {
+		return &domain.TokenClaims{UserID: unverifiedUserID, Email: "test@example.com", Role: role}, nil
+	}
+	return &domain.TokenClaims{UserID: 1, Email: "test@example.com", Role: role}, nil
+}
+
+// MockTokenService for testing
+type MockTokenService struct{}
+
+func (m *MockTokenService) IssueRefreshToken(ctx context.Context, userID int) (string, error) {
+	return "test_refresh_token", nil
+}
+
+func (m *MockTokenService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	if refreshToken != "test_refresh_token" {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	return &domain.TokenPair{AccessToken: "new_test_token", RefreshToken: "new_test_refresh_token"}, nil
+}
+
+func (m *MockTokenService) Logout(ctx context.Context, claims *domain.TokenClaims, refreshToken string) error {
+	return nil
+}
+
+func (m *MockTokenService) LogoutAll(ctx context.Context, userID int) error {
+	return nil
+}
+
+// lockedMFACode makes MockMFAService answer as if too many wrong codes were entered
+const lockedMFACode = "999999"
+
+// MockMFAService for testing; "123456" is the only valid code
+type MockMFAService struct{}
+
+func (m *MockMFAService) GetStatus(ctx context.Context, userID int) (*domain.MFAStatus, error) {
+	return &domain.MFAStatus{}, nil
+}
+
+func (m *MockMFAService) BeginEnrollment(ctx context.Context, userID int) (*domain.MFASetup, error) {
+	return &domain.MFASetup{Secret: "SECRET", ProvisioningURI: "otpauth://totp/test?secret=SECRET", QRCode: []byte("png")}, nil
+}
+
+func (m *MockMFAService) ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error) {
+	if code != "123456" {
+		return nil, domain.ErrInvalidMFACode
+	}
+	return []string{"aaaaa-bbbbb"}, nil
+}
+
+func (m *MockMFAService) Disable(ctx context.Context, userID int, code string) error {
+	if code == lockedMFACode {
+		return domain.ErrMFALocked
+	}
+	if code != "123456" {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	if code != "123456" {
+		return nil, domain.ErrInvalidMFACode
+	}
+	return []string{"ccccc-ddddd"}, nil
+}
+
+func (m *MockMFAService) CompleteLogin(ctx context.Context, challenge, code string) (string, *domain.User, error) {
+	if challenge != "test_mfa_token" {
+		return "", nil, domain.ErrInvalidMFAToken
+	}
+	if code == lockedMFACode {
+		return "", nil, domain.ErrMFALocked
+	}
+	if code != "123456" {
+		return "", nil, domain.ErrInvalidMFACode
+	}
+	return "test_token", &domain.User{ID: 1, Email: "mfa@example.com"}, nil
+}
+
+// MockPasswordResetService for testing; "valid_reset_token" is the only valid token
+type MockPasswordResetService struct{}
+
+func (m *MockPasswordResetService) RequestReset(ctx context.Context, email string) error {
+	return nil
+}
+
+func (m *MockPasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if token != "valid_reset_token" {
+		return domain.ErrInvalidResetToken
+	}
+	if newPassword == weakPassword {
+		return weakPasswordError
+	}
+	return nil
+}
+
+// MockEmailVerificationService for testing; "valid_verification_token" is the
+// only valid token, and unverifiedUserID is throttled
+type MockEmailVerificationService struct{}
+
+func (m *MockEmailVerificationService) SendVerification(ctx context.Context, userID int) error {
+	if userID == unverifiedUserID {
+		return domain.ErrVerificationThrottled
+	}
+	return nil
+}
+
+func (m *MockEmailVerificationService) ResendVerification(ctx context.Context, email string) error {
+	return nil
+}
+
+func (m *MockEmailVerificationService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	if token != "valid_verification_token" {
+		return nil, domain.ErrInvalidVerificationToken
+	}
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &domain.User{ID: 1, Email: "test@example.com", EmailVerifiedAt: &verifiedAt}, nil
+}
+
+// MockEmailChangeService for testing; "taken@example.com" is used by another
+// account once the change is confirmed
+type MockEmailChangeService struct{}
+
+func (m *MockEmailChangeService) RequestEmailChange(ctx context.Context, userID int, password, newEmail string) error {
+	if password != "password123" {
+		return domain.ErrIncorrectPassword
+	}
+	if newEmail == "test@example.com" {
+		return domain.ErrEmailUnchanged
+	}
+	return nil
+}
+
+func (m *MockEmailChangeService) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	switch token {
+	case "valid_confirm_token":
+		return &domain.User{ID: 1, Email: "new@example.com"}, nil
+	case "taken_confirm_token":
+		return nil, domain.ErrUserAlreadyExists
+	}
+	return nil, domain.ErrInvalidEmailChangeToken
+}
+
+func (m *MockEmailChangeService) RevertEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	if token != "valid_revert_token" {
+		return nil, domain.ErrInvalidEmailChangeToken
+	}
+	return &domain.User{ID: 1, Email: "test@example.com"}, nil
+}
+
+// MockPhoneVerificationService for testing; "123456" is the only valid code,
+// and unverifiedUserID is throttled
+type MockPhoneVerificationService struct{}
+
+func (m *MockPhoneVerificationService) SendCode(ctx context.Context, userID int) error {
+	if userID == unverifiedUserID {
+		return domain.ErrPhoneCodeThrottled
+	}
+	return nil
+}
+
+func (m *MockPhoneVerificationService) VerifyPhone(ctx context.Context, userID int, code string) (*domain.User, error) {
+	if code != "123456" {
+		return nil, domain.ErrInvalidPhoneCode
+	}
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &domain.User{ID: userID, Email: "test@example.com", Phone: "+14155552671", PhoneVerifiedAt: &verifiedAt}, nil
+}
+
+// MockLoginThrottleService for testing; "locked@example.com" is locked for
+// 90 seconds, and only user 1 exists. CheckedIPs records the client address
+// of every login.
+type MockLoginThrottleService struct {
+	Failures   int
+	Successes  int
+	CheckedIPs []string
+}
+
+func (m *MockLoginThrottleService) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	m.CheckedIPs = append(m.CheckedIPs, ip)
+	if email == "locked@example.com" {
+		return 90 * time.Second, domain.ErrAccountLocked
+	}
+	return 0, nil
+}
+
+func (m *MockLoginThrottleService) RecordFailure(ctx context.Context, email, ip string) error {
+	m.Failures++
+	return nil
+}
+
+func (m *MockLoginThrottleService) RecordSuccess(ctx context.Context, email, ip string) error {
+	m.Successes++
+	return nil
+}
+
+func (m *MockLoginThrottleService) CheckMFA(ctx context.Context, userID int, challengeID string) (time.Duration, error) {
+	return 0, nil
+}
+
+func (m *MockLoginThrottleService) RecordMFAFailure(ctx context.Context, userID int, challengeID string) error {
+	return nil
+}
+
+func (m *MockLoginThrottleService) RecordMFASuccess(ctx context.Context, userID int) error {
+	return nil
+}
+
+func (m *MockLoginThrottleService) Unlock(ctx context.Context, userID int) error {
+	if userID != 1 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (m *MockLoginThrottleService) PruneStale(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// MockRevocationStore for testing; tokens listed in Revoked are rejected
+type MockRevocationStore struct {
+	Revoked map[string]bool
+}
+
+func (m *MockRevocationStore) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	if m.Revoked == nil {
+		m.Revoked = make(map[string]bool)
+	}
+	m.Revoked[tokenID] = true
+	return nil
+}
+
+func (m *MockRevocationStore) RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *MockRevocationStore) IsRevoked(ctx context.Context, claims *domain.TokenClaims) (bool, error) {
+	return m.Revoked[claims.TokenID], nil
+}
+
+// MockSigningKeyService for testing; every rotation adds a new active key
+type MockSigningKeyService struct {
+	Keys []domain.SigningKeyInfo
+}
+
+func (m *MockSigningKeyService) ListSigningKeys(ctx context.Context) ([]domain.SigningKeyInfo, error) {
+	return m.Keys, nil
+}
+
+func (m *MockSigningKeyService) RotateSigningKey(ctx context.Context) (*domain.SigningKeyInfo, error) {
+	now := time.Now()
+	for i := range m.Keys {
+		if m.Keys[i].Active {
+			expiresAt := now.Add(time.Hour)
+			m.Keys[i].Active = false
+			m.Keys[i].RetiredAt = &now
+			m.Keys[i].ExpiresAt = &expiresAt
+		}
+	}
+	key := domain.SigningKeyInfo{ID: fmt.Sprintf("key-%d", len(m.Keys)+1), Algorithm: "HS256", CreatedAt: now, Active: true}
+	m.Keys = append(m.Keys, key)
+	return &key, nil
 }
@@ -0,0 +1,120 @@
+package interfaces
+
+import (
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// @Summary Forgot Password
+// @Description Email a password reset link to the account using the email. The response is the same whether or not such an account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Email of the account"
+// @Success 202 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /password/forgot [post]
+func (h *UserHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.passwordService.RequestReset(r.Context(), req.Email); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusAccepted, "If an account uses this email, a password reset link has been sent to it", nil)
+}
+
+// @Summary Reset Password
+// @Description Set a new password with the token from a password reset link. The token works once, and the user is logged out everywhere.
+// @Description A password that breaks the password policy gets 422 VALIDATION_ERROR, listing each broken rule in fields, and the token can still be used.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /password/reset [post]
+func (h *UserHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		if policyErr, ok := err.(*domain.PasswordPolicyError); ok {
+			h.sendPasswordPolicyResponse(w, "password", policyErr)
+			return
+		}
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INVALID_RESET_TOKEN":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "Password has been reset", nil)
+}
+
+// @Summary Change Password
+// @Description Replace the current user's password. Every other session is logged out: older access tokens stop working and refresh tokens are revoked.
+// @Description The response holds a new access token and refresh token for the caller.
+// @Description A wrong current password gets 400 INCORRECT_PASSWORD; a new password that breaks the password policy gets 422 VALIDATION_ERROR, listing each broken rule in fields.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body dto.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /me/password [post]
+func (h *UserHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	result, err := h.userService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		if policyErr, ok := err.(*domain.PasswordPolicyError); ok {
+			h.sendPasswordPolicyResponse(w, "new_password", policyErr)
+			return
+		}
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INCORRECT_PASSWORD":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			case "USER_NOT_FOUND":
+				h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	h.sendLoginResponse(w, r, result.Token, result.User)
+}
@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// @Summary Send Phone Verification Code
+// @Description Text a 6-digit code to the current user's phone number, to be entered with POST /me/verify-phone. Codes sent before stop working. Only a few codes are sent to the same number per send window, whichever accounts ask for them.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /me/verify-phone/send [post]
+func (h *UserHandler) SendPhoneCodeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	if err := h.phoneService.SendCode(r.Context(), userID); err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "PHONE_NUMBER_MISSING", "INVALID_PHONE_NUMBER":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			case "PHONE_CODE_THROTTLED":
+				h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to send phone verification code")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusAccepted, "Unless your phone number is already verified, a code has been sent to it", nil)
+}
+
+// @Summary Verify Phone
+// @Description Verify the current user's phone number with the code last texted to it. The code expires, works once, and stops working after a few wrong attempts or if the phone number changes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body dto.VerifyPhoneRequest true "Code from the text message"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /me/verify-phone [post]
+func (h *UserHandler) VerifyPhoneHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	var req dto.VerifyPhoneRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	user, err := h.phoneService.VerifyPhone(r.Context(), userID, req.Code)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "INVALID_PHONE_CODE":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to verify phone number")
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
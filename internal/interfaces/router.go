@@ -1,27 +1,61 @@
 package interfaces
 
 import (
+	"net/netip"
+
 	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/validator"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// RouterConfig holds the settings that change which routes are available
+// and which requests are accepted
+type RouterConfig struct {
+	// RestrictUnverified limits users who have not verified their email
+	// address to viewing and deleting their account, resending the
+	// verification email, changing their email address and logging out
+	RestrictUnverified bool
+	// MinimumAge is how old users must be, by the birthday they register with
+	MinimumAge int
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are believed when telling which address a login
+	// comes from
+	TrustedProxies []netip.Prefix
+}
+
 // Router holds all the route handlers and dependencies
 type Router struct {
 	userHandler    *UserHandler
+	jwksHandler    *JWKSHandler
+	keyHandler     *KeyHandler
 	authMiddleware *AuthMiddleware
+	config         RouterConfig
 }
 
 // NewRouter creates a new router with all dependencies
 func NewRouter(
 	userService domain.UserService,
 	authService domain.AuthService,
+	tokenService domain.TokenService,
+	mfaService domain.MFAService,
+	passwordService domain.PasswordResetService,
+	verificationService domain.EmailVerificationService,
+	emailChangeService domain.EmailChangeService,
+	phoneService domain.PhoneVerificationService,
+	loginThrottle domain.LoginThrottleService,
+	revocationStore domain.TokenRevocationStore,
+	keyService domain.SigningKeyService,
+	config RouterConfig,
 ) *Router {
 	return &Router{
-		userHandler:    NewUserHandler(userService),
-		authMiddleware: NewAuthMiddleware(authService),
+		userHandler:    NewUserHandler(userService, tokenService, mfaService, passwordService, verificationService, emailChangeService, phoneService, loginThrottle, validator.New(config.MinimumAge), config.TrustedProxies),
+		jwksHandler:    NewJWKSHandler(authService),
+		keyHandler:     NewKeyHandler(keyService),
+		authMiddleware: NewAuthMiddleware(authService, revocationStore, userService),
+		config:         config,
 	}
 }
 
@@ -39,17 +73,68 @@ func (router *Router) SetupRoutes() *chi.Mux {
 	r.Get("/", router.userHandler.HelloHandler)
 	r.Post("/register", router.userHandler.RegisterHandler)
 	r.Post("/login", router.userHandler.LoginHandler)
+	r.Post("/login/mfa", router.userHandler.MFALoginHandler)
+	r.Post("/token/refresh", router.userHandler.RefreshTokenHandler)
+	r.Post("/password/forgot", router.userHandler.ForgotPasswordHandler)
+	r.Post("/password/reset", router.userHandler.ResetPasswordHandler)
+	r.Get("/verify-email", router.userHandler.VerifyEmailHandler)
+	r.Post("/verify-email/resend", router.userHandler.ResendVerificationHandler)
+	r.Get("/email/confirm", router.userHandler.ConfirmEmailChangeHandler)
+	r.Get("/email/revert", router.userHandler.RevertEmailChangeHandler)
+	r.Get("/.well-known/jwks.json", router.jwksHandler.JWKSHandler)
 
 	// Swagger documentation
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:3333/swagger/doc.json"),
 	))
 
-	// Protected routes
+	// Protected routes, open to users who have not verified their email
 	r.Group(func(r chi.Router) {
 		r.Use(router.authMiddleware.Middleware)
 		r.Get("/me", router.userHandler.MeHandler)
+		r.Delete("/me", router.userHandler.DeleteMeHandler)
+		r.Post("/me/verify-email/resend", router.userHandler.ResendMyVerificationHandler)
+		r.Post("/me/email", router.userHandler.ChangeEmailHandler)
+		r.Post("/logout", router.userHandler.LogoutHandler)
+		r.Post("/logout-all", router.userHandler.LogoutAllHandler)
+	})
+
+	// Protected routes
+	r.Group(func(r chi.Router) {
+		r.Use(router.authMiddleware.Middleware)
+		router.restrictUnverified(r)
+		r.Patch("/me", router.userHandler.UpdateMeHandler)
+		r.Post("/me/password", router.userHandler.ChangePasswordHandler)
+		r.Post("/me/verify-phone/send", router.userHandler.SendPhoneCodeHandler)
+		r.Post("/me/verify-phone", router.userHandler.VerifyPhoneHandler)
+		r.Get("/me/mfa", router.userHandler.MFAStatusHandler)
+		r.Post("/me/mfa/enroll", router.userHandler.MFAEnrollHandler)
+		r.Post("/me/mfa/confirm", router.userHandler.MFAConfirmHandler)
+		r.Post("/me/mfa/disable", router.userHandler.MFADisableHandler)
+		r.Post("/me/mfa/recovery-codes", router.userHandler.MFARecoveryCodesHandler)
+	})
+
+	// Admin routes
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(router.authMiddleware.Middleware)
+		router.restrictUnverified(r)
+		r.Use(router.authMiddleware.RequireRole(domain.RoleAdmin))
+		r.Get("/users", router.userHandler.ListUsersHandler)
+		r.Put("/users/{id}/role", router.userHandler.SetUserRoleHandler)
+		r.Delete("/users/{id}", router.userHandler.DeleteUserHandler)
+		r.Post("/users/{id}/restore", router.userHandler.RestoreUserHandler)
+		r.Post("/users/{id}/unlock", router.userHandler.UnlockUserHandler)
+		r.Get("/keys", router.keyHandler.ListKeysHandler)
+		r.Post("/keys/rotate", router.keyHandler.RotateKeyHandler)
 	})
 
 	return r
 }
+
+// restrictUnverified turns away users who have not verified their email
+// address from the routes of r, if so configured
+func (router *Router) restrictUnverified(r chi.Router) {
+	if router.config.RestrictUnverified {
+		r.Use(router.authMiddleware.RequireVerifiedEmail())
+	}
+}
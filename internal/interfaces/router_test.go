@@ -1,16 +1,22 @@
 package interfaces
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
 )
 
 func TestNewRouter(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
 
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 
 	if router == nil {
 		t.Error("Expected router to be created")
@@ -28,7 +34,7 @@ func TestNewRouter(t *testing.T) {
 func TestRouter_SetupRoutes(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 
 	chiRouter := router.SetupRoutes()
 
@@ -40,7 +46,7 @@ func TestRouter_SetupRoutes(t *testing.T) {
 func TestRouter_PublicRoutes(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	// Test cases for public routes
@@ -95,7 +101,7 @@ func TestRouter_PublicRoutes(t *testing.T) {
 func TestRouter_ProtectedRoutes(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	// Test protected route with valid token
@@ -111,10 +117,888 @@ func TestRouter_ProtectedRoutes(t *testing.T) {
 	}
 }
 
+func TestRouter_PatchMe(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("PATCH", "/me", strings.NewReader(`{"firstname": "Jane", "phone": "+14155550123"}`))
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var response dto.UserResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.FirstName != "Jane" {
+		t.Errorf("Expected first name Jane, got %s", response.FirstName)
+	}
+	if response.LastName != "Doe" {
+		t.Errorf("Expected last name to remain Doe, got %s", response.LastName)
+	}
+	if response.Phone != "+14155550123" {
+		t.Errorf("Expected phone +14155550123, got %s", response.Phone)
+	}
+}
+
+func TestRouter_PatchMe_ValidationErrors(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("PATCH", "/me", strings.NewReader(`{"firstname": "", "phone": null, "birthday": "15/05/1992"}`))
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d", rr.Code)
+	}
+
+	var response dto.ValidationErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Fields) != 3 {
+		t.Errorf("Expected 3 field errors, got %v", response.Fields)
+	}
+}
+
+func TestUserHandler_ClientIP(t *testing.T) {
+	handler := &UserHandler{trustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.1/32"),
+	}}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{name: "Direct client", remoteAddr: "203.0.113.7:4242", expected: "203.0.113.7"},
+		{name: "Headers from an untrusted client are ignored", remoteAddr: "203.0.113.7:4242", headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.2"}, expected: "203.0.113.7"},
+		{name: "Forwarded by a trusted proxy", remoteAddr: "10.1.2.3:4242", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "Spoofed hops left of the client are ignored", remoteAddr: "10.1.2.3:4242", headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 192.0.2.1"}, expected: "198.51.100.1"},
+		{name: "Only trusted proxies forwarded", remoteAddr: "10.1.2.3:4242", headers: map[string]string{"X-Forwarded-For": "10.9.9.9, 192.0.2.1"}, expected: "10.9.9.9"},
+		{name: "Malformed hop stops the walk", remoteAddr: "10.1.2.3:4242", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, garbage"}, expected: "10.1.2.3"},
+		{name: "X-Real-IP from a trusted proxy", remoteAddr: "192.0.2.1:4242", headers: map[string]string{"X-Real-IP": "198.51.100.2"}, expected: "198.51.100.2"},
+		{name: "Malformed X-Real-IP", remoteAddr: "192.0.2.1:4242", headers: map[string]string{"X-Real-IP": "unknown"}, expected: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if ip := handler.clientIP(req); ip != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestRouter_Login_ThrottlesForwardedClient(t *testing.T) {
+	throttle := &MockLoginThrottleService{}
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, throttle, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	})
+	chiRouter := router.SetupRoutes()
+
+	for _, remoteAddr := range []string{"10.0.0.2:4242", "203.0.113.7:4242"} {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email": "test@example.com", "password": "password123"}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		chiRouter.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := []string{"198.51.100.1", "203.0.113.7"}
+	if len(throttle.CheckedIPs) != 2 || throttle.CheckedIPs[0] != expected[0] || throttle.CheckedIPs[1] != expected[1] {
+		t.Errorf("Expected logins to be throttled by %v, got %v", expected, throttle.CheckedIPs)
+	}
+}
+
+func TestRouter_Login_ReturnsRefreshToken(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email": "test@example.com", "password": "password123"}`))
+	rr := httptest.NewRecorder()
+
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var response dto.LoginResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Token != "test_token" {
+		t.Errorf("Expected token test_token, got %s", response.Token)
+	}
+	if response.RefreshToken != "test_refresh_token" {
+		t.Errorf("Expected refresh token test_refresh_token, got %s", response.RefreshToken)
+	}
+}
+
+func TestRouter_Login_MFA(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	loginThrottle := &MockLoginThrottleService{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, loginThrottle, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"email": "mfa@example.com", "password": "password123"}`))
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", rr.Code)
+	}
+	var challenge dto.MFAChallengeResponse
+	if err := json.NewDecoder(rr.Body).Decode(&challenge); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !challenge.MFARequired || challenge.MFAToken != "test_mfa_token" {
+		t.Fatalf("Expected MFA challenge test_mfa_token, got %+v", challenge)
+	}
+	if loginThrottle.Successes != 0 {
+		t.Errorf("Expected the login not to count as successful before the second factor, got %d successes", loginThrottle.Successes)
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Valid code", body: `{"mfa_token": "test_mfa_token", "code": "123456"}`, expectedStatus: http.StatusOK},
+		{name: "Invalid code", body: `{"mfa_token": "test_mfa_token", "code": "000000"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "Invalid MFA token", body: `{"mfa_token": "bogus", "code": "123456"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "Missing code", body: `{"mfa_token": "test_mfa_token"}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Locked second factor", body: `{"mfa_token": "test_mfa_token", "code": "` + lockedMFACode + `"}`, expectedStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/login/mfa", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			var response dto.LoginResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Token != "test_token" || response.RefreshToken != "test_refresh_token" {
+				t.Errorf("Expected token pair, got %+v", response)
+			}
+		})
+	}
+
+	if loginThrottle.Successes != 1 {
+		t.Errorf("Expected only the completed login to be recorded, got %d successes", loginThrottle.Successes)
+	}
+}
+
+func TestRouter_MFAEndpoints(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Status", method: "GET", path: "/me/mfa", expectedStatus: http.StatusOK},
+		{name: "Enroll", method: "POST", path: "/me/mfa/enroll", expectedStatus: http.StatusOK},
+		{name: "Confirm", method: "POST", path: "/me/mfa/confirm", body: `{"code": "123456"}`, expectedStatus: http.StatusOK},
+		{name: "Confirm with invalid code", method: "POST", path: "/me/mfa/confirm", body: `{"code": "000000"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Confirm without code", method: "POST", path: "/me/mfa/confirm", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Regenerate recovery codes", method: "POST", path: "/me/mfa/recovery-codes", body: `{"code": "123456"}`, expectedStatus: http.StatusOK},
+		{name: "Disable", method: "POST", path: "/me/mfa/disable", body: `{"code": "123456"}`, expectedStatus: http.StatusOK},
+		{name: "Disable when locked", method: "POST", path: "/me/mfa/disable", body: `{"code": "` + lockedMFACode + `"}`, expectedStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer valid_token")
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+
+	req := httptest.NewRequest("POST", "/me/mfa/enroll", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+
+	var enrollment dto.MFAEnrollmentResponse
+	if err := json.NewDecoder(rr.Body).Decode(&enrollment); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if enrollment.QRCode != "cG5n" {
+		t.Errorf("Expected base64 encoded QR code cG5n, got %s", enrollment.QRCode)
+	}
+
+	req = httptest.NewRequest("POST", "/me/mfa/enroll", nil)
+	rr = httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a token, got %d", rr.Code)
+	}
+}
+
+func TestRouter_RefreshToken(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Valid refresh token", body: `{"refresh_token": "test_refresh_token"}`, expectedStatus: http.StatusOK},
+		{name: "Invalid refresh token", body: `{"refresh_token": "bogus"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "Missing refresh token", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/token/refresh", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_RegisterWeakPassword(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	body := `{"email": "test@example.com", "password": "weak", "firstname": "John", "lastname": "Doe", "phone": "1234567890", "birthday": "1990-01-01"}`
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	var response dto.ValidationErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != "VALIDATION_ERROR" {
+		t.Errorf("Expected code VALIDATION_ERROR, got %q", response.Code)
+	}
+	if len(response.Fields) != 2 || response.Fields[0].Field != "password" || response.Fields[0].Rule != domain.PasswordRuleMinLength || response.Fields[1].Rule != domain.PasswordRuleDigit {
+		t.Errorf("Expected one password error per broken rule, got %+v", response.Fields)
+	}
+}
+
+func TestRouter_RegisterValidationErrors(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{MinimumAge: 13})
+	chiRouter := router.SetupRoutes()
+
+	body := `{"email": "test.example.com", "firstname": "John", "lastname": "", "phone": "12-34", "birthday": "2999-01-01"}`
+	req := httptest.NewRequest("POST", "/register", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+	}
+	var response dto.ValidationErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != "VALIDATION_ERROR" {
+		t.Errorf("Expected code VALIDATION_ERROR, got %q", response.Code)
+	}
+
+	// Every invalid field is reported at once, in the order of the request
+	expected := []dto.ValidationError{
+		{Field: "email", Rule: "email"},
+		{Field: "password", Rule: "required"},
+		{Field: "lastname", Rule: "required"},
+		{Field: "phone", Rule: "phone"},
+		{Field: "birthday", Rule: "future"},
+	}
+	if len(response.Fields) != len(expected) {
+		t.Fatalf("Expected %d field errors, got %+v", len(expected), response.Fields)
+	}
+	for i, want := range expected {
+		if got := response.Fields[i]; got.Field != want.Field || got.Rule != want.Rule || got.Message == "" {
+			t.Errorf("Expected a %s error on %s, got %+v", want.Rule, want.Field, got)
+		}
+	}
+}
+
+func TestRouter_PasswordReset(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Forgot password", path: "/password/forgot", body: `{"email": "unknown@example.com"}`, expectedStatus: http.StatusAccepted},
+		{name: "Forgot password without email", path: "/password/forgot", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Reset password", path: "/password/reset", body: `{"token": "valid_reset_token", "password": "newpassword"}`, expectedStatus: http.StatusOK},
+		{name: "Reset password with invalid token", path: "/password/reset", body: `{"token": "bogus", "password": "newpassword"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Reset password without password", path: "/password/reset", body: `{"token": "valid_reset_token"}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Reset password with weak password", path: "/password/reset", body: `{"token": "valid_reset_token", "password": "weak"}`, expectedStatus: http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_ChangePassword(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "Change password", body: `{"current_password": "password123", "new_password": "newpassword456"}`, expectedStatus: http.StatusOK},
+		{name: "Wrong current password", body: `{"current_password": "wrong", "new_password": "newpassword456"}`, expectedStatus: http.StatusBadRequest, expectedCode: "INCORRECT_PASSWORD"},
+		{name: "Weak new password", body: `{"current_password": "password123", "new_password": "weak"}`, expectedStatus: http.StatusUnprocessableEntity, expectedCode: "VALIDATION_ERROR"},
+		{name: "Missing current password", body: `{"new_password": "newpassword456"}`, expectedStatus: http.StatusUnprocessableEntity, expectedCode: "VALIDATION_ERROR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/me/password", strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer test_token")
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var response struct {
+				Code         string `json:"code"`
+				Token        string `json:"token"`
+				RefreshToken string `json:"refresh_token"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Code != tc.expectedCode {
+				t.Errorf("Expected code %q, got %q", tc.expectedCode, response.Code)
+			}
+			if tc.expectedStatus == http.StatusOK && (response.Token != "changed_password_token" || response.RefreshToken == "") {
+				t.Errorf("Expected new tokens, got %+v", response)
+			}
+		})
+	}
+}
+
+func TestRouter_EmailChange(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{RestrictUnverified: true})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Request change", method: "POST", path: "/me/email", token: "test_token", body: `{"password": "password123", "email": "new@example.com"}`, expectedStatus: http.StatusAccepted},
+		{name: "Request change while unverified", method: "POST", path: "/me/email", token: "unverified_token", body: `{"password": "password123", "email": "new@example.com"}`, expectedStatus: http.StatusAccepted},
+		{name: "Request change with wrong password", method: "POST", path: "/me/email", token: "test_token", body: `{"password": "wrong", "email": "new@example.com"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Request change to the current address", method: "POST", path: "/me/email", token: "test_token", body: `{"password": "password123", "email": "test@example.com"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Request change without email", method: "POST", path: "/me/email", token: "test_token", body: `{"password": "password123"}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Request change without token", method: "POST", path: "/me/email", body: `{"password": "password123", "email": "new@example.com"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "Confirm", method: "GET", path: "/email/confirm?token=valid_confirm_token", expectedStatus: http.StatusOK},
+		{name: "Confirm a taken address", method: "GET", path: "/email/confirm?token=taken_confirm_token", expectedStatus: http.StatusConflict},
+		{name: "Confirm with invalid token", method: "GET", path: "/email/confirm?token=bogus", expectedStatus: http.StatusBadRequest},
+		{name: "Revert", method: "GET", path: "/email/revert?token=valid_revert_token", expectedStatus: http.StatusOK},
+		{name: "Revert without token", method: "GET", path: "/email/revert", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_EmailVerification(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Verify email", method: "GET", path: "/verify-email?token=valid_verification_token", expectedStatus: http.StatusOK},
+		{name: "Verify email with invalid token", method: "GET", path: "/verify-email?token=bogus", expectedStatus: http.StatusBadRequest},
+		{name: "Verify email without token", method: "GET", path: "/verify-email", expectedStatus: http.StatusBadRequest},
+		{name: "Resend verification", method: "POST", path: "/verify-email/resend", body: `{"email": "unknown@example.com"}`, expectedStatus: http.StatusAccepted},
+		{name: "Resend verification without email", method: "POST", path: "/verify-email/resend", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Resend my verification", method: "POST", path: "/me/verify-email/resend", token: "valid_token", expectedStatus: http.StatusAccepted},
+		{name: "Resend my verification too soon", method: "POST", path: "/me/verify-email/resend", token: "unverified_token", expectedStatus: http.StatusTooManyRequests},
+		{name: "Resend my verification without token", method: "POST", path: "/me/verify-email/resend", expectedStatus: http.StatusUnauthorized},
+		{name: "Login before verifying", method: "POST", path: "/login", body: `{"email": "unverified@example.com", "password": "password123"}`, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_PhoneVerification(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		path           string
+		token          string
+		body           string
+		expectedStatus int
+	}{
+		{name: "Send code", path: "/me/verify-phone/send", token: "valid_token", expectedStatus: http.StatusAccepted},
+		{name: "Send code too often", path: "/me/verify-phone/send", token: "unverified_token", expectedStatus: http.StatusTooManyRequests},
+		{name: "Send code without token", path: "/me/verify-phone/send", expectedStatus: http.StatusUnauthorized},
+		{name: "Verify phone", path: "/me/verify-phone", token: "valid_token", body: `{"code": "123456"}`, expectedStatus: http.StatusOK},
+		{name: "Verify phone with wrong code", path: "/me/verify-phone", token: "valid_token", body: `{"code": "000000"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Verify phone without code", path: "/me/verify-phone", token: "valid_token", body: `{}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "Verify phone without token", path: "/me/verify-phone", body: `{"code": "123456"}`, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_RestrictUnverified(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{RestrictUnverified: true})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "Unverified user can view their account", method: "GET", path: "/me", token: "unverified_token", expectedStatus: http.StatusOK},
+		{name: "Unverified user cannot update their account", method: "PATCH", path: "/me", token: "unverified_token", expectedStatus: http.StatusForbidden},
+		{name: "Unverified user cannot enroll in MFA", method: "POST", path: "/me/mfa/enroll", token: "unverified_token", expectedStatus: http.StatusForbidden},
+		{name: "Unverified user can log out", method: "POST", path: "/logout-all", token: "unverified_token", expectedStatus: http.StatusOK},
+		{name: "Verified user can update their account", method: "PATCH", path: "/me", token: "valid_token", expectedStatus: http.StatusOK},
+		{name: "Verified admin can list users", method: "GET", path: "/admin/users", token: "admin_token", expectedStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{}`))
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tc.expectedStatus == http.StatusForbidden && !strings.Contains(rr.Body.String(), "EMAIL_NOT_VERIFIED") {
+				t.Errorf("Expected EMAIL_NOT_VERIFIED, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_LoginThrottle(t *testing.T) {
+	loginThrottle := &MockLoginThrottleService{}
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, loginThrottle, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		body := `{"email": "` + email + `", "password": "` + password + `"}`
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		chiRouter.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := login("test@example.com", "wrongpassword"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+	if loginThrottle.Failures != 1 {
+		t.Errorf("Expected the failed login to be recorded, got %d failures", loginThrottle.Failures)
+	}
+
+	if rr := login("test@example.com", "password123"); rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if loginThrottle.Successes != 1 {
+		t.Errorf("Expected the successful login to be recorded, got %d successes", loginThrottle.Successes)
+	}
+
+	rr := login("locked@example.com", "password123")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "ACCOUNT_LOCKED") {
+		t.Errorf("Expected ACCOUNT_LOCKED, got %s", rr.Body.String())
+	}
+	if retryAfter := rr.Header().Get("Retry-After"); retryAfter != "90" {
+		t.Errorf("Expected Retry-After 90, got %q", retryAfter)
+	}
+}
+
+func TestRouter_UnlockUser(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "Unlock user", path: "/admin/users/1/unlock", token: "admin_token", expectedStatus: http.StatusOK},
+		{name: "Unlock unknown user", path: "/admin/users/999/unlock", token: "admin_token", expectedStatus: http.StatusNotFound},
+		{name: "Unlock with invalid ID", path: "/admin/users/abc/unlock", token: "admin_token", expectedStatus: http.StatusBadRequest},
+		{name: "Unlock as a regular user", path: "/admin/users/1/unlock", token: "valid_token", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tc.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouter_Logout(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "Logout without body", path: "/logout"},
+		{name: "Logout with refresh token", path: "/logout", body: `{"refresh_token": "test_refresh_token"}`},
+		{name: "Logout everywhere", path: "/logout-all"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Authorization", "Bearer valid_token")
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_AdminRoutes(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		token          string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "No token", path: "/admin/users/2/role", body: `{"role": "admin"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "Regular user", token: "valid_token", path: "/admin/users/2/role", body: `{"role": "admin"}`, expectedStatus: http.StatusForbidden},
+		{name: "Admin demoted since the token was issued", token: "demoted_token", path: "/admin/users/2/role", body: `{"role": "admin"}`, expectedStatus: http.StatusForbidden},
+		{name: "Admin", token: "admin_token", path: "/admin/users/2/role", body: `{"role": "admin"}`, expectedStatus: http.StatusOK},
+		{name: "Unknown role", token: "admin_token", path: "/admin/users/2/role", body: `{"role": "root"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Invalid user ID", token: "admin_token", path: "/admin/users/abc/role", body: `{"role": "user"}`, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown user", token: "admin_token", path: "/admin/users/404/role", body: `{"role": "user"}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_AdminListUsers(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		token          string
+		query          string
+		expectedStatus int
+	}{
+		{name: "Regular user", token: "valid_token", expectedStatus: http.StatusForbidden},
+		{name: "Admin", token: "admin_token", query: "?limit=1&sort=-created_at&email=test", expectedStatus: http.StatusOK},
+		{name: "Invalid parameters", token: "admin_token", query: "?limit=abc&sort=email", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/users"+tc.query, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response dto.UserListResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(response.Users) != 1 || response.Total != 2 || response.NextCursor != "next" {
+				t.Errorf("Expected one user of two with a next cursor, got %+v", response)
+			}
+		})
+	}
+}
+
+func TestRouter_DeleteAndRestoreUsers(t *testing.T) {
+	mockUserService := &MockUserService{}
+	mockAuthService := &MockAuthServiceForRouter{}
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	testCases := []struct {
+		name           string
+		method         string
+		token          string
+		path           string
+		expectedStatus int
+	}{
+		{name: "Delete own account", method: "DELETE", token: "valid_token", path: "/me", expectedStatus: http.StatusOK},
+		{name: "Regular user deletes another user", method: "DELETE", token: "valid_token", path: "/admin/users/2", expectedStatus: http.StatusForbidden},
+		{name: "Admin deletes a user", method: "DELETE", token: "admin_token", path: "/admin/users/2", expectedStatus: http.StatusOK},
+		{name: "Admin deletes an unknown user", method: "DELETE", token: "admin_token", path: "/admin/users/404", expectedStatus: http.StatusNotFound},
+		{name: "Admin restores a user", method: "POST", token: "admin_token", path: "/admin/users/2/restore", expectedStatus: http.StatusOK},
+		{name: "Restore after the grace period", method: "POST", token: "admin_token", path: "/admin/users/410/restore", expectedStatus: http.StatusGone},
+		{name: "Restore an unknown user", method: "POST", token: "admin_token", path: "/admin/users/404/restore", expectedStatus: http.StatusNotFound},
+		{name: "Invalid user ID", method: "POST", token: "admin_token", path: "/admin/users/abc/restore", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			rr := httptest.NewRecorder()
+
+			chiRouter.ServeHTTP(rr, req)
+
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_SigningKeys(t *testing.T) {
+	keyService := &MockSigningKeyService{}
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, keyService, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/admin/keys/rotate", nil)
+	req.Header.Set("Authorization", "Bearer valid_token")
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected regular users to be forbidden, got %d", rr.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		req = httptest.NewRequest("POST", "/admin/keys/rotate", nil)
+		req.Header.Set("Authorization", "Bearer admin_token")
+		rr = httptest.NewRecorder()
+		chiRouter.ServeHTTP(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", rr.Code)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/admin/keys", nil)
+	req.Header.Set("Authorization", "Bearer admin_token")
+	rr = httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var response dto.SigningKeyListResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(response.Keys))
+	}
+	if response.Keys[0].Active || response.Keys[0].ExpiresAt == nil {
+		t.Errorf("Expected the first key to be retired with an expiry, got %+v", response.Keys[0])
+	}
+	if !response.Keys[1].Active || response.Keys[1].ID != "key-2" {
+		t.Errorf("Expected key-2 to be active, got %+v", response.Keys[1])
+	}
+}
+
+func TestRouter_JWKS(t *testing.T) {
+	router := NewRouter(&MockUserService{}, &MockAuthServiceForRouter{}, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
+	chiRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	chiRouter.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("Cache-Control") == "" {
+		t.Error("Expected the key set to be cacheable")
+	}
+
+	var response dto.JWKSResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Keys) != 1 || response.Keys[0].KeyID != "test-key" || response.Keys[0].Curve != "Ed25519" {
+		t.Errorf("Expected the published test key, got %+v", response.Keys)
+	}
+}
+
 func TestRouter_ProtectedRoutes_NoAuth(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	// Test protected route without token
@@ -132,7 +1016,7 @@ func TestRouter_ProtectedRoutes_NoAuth(t *testing.T) {
 func TestRouter_NotFoundRoute(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	req := httptest.NewRequest("GET", "/nonexistent", nil)
@@ -148,7 +1032,7 @@ func TestRouter_NotFoundRoute(t *testing.T) {
 func TestRouter_MethodNotAllowed(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	// Try to POST to hello endpoint which only accepts GET
@@ -165,7 +1049,7 @@ func TestRouter_MethodNotAllowed(t *testing.T) {
 func TestRouter_SwaggerEndpoint(t *testing.T) {
 	mockUserService := &MockUserService{}
 	mockAuthService := &MockAuthServiceForRouter{}
-	router := NewRouter(mockUserService, mockAuthService)
+	router := NewRouter(mockUserService, mockAuthService, &MockTokenService{}, &MockMFAService{}, &MockPasswordResetService{}, &MockEmailVerificationService{}, &MockEmailChangeService{}, &MockPhoneVerificationService{}, &MockLoginThrottleService{}, &MockRevocationStore{}, &MockSigningKeyService{}, RouterConfig{})
 	chiRouter := router.SetupRoutes()
 
 	req := httptest.NewRequest("GET", "/swagger/", nil)
@@ -2,25 +2,63 @@ package interfaces
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
+	"time"
 
 	"hello-world/internal/domain"
 	"hello-world/internal/interfaces/dto"
 	"hello-world/internal/interfaces/mapper"
+	"hello-world/internal/interfaces/validator"
 )
 
 // UserHandler handles HTTP requests for user operations
 type UserHandler struct {
-	userService domain.UserService
-	mapper      *mapper.UserMapper
+	userService         domain.UserService
+	tokenService        domain.TokenService
+	mfaService          domain.MFAService
+	passwordService     domain.PasswordResetService
+	verificationService domain.EmailVerificationService
+	emailChangeService  domain.EmailChangeService
+	phoneService        domain.PhoneVerificationService
+	loginThrottle       domain.LoginThrottleService
+	mapper              *mapper.UserMapper
+	validator           *validator.Validator
+	trustedProxies      []netip.Prefix
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userService domain.UserService) *UserHandler {
+func NewUserHandler(
+	userService domain.UserService,
+	tokenService domain.TokenService,
+	mfaService domain.MFAService,
+	passwordService domain.PasswordResetService,
+	verificationService domain.EmailVerificationService,
+	emailChangeService domain.EmailChangeService,
+	phoneService domain.PhoneVerificationService,
+	loginThrottle domain.LoginThrottleService,
+	requestValidator *validator.Validator,
+	trustedProxies []netip.Prefix,
+) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		mapper:      mapper.NewUserMapper(),
+		userService:         userService,
+		tokenService:        tokenService,
+		mfaService:          mfaService,
+		passwordService:     passwordService,
+		verificationService: verificationService,
+		emailChangeService:  emailChangeService,
+		phoneService:        phoneService,
+		loginThrottle:       loginThrottle,
+		mapper:              mapper.NewUserMapper(),
+		validator:           requestValidator,
+		trustedProxies:      trustedProxies,
 	}
 }
 
@@ -49,7 +87,8 @@ func (h *UserHandler) HelloHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Register User
-// @Description Register a new user
+// @Description Register a new user. A link to verify the email address is emailed to it.
+// @Description Invalid fields get 422 VALIDATION_ERROR listing every one of them, including each password policy rule the password breaks and an email address or phone number that cannot be normalized.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -57,39 +96,33 @@ func (h *UserHandler) HelloHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 201 {object} dto.APIResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 409 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
 // @Router /register [post]
 func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
-		return
-	}
-
-	// Validate required fields
-	if err := h.validateRegisterRequest(req); err != nil {
-		h.sendErrorResponse(w, http.StatusBadRequest, err.Error())
+	if !h.decodeRequest(w, r, &req) {
 		return
 	}
 
 	// Parse request using mapper
 	email, password, firstName, lastName, phone, birthday, err := h.mapper.ParseCreateUserRequest(req)
 	if err != nil {
-		if domainErr, ok := err.(domain.DomainError); ok {
-			h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
-		} else {
-			h.sendErrorResponse(w, http.StatusBadRequest, "Invalid request data")
-		}
+		h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, []dto.ValidationError{
+			{Field: "birthday", Rule: domain.FieldRuleBirthday, Message: domain.ErrInvalidBirthday.Message},
+		})
 		return
 	}
 
 	user, err := h.userService.Register(r.Context(), email, password, firstName, lastName, phone, birthday)
 	if err != nil {
+		if invalid, ok := err.(*domain.ValidationError); ok {
+			h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, h.mapper.ToValidationErrors(invalid))
+			return
+		}
 		if domainErr, ok := err.(domain.DomainError); ok {
 			switch domainErr.Code {
 			case "USER_ALREADY_EXISTS":
 				h.sendErrorResponseWithCode(w, http.StatusConflict, domainErr.Message, domainErr.Code)
-			case "INVALID_BIRTHDAY":
-				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
 			default:
 				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
 			}
@@ -99,6 +132,10 @@ func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account exists either way; if the email cannot be sent now, the
+	// user can ask for it again
+	_ = h.verificationService.SendVerification(r.Context(), user.ID)
+
 	userResponse := h.mapper.ToUserResponse(user)
 	h.sendSuccessResponse(w, http.StatusCreated, "User registered successfully", userResponse)
 }
@@ -109,29 +146,43 @@ func (h *UserHandler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param credentials body dto.LoginRequest true "Login credentials"
+// @Description The response contains a short-lived access token and an opaque refresh token for POST /token/refresh.
+// @Description Users with two-factor authentication get 202 with an MFA token instead, to complete the login with POST /login/mfa.
+// @Description When verified emails are required, users who have not verified theirs get 403 EMAIL_NOT_VERIFIED.
+// @Description Repeated failures lock the account (ACCOUNT_LOCKED) or the client IP address (TOO_MANY_LOGIN_ATTEMPTS) for a while; the response then has a Retry-After header.
 // @Success 200 {object} dto.LoginResponse
+// @Success 202 {object} dto.MFAChallengeResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Header 429 {integer} Retry-After "Seconds until logins are accepted again"
 // @Router /login [post]
 func (h *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var req dto.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if !h.decodeRequest(w, r, &req) {
 		return
 	}
 
-	// Validate required fields
-	if req.Email == "" || req.Password == "" {
-		h.sendErrorResponse(w, http.StatusBadRequest, "Email and password are required")
+	// Locked accounts are refused before the costly password check
+	ip := h.clientIP(r)
+	if retryAfter, err := h.loginThrottle.Check(r.Context(), req.Email, ip); err != nil {
+		h.sendThrottledResponse(w, retryAfter, err)
 		return
 	}
 
-	token, user, err := h.userService.Login(r.Context(), req.Email, req.Password)
+	result, err := h.userService.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
 		if domainErr, ok := err.(domain.DomainError); ok {
 			switch domainErr.Code {
 			case "INVALID_CREDENTIALS":
+				if err := h.loginThrottle.RecordFailure(r.Context(), req.Email, ip); err != nil {
+					log.Printf("Failed to record failed login: %v", err)
+				}
 				h.sendErrorResponseWithCode(w, http.StatusUnauthorized, domainErr.Message, domainErr.Code)
+			case "EMAIL_NOT_VERIFIED":
+				h.sendErrorResponseWithCode(w, http.StatusForbidden, domainErr.Message, domainErr.Code)
 			default:
 				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
 			}
@@ -141,11 +192,127 @@ func (h *UserHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Failed logins are forgotten only once the second factor, if any, passes
+	if result.MFARequired() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(h.mapper.ToMFAChallengeResponse(result.MFAChallenge))
+		return
+	}
+	if err := h.loginThrottle.RecordSuccess(r.Context(), req.Email, ip); err != nil {
+		log.Printf("Failed to reset failed logins: %v", err)
+	}
+
+	h.sendLoginResponse(w, r, result.Token, result.User)
+}
+
+// sendLoginResponse issues a refresh token and sends it with the access token
+func (h *UserHandler) sendLoginResponse(w http.ResponseWriter, r *http.Request, token string, user *domain.User) {
+	refreshToken, err := h.tokenService.IssueRefreshToken(r.Context(), user.ID)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Login failed")
+		return
+	}
+
 	loginResponse := h.mapper.ToLoginResponse(token, user)
+	loginResponse.RefreshToken = refreshToken
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(loginResponse)
 }
 
+// @Summary Refresh Token
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used only once; reusing one revokes every token issued from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body dto.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /token/refresh [post]
+func (h *UserHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.RefreshTokenRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	pair, err := h.tokenService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INVALID_REFRESH_TOKEN", "REFRESH_TOKEN_REUSED":
+				h.sendErrorResponseWithCode(w, http.StatusUnauthorized, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Token refresh failed")
+		return
+	}
+
+	tokenResponse := h.mapper.ToTokenResponse(pair)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokenResponse)
+}
+
+// @Summary Logout
+// @Description Revoke the current access token, and the refresh token if one is given
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param token body dto.LogoutRequest false "Refresh token to revoke"
+// @Success 200 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /logout [post]
+func (h *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value("token_claims").(*domain.TokenClaims)
+	if !ok {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	// The body is optional
+	var req dto.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.tokenService.Logout(r.Context(), claims, req.RefreshToken); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Logout failed")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "Logged out successfully", nil)
+}
+
+// @Summary Logout Everywhere
+// @Description Revoke every access token and refresh token issued to the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.APIResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /logout-all [post]
+func (h *UserHandler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	if err := h.tokenService.LogoutAll(r.Context(), userID); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Logout failed")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "Logged out from all sessions", nil)
+}
+
 // @Summary Get Current User
 // @Description Get current user information from JWT token
 // @Tags auth
@@ -183,6 +350,106 @@ func (h *UserHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(userResponse)
 }
 
+// @Summary Update Current User
+// @Description Partially update the current user's profile. Omitted fields are left unchanged; no field can be cleared.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param user body dto.PatchUserRequest true "Fields to update"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /me [patch]
+func (h *UserHandler) UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	var req dto.PatchUserRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	update, fieldErrors := h.mapper.ParsePatchUserRequest(req)
+	if len(fieldErrors) > 0 {
+		h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, fieldErrors)
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), userID, update)
+	if err != nil {
+		if invalid, ok := err.(*domain.ValidationError); ok {
+			h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, h.mapper.ToValidationErrors(invalid))
+			return
+		}
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "INVALID_FIRST_NAME", "INVALID_LAST_NAME", "INVALID_EMAIL":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to update user profile")
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// @Summary Delete Current User
+// @Description Delete the current user's account and end all of its sessions. The account can be restored by an administrator until the grace period ends, after which it is purged.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.APIResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Router /me [delete]
+func (h *UserHandler) DeleteMeHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	h.deleteAccount(w, r, userID)
+}
+
+// deleteAccount soft-deletes the user and revokes every token issued to it
+func (h *UserHandler) deleteAccount(w http.ResponseWriter, r *http.Request, userID int) {
+	if err := h.userService.DeleteAccount(r.Context(), userID); err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	if err := h.tokenService.LogoutAll(r.Context(), userID); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Account deleted but its sessions could not be revoked")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusOK, "Account deleted", nil)
+}
+
 // Helper methods
 
 func (h *UserHandler) sendErrorResponse(w http.ResponseWriter, statusCode int, message string) {
@@ -197,32 +464,101 @@ func (h *UserHandler) sendErrorResponseWithCode(w http.ResponseWriter, statusCod
 	json.NewEncoder(w).Encode(dto.ErrorResponse{Error: message, Code: code})
 }
 
+func (h *UserHandler) sendValidationErrorResponse(w http.ResponseWriter, statusCode int, fields []dto.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(dto.ValidationErrorResponse{Error: "Validation failed", Code: "VALIDATION_ERROR", Fields: fields})
+}
+
+// decodeRequest decodes the JSON body into req and checks it against its
+// validate tags, answering malformed JSON with 400 and invalid fields with
+// 422, all of them at once. It reports whether req can be used.
+func (h *UserHandler) decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	if fieldErrors := h.validator.Validate(req); len(fieldErrors) > 0 {
+		h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, fieldErrors)
+		return false
+	}
+	return true
+}
+
+// sendPasswordPolicyResponse answers with 422, listing every password policy
+// rule the password in field breaks
+func (h *UserHandler) sendPasswordPolicyResponse(w http.ResponseWriter, field string, err *domain.PasswordPolicyError) {
+	h.sendValidationErrorResponse(w, http.StatusUnprocessableEntity, h.mapper.ToPasswordValidationErrors(field, err))
+}
+
+// sendThrottledResponse refuses a login with 429 and a Retry-After header
+// telling the client how many seconds to wait
+func (h *UserHandler) sendThrottledResponse(w http.ResponseWriter, retryAfter time.Duration, err error) {
+	domainErr, ok := err.(domain.DomainError)
+	if !ok {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Login failed")
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+}
+
 func (h *UserHandler) sendSuccessResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(dto.APIResponse{Message: message, Data: data})
 }
 
-func (h *UserHandler) validateRegisterRequest(req dto.CreateUserRequest) error {
-	if req.Email == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "Email is required"}
+// clientIP returns the address of the client the request came from. Clients
+// can set X-Forwarded-For and X-Real-IP to anything, so they are only read
+// when the request comes from a trusted proxy. X-Forwarded-For is walked from
+// the right, past the trusted proxies each hop appended, to the first address
+// no trusted proxy vouches for.
+func (h *UserHandler) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
 	}
-	if req.Password == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "Password is required"}
+	if !h.isTrustedProxy(host) {
+		return host
 	}
-	if req.FirstName == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "First name is required"}
+
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) > 0 {
+		hops := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break
+			}
+			host = hop
+			if !h.isTrustedProxy(hop) {
+				return hop
+			}
+		}
+		return host
 	}
-	if req.LastName == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "Last name is required"}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		if _, err := netip.ParseAddr(realIP); err == nil {
+			return realIP
+		}
 	}
-	if req.Phone == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "Phone is required"}
+	return host
+}
+
+// isTrustedProxy reports whether ip belongs to a trusted proxy
+func (h *UserHandler) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
 	}
-	if req.Birthday == "" {
-		return domain.DomainError{Code: "VALIDATION_ERROR", Message: "Birthday is required"}
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
 	}
-	return nil
+	return false
 }
 
 func (h *UserHandler) getUserIDFromContext(r *http.Request) (int, error) {
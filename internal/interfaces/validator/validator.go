@@ -0,0 +1,228 @@
+// Package validator checks request DTOs against their validate struct tags
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// Rules reported in dto.ValidationError.Rule. The birthday tag reports
+// RuleDate, RuleFuture, RuleMinAge or RuleMaxAge.
+const (
+	RuleRequired = "required"
+	RuleEmail    = "email"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RulePhone    = "phone"
+	RuleDate     = "date"
+	RuleFuture   = "future"
+	RuleMinAge   = "min_age"
+	RuleMaxAge   = "max_age"
+)
+
+// MaxAge is the oldest age a birthday is accepted for
+const MaxAge = 150
+
+// birthdayTag is the tag checking birthdays, which reports finer rules
+const birthdayTag = "birthday"
+
+// dateLayout is the format of dates in requests
+const dateLayout = "2006-01-02"
+
+// phonePattern matches the characters a phone number may be written with
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+
+// Validator checks the string and dto.NullableString fields of a request
+// against the comma-separated rules of their validate tag:
+//
+//	required  present and not blank
+//	email     an email address that domain.NormalizeEmail accepts
+//	min=N     at least N characters
+//	max=N     at most N characters
+//	phone     7 to 15 digits, optionally after a +, grouped with spaces,
+//	          dashes, dots or parentheses
+//	date      a YYYY-MM-DD date
+//	birthday  a YYYY-MM-DD date, not in the future, of someone between the
+//	          minimum age and MaxAge years old
+//
+// Rules other than required accept empty values, so optional fields can be
+// left out. An omitted NullableString is not checked at all; one set to null
+// only breaks required. Fields are named by their json tag, and in messages
+// by their label tag if they have one.
+type Validator struct {
+	minimumAge int
+	now        func() time.Time
+}
+
+// New creates a Validator. Birthdays of people younger than minimumAge years
+// are refused.
+func New(minimumAge int) *Validator {
+	return &Validator{minimumAge: minimumAge, now: time.Now}
+}
+
+// Validate returns an error for every invalid field of req, a pointer to a
+// request struct. Each field reports the first rule it breaks.
+func (v *Validator) Validate(req interface{}) []dto.ValidationError {
+	value := reflect.Indirect(reflect.ValueOf(req))
+	structType := value.Type()
+
+	var fieldErrors []dto.ValidationError
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		text, null, present := fieldText(value.Field(i))
+		if !present {
+			continue
+		}
+		name := fieldName(field)
+		label := field.Tag.Get("label")
+		if label == "" {
+			label = defaultLabel(name)
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			rule, param, _ := strings.Cut(rule, "=")
+			if broken, message := v.check(rule, param, label, text, null); broken != "" {
+				fieldErrors = append(fieldErrors, dto.ValidationError{Field: name, Rule: broken, Message: message})
+				break
+			}
+		}
+	}
+	return fieldErrors
+}
+
+// check returns the rule broken by text and why, or an empty rule
+func (v *Validator) check(rule, param, label, text string, null bool) (string, string) {
+	if rule == RuleRequired {
+		if null || strings.TrimSpace(text) == "" {
+			return RuleRequired, label + " is required"
+		}
+		return "", ""
+	}
+	if text == "" {
+		return "", ""
+	}
+
+	switch rule {
+	case RuleEmail:
+		if !isEmail(text) {
+			return RuleEmail, label + " must be a valid email address"
+		}
+	case RuleMin:
+		if min := intParam(rule, param); utf8.RuneCountInString(text) < min {
+			return RuleMin, fmt.Sprintf("%s must be at least %d characters long", label, min)
+		}
+	case RuleMax:
+		if max := intParam(rule, param); utf8.RuneCountInString(text) > max {
+			return RuleMax, fmt.Sprintf("%s must be at most %d characters long", label, max)
+		}
+	case RulePhone:
+		if !isPhone(text) {
+			return RulePhone, label + " must be a phone number of 7 to 15 digits"
+		}
+	case RuleDate:
+		if _, err := time.Parse(dateLayout, text); err != nil {
+			return RuleDate, label + " must be a date in YYYY-MM-DD format"
+		}
+	case birthdayTag:
+		return v.checkBirthday(label, text)
+	default:
+		panic("validator: unknown rule " + rule)
+	}
+	return "", ""
+}
+
+// checkBirthday returns the rule broken by a birthday and why, or an empty rule
+func (v *Validator) checkBirthday(label, text string) (string, string) {
+	birthday, err := time.Parse(dateLayout, text)
+	if err != nil {
+		return RuleDate, label + " must be a date in YYYY-MM-DD format"
+	}
+
+	year, month, day := v.now().UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	switch {
+	case birthday.After(today):
+		return RuleFuture, label + " cannot be in the future"
+	case birthday.AddDate(v.minimumAge, 0, 0).After(today):
+		return RuleMinAge, fmt.Sprintf("You must be at least %d years old", v.minimumAge)
+	case !birthday.AddDate(MaxAge, 0, 0).After(today):
+		return RuleMaxAge, fmt.Sprintf("%s must be less than %d years ago", label, MaxAge)
+	}
+	return "", ""
+}
+
+// isEmail reports whether text is a single bare address that can be normalized
+func isEmail(text string) bool {
+	normalized, err := domain.NormalizeEmail(text)
+	if err != nil || len(normalized) > 254 {
+		return false
+	}
+	address, err := mail.ParseAddress(normalized)
+	return err == nil && address.Name == "" && address.Address == normalized
+}
+
+// isPhone reports whether text is written like a phone number with 7 to 15 digits
+func isPhone(text string) bool {
+	if !phonePattern.MatchString(text) {
+		return false
+	}
+	digits := 0
+	for _, r := range text {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// fieldText returns the text of a string or dto.NullableString field, and
+// whether it is null and present in the request
+func fieldText(field reflect.Value) (text string, null, present bool) {
+	switch value := field.Interface().(type) {
+	case string:
+		return value, false, true
+	case dto.NullableString:
+		return value.Value, value.Null, value.Set
+	default:
+		panic("validator: cannot validate a field of type " + field.Type().String())
+	}
+}
+
+// fieldName returns the name of the field in the JSON payload
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// defaultLabel turns a JSON field name such as current_password into
+// "Current password"
+func defaultLabel(name string) string {
+	label := strings.ReplaceAll(name, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+func intParam(rule, param string) int {
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		panic("validator: " + rule + " needs a number, got " + strconv.Quote(param))
+	}
+	return n
+}
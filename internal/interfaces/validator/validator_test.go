@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"hello-world/internal/interfaces/dto"
+)
+
+// newTestValidator creates a validator for users of at least 13, on 2024-06-15
+func newTestValidator() *Validator {
+	v := New(13)
+	v.now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+	return v
+}
+
+func TestValidator_Rules(t *testing.T) {
+	type request struct {
+		Name     string `json:"name" validate:"required,min=2,max=5"`
+		Email    string `json:"email" validate:"email"`
+		Phone    string `json:"phone" validate:"phone"`
+		Date     string `json:"date" validate:"date"`
+		Birthday string `json:"birthday" validate:"birthday"`
+	}
+	valid := request{Name: "Ann", Email: "ann@example.com", Phone: "+66 (81) 234-5678", Date: "2024-02-29", Birthday: "2011-06-15"}
+
+	tests := []struct {
+		name   string
+		change func(r *request)
+		field  string
+		rule   string
+	}{
+		{name: "Valid", change: func(r *request) {}},
+		{name: "Optional fields may be empty", change: func(r *request) { r.Email, r.Phone, r.Date, r.Birthday = "", "", "", "" }},
+		{name: "Missing", change: func(r *request) { r.Name = "" }, field: "name", rule: RuleRequired},
+		{name: "Blank", change: func(r *request) { r.Name = "   " }, field: "name", rule: RuleRequired},
+		{name: "Too short", change: func(r *request) { r.Name = "A" }, field: "name", rule: RuleMin},
+		{name: "Too long", change: func(r *request) { r.Name = "Annabel" }, field: "name", rule: RuleMax},
+		{name: "Length counts characters", change: func(r *request) { r.Name = "Ánnéø" }},
+		{name: "Email without @", change: func(r *request) { r.Email = "ann.example.com" }, field: "email", rule: RuleEmail},
+		{name: "Email with a display name", change: func(r *request) { r.Email = "Ann <ann@example.com>" }, field: "email", rule: RuleEmail},
+		{name: "Email with spaces", change: func(r *request) { r.Email = "ann smith@example.com" }, field: "email", rule: RuleEmail},
+		{name: "Email with an internationalized domain", change: func(r *request) { r.Email = "ann@bücher.example" }},
+		{name: "Phone with letters", change: func(r *request) { r.Phone = "081-CALL-NOW" }, field: "phone", rule: RulePhone},
+		{name: "Phone too short", change: func(r *request) { r.Phone = "12-34" }, field: "phone", rule: RulePhone},
+		{name: "Phone too long", change: func(r *request) { r.Phone = "+1234567890123456" }, field: "phone", rule: RulePhone},
+		{name: "Date in another format", change: func(r *request) { r.Date = "15/06/2024" }, field: "date", rule: RuleDate},
+		{name: "Date that does not exist", change: func(r *request) { r.Date = "2023-02-29" }, field: "date", rule: RuleDate},
+		{name: "Birthday in another format", change: func(r *request) { r.Birthday = "June 15" }, field: "birthday", rule: RuleDate},
+		{name: "Birthday in the future", change: func(r *request) { r.Birthday = "2024-06-16" }, field: "birthday", rule: RuleFuture},
+		{name: "Birthday too recent", change: func(r *request) { r.Birthday = "2011-06-16" }, field: "birthday", rule: RuleMinAge},
+		{name: "Birthday too old", change: func(r *request) { r.Birthday = "1874-06-15" }, field: "birthday", rule: RuleMaxAge},
+		{name: "Birthday just young enough", change: func(r *request) { r.Birthday = "1874-06-16" }},
+	}
+
+	v := newTestValidator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.change(&req)
+			fieldErrors := v.Validate(&req)
+
+			if tt.rule == "" {
+				if len(fieldErrors) != 0 {
+					t.Fatalf("Expected no errors, got %+v", fieldErrors)
+				}
+				return
+			}
+			if len(fieldErrors) != 1 {
+				t.Fatalf("Expected one error, got %+v", fieldErrors)
+			}
+			if got := fieldErrors[0]; got.Field != tt.field || got.Rule != tt.rule || got.Message == "" {
+				t.Errorf("Expected a %s error on %s, got %+v", tt.rule, tt.field, got)
+			}
+		})
+	}
+}
+
+func TestValidator_ReportsEveryField(t *testing.T) {
+	fieldErrors := newTestValidator().Validate(&dto.CreateUserRequest{Email: "nope", Phone: "123"})
+
+	want := map[string]string{
+		"email":     RuleEmail,
+		"password":  RuleRequired,
+		"firstname": RuleRequired,
+		"lastname":  RuleRequired,
+		"phone":     RulePhone,
+		"birthday":  RuleRequired,
+	}
+	if len(fieldErrors) != len(want) {
+		t.Fatalf("Expected %d errors, got %+v", len(want), fieldErrors)
+	}
+	for _, fieldError := range fieldErrors {
+		if want[fieldError.Field] != fieldError.Rule {
+			t.Errorf("Expected a %s error on %s, got %+v", want[fieldError.Field], fieldError.Field, fieldError)
+		}
+	}
+	if fieldErrors[2].Message != "First name is required" {
+		t.Errorf("Expected the label in the message, got %q", fieldErrors[2].Message)
+	}
+}
+
+func TestValidator_NullableFields(t *testing.T) {
+	v := newTestValidator()
+	decode := func(body string) *dto.PatchUserRequest {
+		var req dto.PatchUserRequest
+		if err := json.Unmarshal([]byte(body), &req); err != nil {
+			t.Fatalf("Failed to decode %s: %v", body, err)
+		}
+		return &req
+	}
+
+	if fieldErrors := v.Validate(decode(`{}`)); len(fieldErrors) != 0 {
+		t.Errorf("Expected omitted fields not to be checked, got %+v", fieldErrors)
+	}
+	if fieldErrors := v.Validate(decode(`{"phone": null, "birthday": null}`)); len(fieldErrors) != 2 || fieldErrors[0].Rule != RuleRequired || fieldErrors[1].Rule != RuleRequired {
+		t.Errorf("Expected the phone and birthday to be required, got %+v", fieldErrors)
+	}
+
+	fieldErrors := v.Validate(decode(`{"firstname": null, "lastname": "", "birthday": "2030-01-01"}`))
+	if len(fieldErrors) != 3 {
+		t.Fatalf("Expected 3 errors, got %+v", fieldErrors)
+	}
+	if fieldErrors[0].Rule != RuleRequired || fieldErrors[1].Rule != RuleRequired || fieldErrors[2].Rule != RuleFuture {
+		t.Errorf("Expected required, required and future errors, got %+v", fieldErrors)
+	}
+}
+
+// TestValidator_RequestDTOs checks that every request DTO only uses known rules
+func TestValidator_RequestDTOs(t *testing.T) {
+	requests := []interface{}{
+		&dto.CreateUserRequest{}, &dto.PatchUserRequest{}, &dto.LoginRequest{}, &dto.RefreshTokenRequest{},
+		&dto.LogoutRequest{}, &dto.SetRoleRequest{}, &dto.MFALoginRequest{}, &dto.MFACodeRequest{},
+		&dto.ForgotPasswordRequest{}, &dto.ResetPasswordRequest{}, &dto.ChangePasswordRequest{},
+		&dto.ResendVerificationRequest{}, &dto.ChangeEmailRequest{}, &dto.VerifyPhoneRequest{},
+	}
+
+	v := newTestValidator()
+	for _, req := range requests {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Validating %T panicked: %v", req, r)
+				}
+			}()
+			for _, fieldError := range v.Validate(req) {
+				if strings.TrimSpace(fieldError.Message) == "" {
+					t.Errorf("Expected a message for %T.%s", req, fieldError.Field)
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,104 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"hello-world/internal/domain"
+	"hello-world/internal/interfaces/dto"
+)
+
+// @Summary Verify Email
+// @Description Verify the email address with the token from a verification link. The link stops working if the email address has changed since it was sent.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token from the verification link"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Router /verify-email [get]
+func (h *UserHandler) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.sendErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	user, err := h.verificationService.VerifyEmail(r.Context(), token)
+	if err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "INVALID_VERIFICATION_TOKEN":
+				h.sendErrorResponseWithCode(w, http.StatusBadRequest, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+
+	userResponse := h.mapper.ToUserResponse(user)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(userResponse)
+}
+
+// @Summary Resend Verification Email
+// @Description Email a new verification link to the unverified account using the email. The response is the same whether or not such an account exists, and at most one email is sent per resend interval.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResendVerificationRequest true "Email of the account"
+// @Success 202 {object} dto.APIResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ValidationErrorResponse
+// @Router /verify-email/resend [post]
+func (h *UserHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResendVerificationRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.verificationService.ResendVerification(r.Context(), req.Email); err != nil {
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to resend verification email")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusAccepted, "If an unverified account uses this email, a verification link has been sent to it", nil)
+}
+
+// @Summary Resend My Verification Email
+// @Description Email a new verification link to the current user. Only one email is sent per resend interval.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} dto.APIResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Router /me/verify-email/resend [post]
+func (h *UserHandler) ResendMyVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		h.sendErrorResponse(w, http.StatusUnauthorized, "Invalid user context")
+		return
+	}
+
+	if err := h.verificationService.SendVerification(r.Context(), userID); err != nil {
+		if domainErr, ok := err.(domain.DomainError); ok {
+			switch domainErr.Code {
+			case "USER_NOT_FOUND":
+				h.sendErrorResponseWithCode(w, http.StatusNotFound, domainErr.Message, domainErr.Code)
+			case "VERIFICATION_THROTTLED":
+				h.sendErrorResponseWithCode(w, http.StatusTooManyRequests, domainErr.Message, domainErr.Code)
+			default:
+				h.sendErrorResponse(w, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		h.sendErrorResponse(w, http.StatusInternalServerError, "Failed to resend verification email")
+		return
+	}
+
+	h.sendSuccessResponse(w, http.StatusAccepted, "Unless your email is already verified, a verification link has been sent to it", nil)
+}
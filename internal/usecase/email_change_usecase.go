@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// EmailChangeConfig holds the settings used by EmailChangeUseCase
+type EmailChangeConfig struct {
+	// ConfirmURL is the link sent to the new address and RevertURL the one
+	// sent to the current address; the token is added as the "token" query
+	// parameter
+	ConfirmURL string
+	RevertURL  string
+	// ResendInterval is the minimum time between two emails asking the user
+	// to confirm an address, shared with email verification
+	ResendInterval time.Duration
+	// ConfirmTTL and RevertTTL are how long the links work, as signed into
+	// the tokens. They are only used to tell the user.
+	ConfirmTTL time.Duration
+	RevertTTL  time.Duration
+}
+
+// EmailChangeUseCase implements domain.EmailChangeService with signed links
+// sent by email
+type EmailChangeUseCase struct {
+	userRepo     domain.UserRepository
+	hasher       domain.PasswordHasher
+	authService  domain.AuthService
+	tokenService domain.TokenService
+	mailer       domain.Mailer
+	config       EmailChangeConfig
+	now          func() time.Time
+}
+
+// NewEmailChangeUseCase creates a new EmailChangeUseCase instance
+func NewEmailChangeUseCase(
+	userRepo domain.UserRepository,
+	hasher domain.PasswordHasher,
+	authService domain.AuthService,
+	tokenService domain.TokenService,
+	mailer domain.Mailer,
+	config EmailChangeConfig,
+) domain.EmailChangeService {
+	return &EmailChangeUseCase{
+		userRepo:     userRepo,
+		hasher:       hasher,
+		authService:  authService,
+		tokenService: tokenService,
+		mailer:       mailer,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// RequestEmailChange stores the new address as pending and emails both
+// addresses. Whether another account uses the new address is only checked
+// on confirmation, so the request does not reveal which are registered.
+func (uc *EmailChangeUseCase) RequestEmailChange(ctx context.Context, userID int, password, newEmail string) error {
+	newEmail, err := domain.NormalizeEmail(newEmail)
+	if err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	if err := uc.hasher.Compare(user.Password, password); err != nil {
+		return domain.ErrIncorrectPassword
+	}
+	if newEmail == user.Email {
+		return domain.ErrEmailUnchanged
+	}
+
+	now := uc.now()
+	recorded, err := uc.userRepo.RecordVerificationSent(ctx, user.ID, now, now.Add(-uc.config.ResendInterval))
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return domain.ErrVerificationThrottled
+	}
+
+	user.PendingEmail = newEmail
+	user.UpdatedAt = now
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return domain.ErrUserUpdateError
+	}
+
+	// The confirmation is an email verification token for the new address
+	confirmToken, err := uc.authService.GenerateEmailVerificationToken(user.ID, newEmail)
+	if err != nil {
+		return domain.ErrTokenGenerationError
+	}
+	revertToken, err := uc.authService.GenerateEmailRevertToken(user.ID, user.Email)
+	if err != nil {
+		return domain.ErrTokenGenerationError
+	}
+
+	if err := uc.mailer.Send(ctx, domain.EmailMessage{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm that you want to log in with this email address from now on by opening the link below:\n\n%s\n\nThe link expires in %s. Until then your account keeps its current email address. If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, addTokenToURL(uc.config.ConfirmURL, confirmToken), uc.config.ConfirmTTL,
+		),
+	}); err != nil {
+		return err
+	}
+	return uc.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Your email address is being changed",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nSomeone asked to change the email address of your account to %s. It changes once the new address is confirmed.\n\nIf this was not you, open the link below to keep this address and log out everywhere, then reset your password:\n\n%s\n\nThe link works for %s.\n",
+			user.FirstName, newEmail, addTokenToURL(uc.config.RevertURL, revertToken), uc.config.RevertTTL,
+		),
+	})
+}
+
+// ConfirmEmailChange switches the email to the pending address. The link
+// stops working once another address is requested or the change is reverted.
+func (uc *EmailChangeUseCase) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	userID, email, err := uc.authService.ValidateEmailVerificationToken(token)
+	if err != nil {
+		return nil, domain.ErrInvalidEmailChangeToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user.PendingEmail == "" || user.PendingEmail != email {
+		return nil, domain.ErrInvalidEmailChangeToken
+	}
+
+	if err := uc.checkEmailAvailable(ctx, user, email); err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	user.Email = email
+	user.PendingEmail = ""
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	if err := uc.updateEmail(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// RevertEmailChange restores the address the revert link was sent to. As
+// the change may have been made by someone else, every session is revoked.
+func (uc *EmailChangeUseCase) RevertEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	userID, email, err := uc.authService.ValidateEmailRevertToken(token)
+	if err != nil {
+		return nil, domain.ErrInvalidEmailChangeToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrInvalidEmailChangeToken
+	}
+
+	now := uc.now()
+	if user.Email != email {
+		if err := uc.checkEmailAvailable(ctx, user, email); err != nil {
+			return nil, err
+		}
+		user.Email = email
+		user.EmailVerifiedAt = nil
+	}
+	// The link proves the user receives mail at the address
+	if !user.IsEmailVerified() {
+		user.EmailVerifiedAt = &now
+	}
+	user.PendingEmail = ""
+	user.UpdatedAt = now
+	if err := uc.updateEmail(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if err := uc.tokenService.LogoutAll(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// checkEmailAvailable returns ErrUserAlreadyExists if another active account
+// uses the email. Changing the case of the user's own address is allowed.
+func (uc *EmailChangeUseCase) checkEmailAvailable(ctx context.Context, user *domain.User, email string) error {
+	if domain.EmailKey(email) == domain.EmailKey(user.Email) {
+		return nil
+	}
+	exists, err := uc.userRepo.Exists(ctx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrUserAlreadyExists
+	}
+	return nil
+}
+
+// updateEmail saves the user. The unique constraint on email catches
+// addresses taken concurrently or held by soft-deleted accounts.
+func (uc *EmailChangeUseCase) updateEmail(ctx context.Context, user *domain.User) error {
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		if err == domain.ErrUserAlreadyExists {
+			return err
+		}
+		return domain.ErrUserUpdateError
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+type emailChangeFixture struct {
+	uc          *EmailChangeUseCase
+	userRepo    *MockUserRepository
+	revocations *MockTokenRevocationStore
+	mailer      *MockMailer
+	userID      int
+	clock       *fakeClock
+}
+
+// newTestEmailChangeUseCase creates an email change use case with a
+// registered user, running on the fixture's clock
+func newTestEmailChangeUseCase(t *testing.T) *emailChangeFixture {
+	t.Helper()
+
+	userRepo := NewMockUserRepository()
+	authService := NewMockAuthService()
+	userID := registerTestUser(t, userRepo)
+
+	revocations := NewMockTokenRevocationStore()
+	tokenService := NewTokenUseCase(userRepo, NewMockRefreshTokenRepository(), revocations, authService, TokenConfig{
+		AccessTTL:  time.Hour,
+		RefreshTTL: time.Hour,
+	})
+
+	f := &emailChangeFixture{
+		userRepo:    userRepo,
+		revocations: revocations,
+		mailer:      &MockMailer{},
+		userID:      userID,
+		clock:       newFakeClock(),
+	}
+	f.uc = NewEmailChangeUseCase(userRepo, NewMockPasswordHasher(), authService, tokenService, f.mailer, EmailChangeConfig{
+		ConfirmURL:     "https://app.example.com/email/confirm",
+		RevertURL:      "https://app.example.com/email/revert",
+		ResendInterval: time.Minute,
+		ConfirmTTL:     24 * time.Hour,
+		RevertTTL:      7 * 24 * time.Hour,
+	}).(*EmailChangeUseCase)
+	f.uc.now = f.clock.Now
+	return f
+}
+
+func TestEmailChangeUseCase_RequestAndConfirm(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "new@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.mailer.sent) != 2 || f.mailer.sent[0].To != "new@example.com" || f.mailer.sent[1].To != "test@example.com" {
+		t.Fatalf("Expected emails to the new and the old address, got %+v", f.mailer.sent)
+	}
+
+	// Nothing changes until the new address is confirmed
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.Email != "test@example.com" || user.PendingEmail != "new@example.com" {
+		t.Fatalf("Expected the change to be pending, got email %q and pending %q", user.Email, user.PendingEmail)
+	}
+
+	confirmed, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0]))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if confirmed.Email != "new@example.com" || confirmed.PendingEmail != "" || !confirmed.IsEmailVerified() {
+		t.Errorf("Expected the verified new address, got %+v", confirmed)
+	}
+	if confirmed.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+	if _, err := f.userRepo.GetByEmail(ctx, "new@example.com"); err != nil {
+		t.Errorf("Expected the user to be found by the new address, got %v", err)
+	}
+
+	// The link works once
+	if _, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0])); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected ErrInvalidEmailChangeToken for a used link, got %v", err)
+	}
+}
+
+func TestEmailChangeUseCase_RequestEmailChange_Errors(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "wrongpassword", "new@example.com"); err != domain.ErrIncorrectPassword {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "test@example.com"); err != domain.ErrEmailUnchanged {
+		t.Errorf("Expected ErrEmailUnchanged, got %v", err)
+	}
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", ""); err != domain.ErrInvalidEmail {
+		t.Errorf("Expected ErrInvalidEmail, got %v", err)
+	}
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "new@"); err != domain.ErrInvalidEmailAddress {
+		t.Errorf("Expected ErrInvalidEmailAddress, got %v", err)
+	}
+	if len(f.mailer.sent) != 0 {
+		t.Fatalf("Expected no emails, got %d", len(f.mailer.sent))
+	}
+
+	// Throttled like verification emails
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "new@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "other@example.com"); err != domain.ErrVerificationThrottled {
+		t.Errorf("Expected ErrVerificationThrottled, got %v", err)
+	}
+}
+
+func TestEmailChangeUseCase_ChangeCase(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", " test@EXAMPLE.com "); err != domain.ErrEmailUnchanged {
+		t.Errorf("Expected ErrEmailUnchanged once normalized, got %v", err)
+	}
+
+	// Only the case of the local part changes, which does not need a free address
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "Test@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	confirmed, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0]))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if confirmed.Email != "Test@example.com" {
+		t.Errorf("Expected the new case to be kept, got %q", confirmed.Email)
+	}
+}
+
+func TestEmailChangeUseCase_ConfirmEmailChange_Superseded(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "first@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	f.clock.Advance(time.Minute)
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "second@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0])); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected ErrInvalidEmailChangeToken for a superseded link, got %v", err)
+	}
+	if _, err := f.uc.ConfirmEmailChange(ctx, "bogus"); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected ErrInvalidEmailChangeToken for a bogus link, got %v", err)
+	}
+}
+
+func TestEmailChangeUseCase_ConfirmEmailChange_AddressTaken(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "taken@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Someone registers the address before the change is confirmed
+	f.userRepo.users["taken@example.com"] = &domain.User{ID: 99, Email: "taken@example.com"}
+
+	if _, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0])); err != domain.ErrUserAlreadyExists {
+		t.Fatalf("Expected ErrUserAlreadyExists, got %v", err)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.Email != "test@example.com" {
+		t.Errorf("Expected the email to be unchanged, got %q", user.Email)
+	}
+}
+
+func TestEmailChangeUseCase_RevertEmailChange(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "new@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0])); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reverted, err := f.uc.RevertEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[1]))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reverted.Email != "test@example.com" || reverted.PendingEmail != "" || !reverted.IsEmailVerified() {
+		t.Errorf("Expected the verified old address, got %+v", reverted)
+	}
+	if _, err := f.userRepo.GetByEmail(ctx, "new@example.com"); err != domain.ErrUserNotFound {
+		t.Errorf("Expected the new address to be released, got %v", err)
+	}
+	if _, revoked := f.revocations.users[f.userID]; !revoked {
+		t.Error("Expected every session of the user to be revoked")
+	}
+
+	if _, err := f.uc.RevertEmailChange(ctx, "bogus"); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected ErrInvalidEmailChangeToken for a bogus link, got %v", err)
+	}
+}
+
+func TestEmailChangeUseCase_RevertEmailChange_CancelsPendingChange(t *testing.T) {
+	f := newTestEmailChangeUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.RequestEmailChange(ctx, f.userID, "password123", "new@example.com"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := f.uc.RevertEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[1])); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := f.uc.ConfirmEmailChange(ctx, tokenFromEmail(t, f.mailer.sent[0])); err != domain.ErrInvalidEmailChangeToken {
+		t.Errorf("Expected the confirmation link to stop working, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// EmailVerificationConfig holds the settings used by EmailVerificationUseCase
+type EmailVerificationConfig struct {
+	// VerifyURL is the link users open from the email; the token is added
+	// as the "token" query parameter
+	VerifyURL string
+	// ResendInterval is the minimum time between two verification emails
+	// to the same user
+	ResendInterval time.Duration
+	// TokenTTL is how long a verification link works, as signed into the
+	// token. It is only used to tell the user.
+	TokenTTL time.Duration
+}
+
+// EmailVerificationUseCase implements domain.EmailVerificationService with
+// signed links sent by email
+type EmailVerificationUseCase struct {
+	userRepo    domain.UserRepository
+	authService domain.AuthService
+	mailer      domain.Mailer
+	config      EmailVerificationConfig
+	now         func() time.Time
+}
+
+// NewEmailVerificationUseCase creates a new EmailVerificationUseCase instance
+func NewEmailVerificationUseCase(
+	userRepo domain.UserRepository,
+	authService domain.AuthService,
+	mailer domain.Mailer,
+	config EmailVerificationConfig,
+) domain.EmailVerificationService {
+	return &EmailVerificationUseCase{
+		userRepo:    userRepo,
+		authService: authService,
+		mailer:      mailer,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// SendVerification emails a verification link to the user, at most once per
+// resend interval. Users who are already verified get nothing.
+func (uc *EmailVerificationUseCase) SendVerification(ctx context.Context, userID int) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+	return uc.send(ctx, user)
+}
+
+// ResendVerification emails a new link to the unverified account using the
+// email, if any. Nothing is reported, so callers cannot tell whether the
+// email is registered or verified, or whether the email was throttled.
+func (uc *EmailVerificationUseCase) ResendVerification(ctx context.Context, email string) error {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.IsEmailVerified() {
+		return nil
+	}
+	if err := uc.send(ctx, user); err != nil && err != domain.ErrVerificationThrottled {
+		return err
+	}
+	return nil
+}
+
+// VerifyEmail marks the user's email address as verified. The link stops
+// working if the user has changed email address since it was sent.
+// Verifying twice is not an error.
+func (uc *EmailVerificationUseCase) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	userID, email, err := uc.authService.ValidateEmailVerificationToken(token)
+	if err != nil {
+		return nil, domain.ErrInvalidVerificationToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || domain.EmailKey(user.Email) != domain.EmailKey(email) {
+		return nil, domain.ErrInvalidVerificationToken
+	}
+
+	if !user.IsEmailVerified() {
+		now := uc.now()
+		user.EmailVerifiedAt = &now
+		user.UpdatedAt = now
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, domain.ErrUserUpdateError
+		}
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// send records the email as sent and sends it, unless the previous one was
+// sent less than the resend interval ago
+func (uc *EmailVerificationUseCase) send(ctx context.Context, user *domain.User) error {
+	now := uc.now()
+	recorded, err := uc.userRepo.RecordVerificationSent(ctx, user.ID, now, now.Add(-uc.config.ResendInterval))
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return domain.ErrVerificationThrottled
+	}
+
+	token, err := uc.authService.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return domain.ErrTokenGenerationError
+	}
+
+	return uc.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nPlease confirm that this is your email address by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.FirstName, addTokenToURL(uc.config.VerifyURL, token), uc.config.TokenTTL,
+		),
+	})
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+type emailVerificationFixture struct {
+	uc       *EmailVerificationUseCase
+	userRepo *MockUserRepository
+	mailer   *MockMailer
+	userID   int
+	clock    *fakeClock
+}
+
+// newTestEmailVerificationUseCase creates an email verification use case with
+// a registered, unverified user, running on the fixture's clock
+func newTestEmailVerificationUseCase(t *testing.T) *emailVerificationFixture {
+	t.Helper()
+
+	userRepo := NewMockUserRepository()
+	authService := NewMockAuthService()
+	userID := registerTestUser(t, userRepo)
+
+	f := &emailVerificationFixture{
+		userRepo: userRepo,
+		mailer:   &MockMailer{},
+		userID:   userID,
+		clock:    newFakeClock(),
+	}
+	f.uc = NewEmailVerificationUseCase(userRepo, authService, f.mailer, EmailVerificationConfig{
+		VerifyURL:      "https://app.example.com/verify-email",
+		ResendInterval: time.Minute,
+		TokenTTL:       24 * time.Hour,
+	}).(*EmailVerificationUseCase)
+	f.uc.now = f.clock.Now
+	return f
+}
+
+func TestEmailVerificationUseCase_SendVerification(t *testing.T) {
+	f := newTestEmailVerificationUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.SendVerification(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.mailer.sent) != 1 || f.mailer.sent[0].To != "test@example.com" {
+		t.Fatalf("Expected one email to test@example.com, got %+v", f.mailer.sent)
+	}
+	if token := tokenFromEmail(t, f.mailer.sent[0]); token != "verify_1_test@example.com" {
+		t.Errorf("Expected the verification token in the link, got %q", token)
+	}
+
+	// Throttled until the resend interval has passed
+	if err := f.uc.SendVerification(ctx, f.userID); err != domain.ErrVerificationThrottled {
+		t.Errorf("Expected ErrVerificationThrottled, got %v", err)
+	}
+	f.clock.Advance(time.Minute)
+	if err := f.uc.SendVerification(ctx, f.userID); err != nil {
+		t.Errorf("Expected no error after the resend interval, got %v", err)
+	}
+	if len(f.mailer.sent) != 2 {
+		t.Errorf("Expected 2 emails, got %d", len(f.mailer.sent))
+	}
+}
+
+func TestEmailVerificationUseCase_ResendVerification(t *testing.T) {
+	f := newTestEmailVerificationUseCase(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"test@example.com", "test@example.com", "unknown@example.com"} {
+		if err := f.uc.ResendVerification(ctx, email); err != nil {
+			t.Fatalf("Expected resending to %s not to report anything, got %v", email, err)
+		}
+	}
+	if len(f.mailer.sent) != 1 {
+		t.Errorf("Expected only the first email to be sent, got %d", len(f.mailer.sent))
+	}
+}
+
+func TestEmailVerificationUseCase_VerifyEmail(t *testing.T) {
+	f := newTestEmailVerificationUseCase(t)
+	ctx := context.Background()
+
+	user, err := f.uc.VerifyEmail(ctx, "verify_1_test@example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.EmailVerifiedAt == nil || !user.EmailVerifiedAt.Equal(f.clock.Now()) {
+		t.Errorf("Expected email to be verified at %v, got %v", f.clock.Now(), user.EmailVerifiedAt)
+	}
+	if user.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+
+	// Verifying again keeps the original time, and verified users get no more emails
+	f.clock.Advance(time.Hour)
+	again, err := f.uc.VerifyEmail(ctx, "verify_1_test@example.com")
+	if err != nil {
+		t.Fatalf("Expected verifying twice not to fail, got %v", err)
+	}
+	if !again.EmailVerifiedAt.Equal(*user.EmailVerifiedAt) {
+		t.Errorf("Expected verification time to be kept, got %v", again.EmailVerifiedAt)
+	}
+	if err := f.uc.SendVerification(ctx, f.userID); err != nil || len(f.mailer.sent) != 0 {
+		t.Errorf("Expected no email for a verified user, got %v and %d emails", err, len(f.mailer.sent))
+	}
+}
+
+func TestEmailVerificationUseCase_VerifyEmail_InvalidToken(t *testing.T) {
+	f := newTestEmailVerificationUseCase(t)
+	ctx := context.Background()
+
+	tests := map[string]string{
+		"malformed":     "bogus",
+		"unknown user":  "verify_999_test@example.com",
+		"changed email": "verify_1_old@example.com",
+	}
+	for name, token := range tests {
+		if _, err := f.uc.VerifyEmail(ctx, token); err != domain.ErrInvalidVerificationToken {
+			t.Errorf("%s: expected ErrInvalidVerificationToken, got %v", name, err)
+		}
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.IsEmailVerified() {
+		t.Error("Expected email to stay unverified")
+	}
+}
+
+func TestUserUseCase_Login_RequireVerifiedEmail(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{RequireVerifiedEmail: true})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	if _, err := userService.Login(ctx, "test@example.com", "wrong"); err != domain.ErrInvalidCredentials {
+		t.Errorf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+	if _, err := userService.Login(ctx, "test@example.com", "password123"); err != domain.ErrEmailNotVerified {
+		t.Fatalf("Expected ErrEmailNotVerified, got %v", err)
+	}
+
+	verifiedAt := time.Now()
+	stored, _ := userRepo.GetByID(ctx, user.ID)
+	stored.EmailVerifiedAt = &verifiedAt
+	if _, err := userService.Login(ctx, "test@example.com", "password123"); err != nil {
+		t.Errorf("Expected a verified user to log in, got %v", err)
+	}
+}
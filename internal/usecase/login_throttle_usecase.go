@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// LoginThrottleConfig holds the settings used by LoginThrottleUseCase
+type LoginThrottleConfig struct {
+	// MaxFailures is how many failed logins an account allows before it is locked
+	MaxFailures int
+	// IPMaxFailures is how many failed logins a client IP address allows
+	// before it is locked, across all accounts. Zero disables IP locking.
+	IPMaxFailures int
+	// LockoutDuration is how long the first lock lasts. Every further
+	// failure after the lock doubles it, up to MaxLockoutDuration.
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	// FailureWindow is how long failures are remembered after the last one
+	FailureWindow time.Duration
+	// MFAChallengeMaxFailures is how many wrong codes an MFA challenge
+	// allows before it stops working, so the password must be entered
+	// again. Wrong codes also count towards locking the user's second
+	// factor after MaxFailures. Zero disables the limit per challenge.
+	MFAChallengeMaxFailures int
+}
+
+// Keys the failed logins are counted under
+const (
+	accountAttemptKeyPrefix      = "account:"
+	ipAttemptKeyPrefix           = "ip:"
+	mfaAttemptKeyPrefix          = "mfa:"
+	mfaChallengeAttemptKeyPrefix = "mfa_challenge:"
+)
+
+// LoginThrottleUseCase implements domain.LoginThrottleService with a
+// lockout that grows exponentially with the number of failures
+type LoginThrottleUseCase struct {
+	attemptRepo domain.LoginAttemptRepository
+	userRepo    domain.UserRepository
+	config      LoginThrottleConfig
+	now         func() time.Time
+}
+
+// NewLoginThrottleUseCase creates a new LoginThrottleUseCase instance
+func NewLoginThrottleUseCase(attemptRepo domain.LoginAttemptRepository, userRepo domain.UserRepository, config LoginThrottleConfig) domain.LoginThrottleService {
+	return &LoginThrottleUseCase{
+		attemptRepo: attemptRepo,
+		userRepo:    userRepo,
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+// Check refuses logins for a locked account, then from a locked IP address
+func (uc *LoginThrottleUseCase) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := uc.now()
+
+	attempts, err := uc.attemptRepo.Get(ctx, accountAttemptKey(email))
+	if err != nil {
+		return 0, err
+	}
+	if attempts != nil && attempts.IsLocked(now) {
+		return attempts.LockedUntil.Sub(now), domain.ErrAccountLocked
+	}
+
+	if !uc.throttlesIP(ip) {
+		return 0, nil
+	}
+	attempts, err = uc.attemptRepo.Get(ctx, ipAttemptKeyPrefix+ip)
+	if err != nil {
+		return 0, err
+	}
+	if attempts != nil && attempts.IsLocked(now) {
+		return attempts.LockedUntil.Sub(now), domain.ErrTooManyLoginAttempts
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failed login for the account and the IP address.
+// Unknown emails are counted too, so a lock does not reveal whether an
+// account exists.
+func (uc *LoginThrottleUseCase) RecordFailure(ctx context.Context, email, ip string) error {
+	if err := uc.recordFailure(ctx, accountAttemptKey(email), uc.config.MaxFailures); err != nil {
+		return err
+	}
+	if uc.throttlesIP(ip) {
+		return uc.recordFailure(ctx, ipAttemptKeyPrefix+ip, uc.config.IPMaxFailures)
+	}
+	return nil
+}
+
+// RecordSuccess forgets the failed logins of the account. Those of the IP
+// address are kept, or an attacker could clear them by logging in to an
+// account of their own between guesses.
+func (uc *LoginThrottleUseCase) RecordSuccess(ctx context.Context, email, ip string) error {
+	return uc.attemptRepo.Reset(ctx, accountAttemptKey(email))
+}
+
+// CheckMFA refuses codes while the user's second factor is locked, then
+// for an MFA challenge that got too many wrong codes
+func (uc *LoginThrottleUseCase) CheckMFA(ctx context.Context, userID int, challengeID string) (time.Duration, error) {
+	now := uc.now()
+
+	attempts, err := uc.attemptRepo.Get(ctx, mfaAttemptKey(userID))
+	if err != nil {
+		return 0, err
+	}
+	if attempts != nil && attempts.IsLocked(now) {
+		return attempts.LockedUntil.Sub(now), domain.ErrMFALocked
+	}
+
+	if challengeID == "" {
+		return 0, nil
+	}
+	attempts, err = uc.attemptRepo.Get(ctx, mfaChallengeAttemptKeyPrefix+challengeID)
+	if err != nil {
+		return 0, err
+	}
+	if attempts != nil && attempts.IsLocked(now) {
+		return 0, domain.ErrInvalidMFAToken
+	}
+	return 0, nil
+}
+
+// RecordMFAFailure counts a wrong code for the user and the MFA challenge.
+// A challenge stays locked for the failure window, which outlasts it.
+func (uc *LoginThrottleUseCase) RecordMFAFailure(ctx context.Context, userID int, challengeID string) error {
+	if err := uc.recordFailure(ctx, mfaAttemptKey(userID), uc.config.MaxFailures); err != nil {
+		return err
+	}
+	if challengeID == "" || uc.config.MFAChallengeMaxFailures <= 0 {
+		return nil
+	}
+
+	now := uc.now()
+	key := mfaChallengeAttemptKeyPrefix + challengeID
+	attempts, err := uc.attemptRepo.RecordFailure(ctx, key, now, now.Add(-uc.config.FailureWindow))
+	if err != nil {
+		return err
+	}
+	if attempts.Failures < uc.config.MFAChallengeMaxFailures {
+		return nil
+	}
+	return uc.attemptRepo.Lock(ctx, key, now.Add(uc.config.FailureWindow))
+}
+
+// RecordMFASuccess forgets the wrong codes of the user
+func (uc *LoginThrottleUseCase) RecordMFASuccess(ctx context.Context, userID int) error {
+	return uc.attemptRepo.Reset(ctx, mfaAttemptKey(userID))
+}
+
+// Unlock forgets the failed logins of the user's account and the wrong
+// codes of their second factor
+func (uc *LoginThrottleUseCase) Unlock(ctx context.Context, userID int) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	if err := uc.attemptRepo.Reset(ctx, mfaAttemptKey(userID)); err != nil {
+		return err
+	}
+	return uc.attemptRepo.Reset(ctx, accountAttemptKey(user.Email))
+}
+
+// PruneStale forgets failures outside the failure window once any lock has ended
+func (uc *LoginThrottleUseCase) PruneStale(ctx context.Context) (int, error) {
+	return uc.attemptRepo.DeleteStale(ctx, uc.now().Add(-uc.config.FailureWindow))
+}
+
+// recordFailure counts a failure for the key and locks it once the failures reach max
+func (uc *LoginThrottleUseCase) recordFailure(ctx context.Context, key string, max int) error {
+	now := uc.now()
+	attempts, err := uc.attemptRepo.RecordFailure(ctx, key, now, now.Add(-uc.config.FailureWindow))
+	if err != nil {
+		return err
+	}
+	if max <= 0 || attempts.Failures < max {
+		return nil
+	}
+	return uc.attemptRepo.Lock(ctx, key, now.Add(uc.lockoutDuration(attempts.Failures-max)))
+}
+
+// lockoutDuration doubles the lockout for every failure beyond the limit
+func (uc *LoginThrottleUseCase) lockoutDuration(extraFailures int) time.Duration {
+	duration := uc.config.LockoutDuration
+	for i := 0; i < extraFailures && duration < uc.config.MaxLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > uc.config.MaxLockoutDuration {
+		duration = uc.config.MaxLockoutDuration
+	}
+	return duration
+}
+
+// throttlesIP reports whether failures from the IP address are counted
+func (uc *LoginThrottleUseCase) throttlesIP(ip string) bool {
+	return uc.config.IPMaxFailures > 0 && ip != ""
+}
+
+// mfaAttemptKey counts wrong second factor codes under the user's ID
+func mfaAttemptKey(userID int) string {
+	return mfaAttemptKeyPrefix + strconv.Itoa(userID)
+}
+
+// accountAttemptKey counts failures under the email as users type it, so
+// that differences in case do not give an attacker extra guesses
+func accountAttemptKey(email string) string {
+	return accountAttemptKeyPrefix + domain.EmailKey(email)
+}
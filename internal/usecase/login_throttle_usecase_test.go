@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MockLoginAttemptRepository implements domain.LoginAttemptRepository for testing
+type MockLoginAttemptRepository struct {
+	attempts map[string]*domain.LoginAttempts
+}
+
+func NewMockLoginAttemptRepository() *MockLoginAttemptRepository {
+	return &MockLoginAttemptRepository{attempts: make(map[string]*domain.LoginAttempts)}
+}
+
+func (m *MockLoginAttemptRepository) Get(ctx context.Context, key string) (*domain.LoginAttempts, error) {
+	attempts, exists := m.attempts[key]
+	if !exists {
+		return nil, nil
+	}
+	copied := *attempts
+	return &copied, nil
+}
+
+func (m *MockLoginAttemptRepository) RecordFailure(ctx context.Context, key string, failedAt, resetBefore time.Time) (*domain.LoginAttempts, error) {
+	attempts, exists := m.attempts[key]
+	if !exists || attempts.LastFailedAt.Before(resetBefore) {
+		attempts = &domain.LoginAttempts{Key: key}
+		m.attempts[key] = attempts
+	}
+	attempts.Failures++
+	attempts.LastFailedAt = failedAt
+	return m.Get(ctx, key)
+}
+
+func (m *MockLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	if attempts, exists := m.attempts[key]; exists {
+		attempts.LockedUntil = &until
+	}
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *MockLoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	for key, attempts := range m.attempts {
+		if attempts.LastFailedAt.Before(before) && (attempts.LockedUntil == nil || attempts.LockedUntil.Before(before)) {
+			delete(m.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// newTestLoginThrottleUseCase creates a login throttle allowing 3 failures per
+// account and 5 per IP, running on the returned clock
+func newTestLoginThrottleUseCase(t *testing.T, userRepo *MockUserRepository) (*LoginThrottleUseCase, *MockLoginAttemptRepository, *fakeClock) {
+	t.Helper()
+
+	attemptRepo := NewMockLoginAttemptRepository()
+	uc := NewLoginThrottleUseCase(attemptRepo, userRepo, LoginThrottleConfig{
+		MaxFailures:        3,
+		IPMaxFailures:      5,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: 10 * time.Minute,
+		FailureWindow:      time.Hour,
+	}).(*LoginThrottleUseCase)
+	clock := newFakeClock()
+	uc.now = clock.Now
+	return uc, attemptRepo, clock
+}
+
+func TestLoginThrottleUseCase_LocksAccount(t *testing.T) {
+	uc, _, clock := newTestLoginThrottleUseCase(t, NewMockUserRepository())
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := uc.Check(ctx, "test@example.com", ""); err != nil {
+			t.Fatalf("Expected attempt %d to be allowed, got %v", i+1, err)
+		}
+		if err := uc.RecordFailure(ctx, "test@example.com", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// The email is matched whatever its case
+	wait, err := uc.Check(ctx, "Test@Example.com", "")
+	if err != domain.ErrAccountLocked {
+		t.Fatalf("Expected ErrAccountLocked, got %v", err)
+	}
+	if wait != time.Minute {
+		t.Errorf("Expected to wait 1m, got %v", wait)
+	}
+	if _, err := uc.Check(ctx, "other@example.com", ""); err != nil {
+		t.Errorf("Expected other accounts not to be locked, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := uc.Check(ctx, "test@example.com", ""); err != nil {
+		t.Errorf("Expected the lock to end, got %v", err)
+	}
+}
+
+func TestLoginThrottleUseCase_ExponentialLockout(t *testing.T) {
+	uc, _, clock := newTestLoginThrottleUseCase(t, NewMockUserRepository())
+	ctx := context.Background()
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i := 0; i < 2; i++ {
+		uc.RecordFailure(ctx, "test@example.com", "")
+	}
+	for _, lockout := range expected {
+		if err := uc.RecordFailure(ctx, "test@example.com", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		wait, err := uc.Check(ctx, "test@example.com", "")
+		if err != domain.ErrAccountLocked || wait != lockout {
+			t.Errorf("Expected a %v lockout, got %v and %v", lockout, wait, err)
+		}
+		clock.Advance(wait)
+	}
+}
+
+func TestLoginThrottleUseCase_LocksIP(t *testing.T) {
+	uc, _, _ := newTestLoginThrottleUseCase(t, NewMockUserRepository())
+	ctx := context.Background()
+
+	// Spraying one guess at many accounts locks the IP, not the accounts
+	for i := 0; i < 5; i++ {
+		email := string(rune('a'+i)) + "@example.com"
+		if err := uc.RecordFailure(ctx, email, "192.0.2.1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if _, err := uc.Check(ctx, "z@example.com", "192.0.2.1"); err != domain.ErrTooManyLoginAttempts {
+		t.Errorf("Expected ErrTooManyLoginAttempts, got %v", err)
+	}
+	if _, err := uc.Check(ctx, "z@example.com", "192.0.2.2"); err != nil {
+		t.Errorf("Expected other IPs not to be locked, got %v", err)
+	}
+}
+
+func TestLoginThrottleUseCase_RecordSuccess(t *testing.T) {
+	uc, attemptRepo, _ := newTestLoginThrottleUseCase(t, NewMockUserRepository())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		uc.RecordFailure(ctx, "test@example.com", "192.0.2.1")
+	}
+	if err := uc.RecordSuccess(ctx, "test@example.com", "192.0.2.1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, exists := attemptRepo.attempts["account:test@example.com"]; exists {
+		t.Error("Expected the account's failures to be forgotten")
+	}
+	if attempts := attemptRepo.attempts["ip:192.0.2.1"]; attempts == nil || attempts.Failures != 2 {
+		t.Errorf("Expected the IP's failures to be kept, got %+v", attempts)
+	}
+}
+
+func TestLoginThrottleUseCase_Unlock(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	uc, _, _ := newTestLoginThrottleUseCase(t, userRepo)
+	ctx := context.Background()
+
+	userID := registerTestUser(t, userRepo)
+	for i := 0; i < 3; i++ {
+		uc.RecordFailure(ctx, "test@example.com", "")
+	}
+
+	if err := uc.Unlock(ctx, userID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := uc.Check(ctx, "test@example.com", ""); err != nil {
+		t.Errorf("Expected the account to be unlocked, got %v", err)
+	}
+	if err := uc.Unlock(ctx, 999); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestLoginThrottleUseCase_PruneStale(t *testing.T) {
+	uc, attemptRepo, clock := newTestLoginThrottleUseCase(t, NewMockUserRepository())
+	ctx := context.Background()
+
+	uc.RecordFailure(ctx, "old@example.com", "")
+	clock.Advance(2 * time.Hour)
+	uc.RecordFailure(ctx, "new@example.com", "")
+
+	pruned, err := uc.PruneStale(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if pruned != 1 || len(attemptRepo.attempts) != 1 {
+		t.Errorf("Expected only the old failures to be pruned, got %d pruned and %d left", pruned, len(attemptRepo.attempts))
+	}
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// Recovery code format: recoveryCodeCount codes of recoveryCodeLength
+// characters, shown in two dash-separated halves. The alphabet leaves out
+// characters that are easily confused, such as 0/o and 1/l.
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// MFAUseCase implements domain.MFAService with TOTP codes and single-use recovery codes
+type MFAUseCase struct {
+	userRepo        domain.UserRepository
+	mfaRepo         domain.MFARepository
+	authService     domain.AuthService
+	totp            domain.TOTPProvider
+	revocationStore domain.TokenRevocationStore
+	throttle        domain.LoginThrottleService
+	now             func() time.Time
+}
+
+// NewMFAUseCase creates a new MFAUseCase instance. Used MFA challenges are
+// kept in the revocation store and wrong codes are counted by the throttle.
+func NewMFAUseCase(userRepo domain.UserRepository, mfaRepo domain.MFARepository, authService domain.AuthService, totp domain.TOTPProvider, revocationStore domain.TokenRevocationStore, throttle domain.LoginThrottleService) domain.MFAService {
+	return &MFAUseCase{
+		userRepo:        userRepo,
+		mfaRepo:         mfaRepo,
+		authService:     authService,
+		totp:            totp,
+		revocationStore: revocationStore,
+		throttle:        throttle,
+		now:             time.Now,
+	}
+}
+
+// GetStatus describes the user's two-factor authentication
+func (uc *MFAUseCase) GetStatus(ctx context.Context, userID int) (*domain.MFAStatus, error) {
+	enrollment, err := uc.mfaRepo.GetEnrollment(ctx, userID)
+	if err == domain.ErrMFANotEnrolled {
+		return &domain.MFAStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !enrollment.IsConfirmed() {
+		return &domain.MFAStatus{}, nil
+	}
+
+	left, err := uc.mfaRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.MFAStatus{Enabled: true, EnabledAt: enrollment.ConfirmedAt, RecoveryCodesLeft: left}, nil
+}
+
+// BeginEnrollment creates a pending enrollment with a new secret. Starting
+// over replaces a pending enrollment that was never confirmed.
+func (uc *MFAUseCase) BeginEnrollment(ctx context.Context, userID int) (*domain.MFASetup, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	enrollment, err := uc.mfaRepo.GetEnrollment(ctx, userID)
+	if err != nil && err != domain.ErrMFANotEnrolled {
+		return nil, err
+	}
+	if err == nil && enrollment.IsConfirmed() {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := uc.totp.GenerateSecret()
+	if err != nil {
+		return nil, domain.ErrMFASetupError
+	}
+	uri := uc.totp.ProvisioningURI(secret, user.Email)
+	qrCode, err := uc.totp.QRCode(uri)
+	if err != nil {
+		return nil, domain.ErrMFASetupError
+	}
+
+	if err := uc.mfaRepo.SaveEnrollment(ctx, &domain.MFAEnrollment{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: uc.now(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &domain.MFASetup{Secret: secret, ProvisioningURI: uri, QRCode: qrCode}, nil
+}
+
+// ConfirmEnrollment enables two-factor authentication with the first valid
+// code from the authenticator and returns the recovery codes
+func (uc *MFAUseCase) ConfirmEnrollment(ctx context.Context, userID int, code string) ([]string, error) {
+	enrollment, err := uc.mfaRepo.GetEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enrollment.IsConfirmed() {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	now := uc.now()
+	step, ok := uc.totp.Verify(enrollment.Secret, code, now)
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, domain.ErrMFASetupError
+	}
+	if err := uc.mfaRepo.ConfirmEnrollment(ctx, userID, now, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a code
+func (uc *MFAUseCase) Disable(ctx context.Context, userID int, code string) error {
+	enrollment, err := uc.confirmedEnrollment(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkCode(ctx, enrollment, code, ""); err != nil {
+		return err
+	}
+	return uc.mfaRepo.DeleteEnrollment(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a code
+func (uc *MFAUseCase) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	enrollment, err := uc.confirmedEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkCode(ctx, enrollment, code, ""); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, domain.ErrMFASetupError
+	}
+	if err := uc.mfaRepo.ReplaceRecoveryCodes(ctx, userID, hashes, uc.now()); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// CompleteLogin exchanges an MFA challenge and a code for an access token.
+// A challenge works only once.
+func (uc *MFAUseCase) CompleteLogin(ctx context.Context, challenge, code string) (string, *domain.User, error) {
+	claims, err := uc.authService.ValidateMFAChallenge(challenge)
+	if err != nil {
+		return "", nil, domain.ErrInvalidMFAToken
+	}
+	revoked, err := uc.revocationStore.IsRevoked(ctx, claims)
+	if err != nil {
+		return "", nil, err
+	}
+	if revoked {
+		return "", nil, domain.ErrInvalidMFAToken
+	}
+	userID := claims.UserID
+
+	// The challenge is useless once the user has disabled two-factor
+	// authentication or been deleted in the meantime
+	enrollment, err := uc.confirmedEnrollment(ctx, userID)
+	if err == domain.ErrMFANotEnrolled {
+		return "", nil, domain.ErrInvalidMFAToken
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", nil, domain.ErrInvalidMFAToken
+	}
+
+	if err := uc.checkCode(ctx, enrollment, code, claims.TokenID); err != nil {
+		return "", nil, err
+	}
+	if err := uc.revocationStore.RevokeToken(ctx, claims.TokenID, userID, claims.ExpiresAt); err != nil {
+		return "", nil, err
+	}
+
+	token, err := uc.authService.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		return "", nil, domain.ErrTokenGenerationError
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return token, &responseUser, nil
+}
+
+// confirmedEnrollment returns the user's enrollment if it is enabled
+func (uc *MFAUseCase) confirmedEnrollment(ctx context.Context, userID int) (*domain.MFAEnrollment, error) {
+	enrollment, err := uc.mfaRepo.GetEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrollment.IsConfirmed() {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	return enrollment, nil
+}
+
+// checkCode verifies a code unless the user's second factor or the MFA
+// challenge is locked, and counts wrong codes towards locking them
+func (uc *MFAUseCase) checkCode(ctx context.Context, enrollment *domain.MFAEnrollment, code, challengeID string) error {
+	if _, err := uc.throttle.CheckMFA(ctx, enrollment.UserID, challengeID); err != nil {
+		return err
+	}
+
+	err := uc.verifyCode(ctx, enrollment, code)
+	if err == domain.ErrInvalidMFACode {
+		if recordErr := uc.throttle.RecordMFAFailure(ctx, enrollment.UserID, challengeID); recordErr != nil {
+			return recordErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return uc.throttle.RecordMFASuccess(ctx, enrollment.UserID)
+}
+
+// verifyCode accepts a TOTP code that was not used before, or an unused
+// recovery code, which is then used up
+func (uc *MFAUseCase) verifyCode(ctx context.Context, enrollment *domain.MFAEnrollment, code string) error {
+	now := uc.now()
+	if step, ok := uc.totp.Verify(enrollment.Secret, code, now); ok {
+		fresh, err := uc.mfaRepo.UseStep(ctx, enrollment.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return domain.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return domain.ErrInvalidMFACode
+	}
+	used, err := uc.mfaRepo.UseRecoveryCode(ctx, enrollment.UserID, hashToken(normalized), now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns fresh recovery codes as shown to the user and
+// the hashes to store
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		var code strings.Builder
+		for j := 0; j < recoveryCodeLength; j++ {
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, nil, err
+			}
+			code.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+		value := code.String()
+		codes = append(codes, value[:recoveryCodeLength/2]+"-"+value[recoveryCodeLength/2:])
+		hashes = append(hashes, hashToken(value))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces in a recovery code
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
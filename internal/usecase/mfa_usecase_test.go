@@ -0,0 +1,399 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MockMFARepository implements domain.MFARepository for testing
+type MockMFARepository struct {
+	enrollments   map[int]*domain.MFAEnrollment
+	recoveryCodes map[int]map[string]bool
+}
+
+func NewMockMFARepository() *MockMFARepository {
+	return &MockMFARepository{
+		enrollments:   make(map[int]*domain.MFAEnrollment),
+		recoveryCodes: make(map[int]map[string]bool),
+	}
+}
+
+func (m *MockMFARepository) GetEnrollment(ctx context.Context, userID int) (*domain.MFAEnrollment, error) {
+	enrollment, ok := m.enrollments[userID]
+	if !ok {
+		return nil, domain.ErrMFANotEnrolled
+	}
+	copied := *enrollment
+	return &copied, nil
+}
+
+func (m *MockMFARepository) SaveEnrollment(ctx context.Context, enrollment *domain.MFAEnrollment) error {
+	if existing, ok := m.enrollments[enrollment.UserID]; ok && existing.IsConfirmed() {
+		return nil
+	}
+	copied := *enrollment
+	m.enrollments[enrollment.UserID] = &copied
+	return nil
+}
+
+func (m *MockMFARepository) ConfirmEnrollment(ctx context.Context, userID int, confirmedAt time.Time, step int64, recoveryCodeHashes []string) error {
+	enrollment, ok := m.enrollments[userID]
+	if !ok || enrollment.IsConfirmed() {
+		return domain.ErrMFANotEnrolled
+	}
+	enrollment.ConfirmedAt = &confirmedAt
+	enrollment.LastUsedStep = step
+	return m.ReplaceRecoveryCodes(ctx, userID, recoveryCodeHashes, confirmedAt)
+}
+
+func (m *MockMFARepository) DeleteEnrollment(ctx context.Context, userID int) error {
+	delete(m.enrollments, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *MockMFARepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	enrollment, ok := m.enrollments[userID]
+	if !ok || enrollment.LastUsedStep >= step {
+		return false, nil
+	}
+	enrollment.LastUsedStep = step
+	return true, nil
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string, usedAt time.Time) (bool, error) {
+	if unused, ok := m.recoveryCodes[userID][codeHash]; !ok || !unused {
+		return false, nil
+	}
+	m.recoveryCodes[userID][codeHash] = false
+	return true, nil
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string, createdAt time.Time) error {
+	codes := make(map[string]bool)
+	for _, hash := range codeHashes {
+		codes[hash] = true
+	}
+	m.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (m *MockMFARepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	count := 0
+	for _, unused := range m.recoveryCodes[userID] {
+		if unused {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MockTOTPProvider implements domain.TOTPProvider for testing; "123456" is
+// the valid code at any time
+type MockTOTPProvider struct{}
+
+func (m *MockTOTPProvider) GenerateSecret() (string, error) {
+	return "JBSWY3DPEHPK3PXP", nil
+}
+
+func (m *MockTOTPProvider) ProvisioningURI(secret, accountName string) string {
+	return "otpauth://totp/Test:" + accountName + "?secret=" + secret
+}
+
+func (m *MockTOTPProvider) QRCode(content string) ([]byte, error) {
+	return []byte("png"), nil
+}
+
+func (m *MockTOTPProvider) Verify(secret, code string, at time.Time) (int64, bool) {
+	if code != "123456" {
+		return 0, false
+	}
+	return at.Unix() / 30, true
+}
+
+// newTestMFAUseCase creates an MFA use case with a registered user, running on
+// the returned clock
+func newTestMFAUseCase(t *testing.T) (*MFAUseCase, domain.UserService, *MockMFARepository, int, *fakeClock) {
+	t.Helper()
+
+	userRepo := NewMockUserRepository()
+	mfaRepo := NewMockMFARepository()
+	authService := NewMockAuthService()
+	userService := NewUserUseCase(userRepo, mfaRepo, NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
+	userID := registerTestUser(t, userRepo)
+
+	clock := newFakeClock()
+	throttle := NewLoginThrottleUseCase(NewMockLoginAttemptRepository(), userRepo, LoginThrottleConfig{
+		MaxFailures:             5,
+		LockoutDuration:         15 * time.Minute,
+		MaxLockoutDuration:      time.Hour,
+		FailureWindow:           time.Hour,
+		MFAChallengeMaxFailures: 3,
+	}).(*LoginThrottleUseCase)
+	throttle.now = clock.Now
+	uc := NewMFAUseCase(userRepo, mfaRepo, authService, &MockTOTPProvider{}, NewMockTokenRevocationStore(), throttle).(*MFAUseCase)
+	uc.now = clock.Now
+	return uc, userService, mfaRepo, userID, clock
+}
+
+// enableMFA enrolls the user and returns the recovery codes
+func enableMFA(t *testing.T, uc *MFAUseCase, userID int) []string {
+	t.Helper()
+
+	ctx := context.Background()
+	setup, err := uc.BeginEnrollment(ctx, userID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if setup.Secret == "" || !strings.Contains(setup.ProvisioningURI, "test@example.com") || len(setup.QRCode) == 0 {
+		t.Fatalf("Expected secret, provisioning URI and QR code, got %+v", setup)
+	}
+
+	codes, err := uc.ConfirmEnrollment(ctx, userID, "123456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return codes
+}
+
+func TestMFAUseCase_Enrollment(t *testing.T) {
+	uc, _, mfaRepo, userID, _ := newTestMFAUseCase(t)
+	ctx := context.Background()
+
+	if _, err := uc.BeginEnrollment(ctx, userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := uc.ConfirmEnrollment(ctx, userID, "000000"); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+	}
+	status, err := uc.GetStatus(ctx, userID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Enabled {
+		t.Fatal("Expected MFA to stay disabled until confirmed")
+	}
+
+	codes, err := uc.ConfirmEnrollment(ctx, userID, "123456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+	for hash := range mfaRepo.recoveryCodes[userID] {
+		for _, code := range codes {
+			if hash == code || hash == normalizeRecoveryCode(code) {
+				t.Fatal("Expected recovery codes to be stored hashed")
+			}
+		}
+	}
+
+	status, err = uc.GetStatus(ctx, userID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !status.Enabled || status.EnabledAt == nil || status.RecoveryCodesLeft != recoveryCodeCount {
+		t.Fatalf("Expected MFA enabled with %d recovery codes, got %+v", recoveryCodeCount, status)
+	}
+
+	if _, err := uc.BeginEnrollment(ctx, userID); err != domain.ErrMFAAlreadyEnabled {
+		t.Fatalf("Expected ErrMFAAlreadyEnabled, got %v", err)
+	}
+}
+
+func TestMFAUseCase_LoginRequiresSecondFactor(t *testing.T) {
+	uc, userService, _, userID, clock := newTestMFAUseCase(t)
+	ctx := context.Background()
+	enableMFA(t, uc, userID)
+
+	result, err := userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !result.MFARequired() || result.Token != "" {
+		t.Fatalf("Expected an MFA challenge instead of a token, got %+v", result)
+	}
+
+	if _, _, err := uc.CompleteLogin(ctx, "bogus", "123456"); err != domain.ErrInvalidMFAToken {
+		t.Fatalf("Expected ErrInvalidMFAToken, got %v", err)
+	}
+
+	// The code that confirmed the enrollment cannot be replayed
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected ErrInvalidMFACode for a used code, got %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	token, user, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token == "" {
+		t.Fatal("Expected token to be returned")
+	}
+	if user.ID != userID || user.Password != "" {
+		t.Fatalf("Expected user %d without password, got %+v", userID, user)
+	}
+
+	// A challenge works only once, even with a fresh code
+	clock.Advance(30 * time.Second)
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != domain.ErrInvalidMFAToken {
+		t.Fatalf("Expected ErrInvalidMFAToken for a used challenge, got %v", err)
+	}
+}
+
+func TestMFAUseCase_ChallengeStopsWorkingAfterWrongCodes(t *testing.T) {
+	uc, userService, _, userID, clock := newTestMFAUseCase(t)
+	ctx := context.Background()
+	enableMFA(t, uc, userID)
+	clock.Advance(30 * time.Second)
+
+	result, err := userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "000000"); err != domain.ErrInvalidMFACode {
+			t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+		}
+	}
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != domain.ErrInvalidMFAToken {
+		t.Fatalf("Expected ErrInvalidMFAToken after too many wrong codes, got %v", err)
+	}
+
+	// Entering the password again gives a new challenge
+	result, err = userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestMFAUseCase_WrongCodesLockSecondFactor(t *testing.T) {
+	uc, userService, _, userID, clock := newTestMFAUseCase(t)
+	ctx := context.Background()
+	enableMFA(t, uc, userID)
+	clock.Advance(30 * time.Second)
+
+	// Wrong codes count across challenges and the /me/mfa endpoints
+	for i := 0; i < 2; i++ {
+		result, err := userService.Login(ctx, "test@example.com", "password123")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for j := 0; j < 2; j++ {
+			if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "000000"); err != domain.ErrInvalidMFACode {
+				t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+			}
+		}
+	}
+	if err := uc.Disable(ctx, userID, "000000"); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+	}
+
+	if err := uc.Disable(ctx, userID, "123456"); err != domain.ErrMFALocked {
+		t.Fatalf("Expected ErrMFALocked, got %v", err)
+	}
+	if _, err := uc.RegenerateRecoveryCodes(ctx, userID, "123456"); err != domain.ErrMFALocked {
+		t.Fatalf("Expected ErrMFALocked, got %v", err)
+	}
+	result, err := userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != domain.ErrMFALocked {
+		t.Fatalf("Expected ErrMFALocked, got %v", err)
+	}
+
+	clock.Advance(16 * time.Minute)
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != nil {
+		t.Fatalf("Expected the lock to expire, got %v", err)
+	}
+}
+
+func TestMFAUseCase_RecoveryCodesAreSingleUse(t *testing.T) {
+	uc, userService, _, userID, _ := newTestMFAUseCase(t)
+	ctx := context.Background()
+	codes := enableMFA(t, uc, userID)
+
+	challenge := func() string {
+		t.Helper()
+		result, err := userService.Login(ctx, "test@example.com", "password123")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return result.MFAChallenge
+	}
+
+	// Recovery codes are accepted regardless of case and dashes
+	code := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if _, _, err := uc.CompleteLogin(ctx, challenge(), code); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, _, err := uc.CompleteLogin(ctx, challenge(), codes[0]); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected ErrInvalidMFACode for a used recovery code, got %v", err)
+	}
+
+	status, err := uc.GetStatus(ctx, userID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Fatalf("Expected %d recovery codes left, got %d", recoveryCodeCount-1, status.RecoveryCodesLeft)
+	}
+
+	newCodes, err := uc.RegenerateRecoveryCodes(ctx, userID, codes[1])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(newCodes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(newCodes))
+	}
+	if _, _, err := uc.CompleteLogin(ctx, challenge(), codes[2]); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected old recovery codes to be discarded, got %v", err)
+	}
+}
+
+func TestMFAUseCase_Disable(t *testing.T) {
+	uc, userService, _, userID, clock := newTestMFAUseCase(t)
+	ctx := context.Background()
+
+	if err := uc.Disable(ctx, userID, "123456"); err != domain.ErrMFANotEnrolled {
+		t.Fatalf("Expected ErrMFANotEnrolled, got %v", err)
+	}
+
+	enableMFA(t, uc, userID)
+	result, err := userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := uc.Disable(ctx, userID, "000000"); err != domain.ErrInvalidMFACode {
+		t.Fatalf("Expected ErrInvalidMFACode, got %v", err)
+	}
+	clock.Advance(30 * time.Second)
+	if err := uc.Disable(ctx, userID, "123456"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Outstanding challenges are void once MFA is disabled
+	clock.Advance(30 * time.Second)
+	if _, _, err := uc.CompleteLogin(ctx, result.MFAChallenge, "123456"); err != domain.ErrInvalidMFAToken {
+		t.Fatalf("Expected ErrInvalidMFAToken, got %v", err)
+	}
+
+	result, err = userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.MFARequired() || result.Token == "" {
+		t.Fatalf("Expected a token without MFA, got %+v", result)
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// PasswordResetConfig holds the settings used by PasswordResetUseCase
+type PasswordResetConfig struct {
+	// TokenTTL is how long a reset link can be used
+	TokenTTL time.Duration
+	// ResetURL is the page users open from the email; the token is added
+	// as the "token" query parameter
+	ResetURL string
+	// PasswordPolicy is the policy new passwords must follow
+	PasswordPolicy domain.PasswordPolicy
+}
+
+// PasswordResetUseCase implements domain.PasswordResetService with emailed, single-use tokens
+type PasswordResetUseCase struct {
+	userRepo     domain.UserRepository
+	resetRepo    domain.PasswordResetRepository
+	hasher       domain.PasswordHasher
+	tokenService domain.TokenService
+	mailer       domain.Mailer
+	config       PasswordResetConfig
+	now          func() time.Time
+}
+
+// NewPasswordResetUseCase creates a new PasswordResetUseCase instance
+func NewPasswordResetUseCase(
+	userRepo domain.UserRepository,
+	resetRepo domain.PasswordResetRepository,
+	hasher domain.PasswordHasher,
+	tokenService domain.TokenService,
+	mailer domain.Mailer,
+	config PasswordResetConfig,
+) domain.PasswordResetService {
+	return &PasswordResetUseCase{
+		userRepo:     userRepo,
+		resetRepo:    resetRepo,
+		hasher:       hasher,
+		tokenService: tokenService,
+		mailer:       mailer,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+// RequestReset emails a reset link to the account using the email, if any.
+// An unknown email is not an error, so the response is the same either way.
+func (uc *PasswordResetUseCase) RequestReset(ctx context.Context, email string) error {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	value, err := randomToken(32)
+	if err != nil {
+		return domain.ErrTokenGenerationError
+	}
+
+	now := uc.now()
+	token := &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(value),
+		ExpiresAt: now.Add(uc.config.TokenTTL),
+		CreatedAt: now,
+	}
+	if err := uc.resetRepo.Create(ctx, token); err != nil {
+		return domain.ErrTokenGenerationError
+	}
+
+	return uc.mailer.Send(ctx, domain.EmailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nSomeone asked to reset the password of your account. Open the link below to choose a new one:\n\n%s\n\nThe link expires in %s and can only be used once. If you did not ask for this, you can ignore this email.\n",
+			user.FirstName, addTokenToURL(uc.config.ResetURL, value), uc.config.TokenTTL,
+		),
+	})
+}
+
+// ResetPassword sets a new password with a reset token. The token is claimed
+// before the password is saved, so that it works only once even for
+// concurrent requests, and released again if saving fails. Every other
+// reset token of the user stops working, and every session is revoked.
+func (uc *PasswordResetUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	stored, err := uc.resetRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+
+	now := uc.now()
+	if stored.UsedAt != nil || stored.IsExpired(now) {
+		return domain.ErrInvalidResetToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return domain.ErrInvalidResetToken
+	}
+
+	if err := uc.config.PasswordPolicy.Validate(newPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return err
+	}
+
+	hashedPassword, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return domain.ErrPasswordHashError
+	}
+
+	claimed, err := uc.resetRepo.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return domain.ErrInvalidResetToken
+	}
+	if _, err := uc.userRepo.UpdatePassword(ctx, user.ID, hashedPassword, now); err != nil {
+		// Let the user try again with the same link
+		if releaseErr := uc.resetRepo.MarkUnused(ctx, stored.ID); releaseErr != nil {
+			return releaseErr
+		}
+		return domain.ErrUserUpdateError
+	}
+	if err := uc.resetRepo.InvalidateAllForUser(ctx, user.ID, now); err != nil {
+		return err
+	}
+	return uc.tokenService.LogoutAll(ctx, user.ID)
+}
+
+// addTokenToURL adds the token to a page URL as the "token" query parameter
+func addTokenToURL(pageURL, token string) string {
+	link, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
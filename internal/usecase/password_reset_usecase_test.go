@@ -0,0 +1,364 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MockPasswordResetRepository implements domain.PasswordResetRepository for
+// testing; it is safe for concurrent use, like the real repositories
+type MockPasswordResetRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*domain.PasswordResetToken
+	nextID int
+}
+
+func NewMockPasswordResetRepository() *MockPasswordResetRepository {
+	return &MockPasswordResetRepository{
+		tokens: make(map[string]*domain.PasswordResetToken),
+		nextID: 1,
+	}
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token.ID = m.nextID
+	m.nextID++
+	copied := *token
+	m.tokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (m *MockPasswordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, exists := m.tokens[tokenHash]
+	if !exists {
+		return nil, domain.ErrInvalidResetToken
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (m *MockPasswordResetRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.ID == id {
+			if token.UsedAt != nil {
+				return false, nil
+			}
+			token.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockPasswordResetRepository) MarkUnused(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.UsedAt = nil
+		}
+	}
+	return nil
+}
+
+func (m *MockPasswordResetRepository) InvalidateAllForUser(ctx context.Context, userID int, usedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// MockMailer implements domain.Mailer for testing and keeps the sent emails
+type MockMailer struct {
+	sent []domain.EmailMessage
+}
+
+func (m *MockMailer) Send(ctx context.Context, message domain.EmailMessage) error {
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+// linkPattern finds the link in an email
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+// tokenFromEmail extracts the token from the link in an email
+func tokenFromEmail(t *testing.T, message domain.EmailMessage) string {
+	t.Helper()
+
+	link, err := url.Parse(linkPattern.FindString(message.Body))
+	if err != nil {
+		t.Fatalf("Expected a link in the email, got %q", message.Body)
+	}
+	token := link.Query().Get("token")
+	if token == "" {
+		t.Fatalf("Expected a token in the link, got %q", link)
+	}
+	return token
+}
+
+type passwordResetFixture struct {
+	uc          *PasswordResetUseCase
+	userRepo    *MockUserRepository
+	resetRepo   *MockPasswordResetRepository
+	revocations *MockTokenRevocationStore
+	mailer      *MockMailer
+	userID      int
+	clock       *fakeClock
+}
+
+// newTestPasswordResetUseCase creates a password reset use case with a
+// registered user, running on the fixture's clock
+func newTestPasswordResetUseCase(t *testing.T) *passwordResetFixture {
+	t.Helper()
+
+	userRepo := NewMockUserRepository()
+	authService := NewMockAuthService()
+	userID := registerTestUser(t, userRepo)
+
+	revocations := NewMockTokenRevocationStore()
+	tokenService := NewTokenUseCase(userRepo, NewMockRefreshTokenRepository(), revocations, authService, TokenConfig{
+		AccessTTL:  time.Hour,
+		RefreshTTL: time.Hour,
+	})
+
+	f := &passwordResetFixture{
+		userRepo:    userRepo,
+		resetRepo:   NewMockPasswordResetRepository(),
+		revocations: revocations,
+		mailer:      &MockMailer{},
+		userID:      userID,
+		clock:       newFakeClock(),
+	}
+	f.uc = NewPasswordResetUseCase(userRepo, f.resetRepo, NewMockPasswordHasher(), tokenService, f.mailer, PasswordResetConfig{
+		TokenTTL: time.Hour,
+		ResetURL: "https://app.example.com/reset-password",
+	}).(*PasswordResetUseCase)
+	f.uc.now = f.clock.Now
+	return f
+}
+
+// requestReset asks for a reset link
+func (f *passwordResetFixture) requestReset(t *testing.T, email string) {
+	t.Helper()
+
+	if err := f.uc.RequestReset(context.Background(), email); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// failingUpdateUserRepository fails every update, like a database that
+// went away
+type failingUpdateUserRepository struct {
+	*MockUserRepository
+}
+
+func (r *failingUpdateUserRepository) Update(ctx context.Context, user *domain.User) error {
+	return errors.New("database is unavailable")
+}
+
+func (r *failingUpdateUserRepository) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	return 0, errors.New("database is unavailable")
+}
+
+func TestPasswordResetUseCase_RequestReset(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+
+	f.requestReset(t, "test@example.com")
+	if len(f.mailer.sent) != 1 || f.mailer.sent[0].To != "test@example.com" {
+		t.Fatalf("Expected one email to test@example.com, got %+v", f.mailer.sent)
+	}
+
+	token := tokenFromEmail(t, f.mailer.sent[0])
+	if _, exists := f.resetRepo.tokens[token]; exists {
+		t.Fatal("Expected reset token to be stored hashed, not in plain text")
+	}
+	stored, exists := f.resetRepo.tokens[hashToken(token)]
+	if !exists {
+		t.Fatal("Expected reset token hash to be stored")
+	}
+	if !stored.ExpiresAt.Equal(f.clock.Now().Add(time.Hour)) {
+		t.Errorf("Expected token to expire after one hour, got %v", stored.ExpiresAt)
+	}
+}
+
+func TestPasswordResetUseCase_RequestReset_UnknownEmail(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+
+	f.requestReset(t, "unknown@example.com")
+	if len(f.mailer.sent) != 0 || len(f.resetRepo.tokens) != 0 {
+		t.Fatalf("Expected no email and no token, got %d emails and %d tokens", len(f.mailer.sent), len(f.resetRepo.tokens))
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	f.requestReset(t, "test@example.com")
+	f.requestReset(t, "test@example.com")
+	first := tokenFromEmail(t, f.mailer.sent[0])
+	second := tokenFromEmail(t, f.mailer.sent[1])
+
+	if err := f.uc.ResetPassword(ctx, second, "newpassword"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.Password != "hashed_newpassword" {
+		t.Errorf("Expected password to be changed, got %q", user.Password)
+	}
+	if user.TokenVersion != 1 {
+		t.Errorf("Expected the token version to be bumped, got %d", user.TokenVersion)
+	}
+	if _, revoked := f.revocations.users[f.userID]; !revoked {
+		t.Error("Expected every session of the user to be revoked")
+	}
+
+	// Single use, and older links stop working too
+	if err := f.uc.ResetPassword(ctx, second, "another"); err != domain.ErrInvalidResetToken {
+		t.Errorf("Expected ErrInvalidResetToken for a used token, got %v", err)
+	}
+	if err := f.uc.ResetPassword(ctx, first, "another"); err != domain.ErrInvalidResetToken {
+		t.Errorf("Expected ErrInvalidResetToken for an older token, got %v", err)
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword_WeakPassword(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+	f.uc.config.PasswordPolicy = domain.PasswordPolicy{MinLength: 8}
+	ctx := context.Background()
+
+	f.requestReset(t, "test@example.com")
+	token := tokenFromEmail(t, f.mailer.sent[0])
+
+	if _, ok := f.uc.ResetPassword(ctx, token, "short").(*domain.PasswordPolicyError); !ok {
+		t.Fatal("Expected *domain.PasswordPolicyError for a short password")
+	}
+	// The token is not used up by a rejected password
+	if err := f.uc.ResetPassword(ctx, token, "longenough"); err != nil {
+		t.Fatalf("Expected the token to still work, got %v", err)
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword_Expired(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	f.requestReset(t, "test@example.com")
+	token := tokenFromEmail(t, f.mailer.sent[0])
+
+	f.clock.Advance(time.Hour)
+	if err := f.uc.ResetPassword(ctx, token, "newpassword"); err != domain.ErrInvalidResetToken {
+		t.Fatalf("Expected ErrInvalidResetToken, got %v", err)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.Password != "hashed_password123" {
+		t.Errorf("Expected password to be unchanged, got %q", user.Password)
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword_UnknownToken(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+
+	if err := f.uc.ResetPassword(context.Background(), "bogus", "newpassword"); err != domain.ErrInvalidResetToken {
+		t.Fatalf("Expected ErrInvalidResetToken, got %v", err)
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword_UpdateFailureKeepsToken(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	f.requestReset(t, "test@example.com")
+	token := tokenFromEmail(t, f.mailer.sent[0])
+
+	f.uc.userRepo = &failingUpdateUserRepository{f.userRepo}
+	if err := f.uc.ResetPassword(ctx, token, "newpassword"); err != domain.ErrUserUpdateError {
+		t.Fatalf("Expected ErrUserUpdateError, got %v", err)
+	}
+
+	// The token still works once the database is back
+	f.uc.userRepo = f.userRepo
+	if err := f.uc.ResetPassword(ctx, token, "newpassword"); err != nil {
+		t.Fatalf("Expected the token to still work, got %v", err)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.Password != "hashed_newpassword" {
+		t.Errorf("Expected password to be changed, got %q", user.Password)
+	}
+}
+
+func TestPasswordResetUseCase_ResetPassword_ConcurrentUse(t *testing.T) {
+	f := newTestPasswordResetUseCase(t)
+	ctx := context.Background()
+
+	f.requestReset(t, "test@example.com")
+	token := tokenFromEmail(t, f.mailer.sent[0])
+
+	// Both requests pass the checks before either claims the token
+	hasher := &gatedPasswordHasher{MockPasswordHasher: NewMockPasswordHasher(), gate: make(chan struct{})}
+	f.uc.hasher = hasher
+	hasher.waiting.Add(2)
+
+	passwords := []string{"firstpassword", "secondpassword"}
+	errs := make([]error, len(passwords))
+	var done sync.WaitGroup
+	for i, password := range passwords {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			errs[i] = f.uc.ResetPassword(ctx, token, password)
+		}()
+	}
+	hasher.waiting.Wait()
+	close(hasher.gate)
+	done.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			succeeded++
+		case domain.ErrInvalidResetToken:
+		default:
+			t.Errorf("Expected nil or ErrInvalidResetToken, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("Expected exactly one request to succeed, got %d", succeeded)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	if user.TokenVersion != 1 {
+		t.Errorf("Expected the password to be changed once, got token version %d", user.TokenVersion)
+	}
+}
+
+// gatedPasswordHasher holds every Hash call until gate is closed
+type gatedPasswordHasher struct {
+	*MockPasswordHasher
+	waiting sync.WaitGroup
+	gate    chan struct{}
+}
+
+func (h *gatedPasswordHasher) Hash(password string) (string, error) {
+	h.waiting.Done()
+	<-h.gate
+	return h.MockPasswordHasher.Hash(password)
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// phoneCodeDigits is the length of the codes texted to phone numbers
+const phoneCodeDigits = 6
+
+// PhoneVerificationConfig holds the settings used by PhoneVerificationUseCase
+type PhoneVerificationConfig struct {
+	// DefaultRegion is the region of stored phone numbers written without
+	// a country code, see domain.NormalizePhone
+	DefaultRegion string
+	// CodeTTL is how long a texted code works
+	CodeTTL time.Duration
+	// MaxAttempts is how many times a code may be entered before it stops working
+	MaxAttempts int
+	// MaxSends is how many codes one phone number may be sent per SendWindow,
+	// whichever accounts ask for them
+	MaxSends   int
+	SendWindow time.Duration
+}
+
+// PhoneVerificationUseCase implements domain.PhoneVerificationService with
+// one-time codes sent by text message
+type PhoneVerificationUseCase struct {
+	userRepo  domain.UserRepository
+	codeRepo  domain.PhoneVerificationRepository
+	smsSender domain.SMSSender
+	config    PhoneVerificationConfig
+	now       func() time.Time
+}
+
+// NewPhoneVerificationUseCase creates a new PhoneVerificationUseCase instance
+func NewPhoneVerificationUseCase(
+	userRepo domain.UserRepository,
+	codeRepo domain.PhoneVerificationRepository,
+	smsSender domain.SMSSender,
+	config PhoneVerificationConfig,
+) domain.PhoneVerificationService {
+	return &PhoneVerificationUseCase{
+		userRepo:  userRepo,
+		codeRepo:  codeRepo,
+		smsSender: smsSender,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+// SendCode texts a new code to the user's phone number, unless too many
+// were sent to the number within the send window. Users whose number is
+// already verified get nothing.
+func (uc *PhoneVerificationUseCase) SendCode(ctx context.Context, userID int) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	if user.IsPhoneVerified() {
+		return nil
+	}
+	if user.Phone == "" {
+		return domain.ErrPhoneNumberMissing
+	}
+	phone, err := domain.NormalizePhone(user.Phone, uc.config.DefaultRegion)
+	if err != nil {
+		return err
+	}
+
+	code, err := newPhoneCode()
+	if err != nil {
+		return domain.ErrTokenGenerationError
+	}
+
+	now := uc.now()
+	created, err := uc.codeRepo.Create(ctx, &domain.PhoneVerificationCode{
+		UserID:    user.ID,
+		Phone:     phone,
+		CodeHash:  hashToken(code),
+		ExpiresAt: now.Add(uc.config.CodeTTL),
+		CreatedAt: now,
+	}, now.Add(-uc.config.SendWindow), uc.config.MaxSends)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return domain.ErrPhoneCodeThrottled
+	}
+
+	return uc.smsSender.Send(ctx, domain.SMSMessage{
+		To:   phone,
+		Body: fmt.Sprintf("Your verification code is %s. It expires in %s. Do not share it with anyone.", code, uc.config.CodeTTL),
+	})
+}
+
+// VerifyPhone marks the user's phone number as verified with the code last
+// sent to it. The code stops working once it is used, after too many
+// attempts, or if the user has changed phone number since it was sent.
+func (uc *PhoneVerificationUseCase) VerifyPhone(ctx context.Context, userID int, code string) (*domain.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	stored, err := uc.codeRepo.GetLatest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := uc.now()
+	if stored.UsedAt != nil || stored.IsExpired(now) {
+		return nil, domain.ErrInvalidPhoneCode
+	}
+	if phone, err := domain.NormalizePhone(user.Phone, uc.config.DefaultRegion); err != nil || phone != stored.Phone {
+		return nil, domain.ErrInvalidPhoneCode
+	}
+
+	// Count the attempt before comparing, so concurrent guesses are limited too
+	counted, err := uc.codeRepo.RecordAttempt(ctx, stored.ID, uc.config.MaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !counted || subtle.ConstantTimeCompare([]byte(hashToken(code)), []byte(stored.CodeHash)) != 1 {
+		return nil, domain.ErrInvalidPhoneCode
+	}
+
+	marked, err := uc.codeRepo.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// Lost a race against another request using the same code
+		return nil, domain.ErrInvalidPhoneCode
+	}
+
+	user.Phone = stored.Phone
+	user.PhoneVerifiedAt = &now
+	user.UpdatedAt = now
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, domain.ErrUserUpdateError
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// newPhoneCode returns a random code of phoneCodeDigits digits
+func newPhoneCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < phoneCodeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", phoneCodeDigits, n), nil
+}
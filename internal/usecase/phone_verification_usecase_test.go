@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MockPhoneVerificationRepository implements domain.PhoneVerificationRepository for testing
+type MockPhoneVerificationRepository struct {
+	codes []*domain.PhoneVerificationCode
+}
+
+func (m *MockPhoneVerificationRepository) Create(ctx context.Context, code *domain.PhoneVerificationCode, since time.Time, max int) (bool, error) {
+	sent := 0
+	for _, stored := range m.codes {
+		if stored.Phone == code.Phone && stored.CreatedAt.After(since) {
+			sent++
+		}
+	}
+	if sent >= max {
+		return false, nil
+	}
+	code.ID = len(m.codes) + 1
+	copied := *code
+	m.codes = append(m.codes, &copied)
+	return true, nil
+}
+
+func (m *MockPhoneVerificationRepository) GetLatest(ctx context.Context, userID int) (*domain.PhoneVerificationCode, error) {
+	for i := len(m.codes) - 1; i >= 0; i-- {
+		if m.codes[i].UserID == userID {
+			copied := *m.codes[i]
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrInvalidPhoneCode
+}
+
+func (m *MockPhoneVerificationRepository) RecordAttempt(ctx context.Context, id int, max int) (bool, error) {
+	code := m.codes[id-1]
+	if code.Attempts >= max {
+		return false, nil
+	}
+	code.Attempts++
+	return true, nil
+}
+
+func (m *MockPhoneVerificationRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	code := m.codes[id-1]
+	if code.UsedAt != nil {
+		return false, nil
+	}
+	code.UsedAt = &usedAt
+	return true, nil
+}
+
+// MockSMSSender implements domain.SMSSender for testing and keeps the sent messages
+type MockSMSSender struct {
+	sent []domain.SMSMessage
+}
+
+func (m *MockSMSSender) Send(ctx context.Context, message domain.SMSMessage) error {
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+// phoneCodePattern finds the code in a text message
+var phoneCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// codeFromSMS extracts the code from a text message
+func codeFromSMS(t *testing.T, message domain.SMSMessage) string {
+	t.Helper()
+
+	code := phoneCodePattern.FindString(message.Body)
+	if code == "" {
+		t.Fatalf("Expected a code in the message, got %q", message.Body)
+	}
+	return code
+}
+
+type phoneVerificationFixture struct {
+	uc        *PhoneVerificationUseCase
+	userRepo  *MockUserRepository
+	codeRepo  *MockPhoneVerificationRepository
+	smsSender *MockSMSSender
+	userID    int
+	clock     *fakeClock
+}
+
+// newTestPhoneVerificationUseCase creates a phone verification use case with
+// a registered user whose phone number, 081-234-5678, is in the default
+// region, running on the fixture's clock
+func newTestPhoneVerificationUseCase(t *testing.T) *phoneVerificationFixture {
+	t.Helper()
+
+	userRepo := NewMockUserRepository()
+	userID := registerTestUser(t, userRepo)
+	// A number stored before phone numbers were normalized
+	stored, _ := userRepo.GetByID(context.Background(), userID)
+	stored.Phone = "081-234-5678"
+
+	f := &phoneVerificationFixture{
+		userRepo:  userRepo,
+		codeRepo:  &MockPhoneVerificationRepository{},
+		smsSender: &MockSMSSender{},
+		userID:    userID,
+		clock:     newFakeClock(),
+	}
+	f.uc = NewPhoneVerificationUseCase(userRepo, f.codeRepo, f.smsSender, PhoneVerificationConfig{
+		DefaultRegion: "TH",
+		CodeTTL:       10 * time.Minute,
+		MaxAttempts:   3,
+		MaxSends:      2,
+		SendWindow:    time.Hour,
+	}).(*PhoneVerificationUseCase)
+	f.uc.now = f.clock.Now
+	return f
+}
+
+func TestPhoneVerificationUseCase_SendAndVerify(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.smsSender.sent) != 1 || f.smsSender.sent[0].To != "+66812345678" {
+		t.Fatalf("Expected one message to +66812345678, got %+v", f.smsSender.sent)
+	}
+	code := codeFromSMS(t, f.smsSender.sent[0])
+	if f.codeRepo.codes[0].CodeHash == code {
+		t.Error("Expected only the hash of the code to be stored")
+	}
+
+	user, err := f.uc.VerifyPhone(ctx, f.userID, code)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !user.IsPhoneVerified() || !user.PhoneVerifiedAt.Equal(f.clock.Now()) {
+		t.Errorf("Expected the phone number to be verified at %v, got %v", f.clock.Now(), user.PhoneVerifiedAt)
+	}
+	if user.Phone != "+66812345678" {
+		t.Errorf("Expected the verified number to be stored normalized, got %q", user.Phone)
+	}
+	if user.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+
+	// The code works once, and verified users are not sent another
+	if _, err := f.uc.VerifyPhone(ctx, f.userID, code); err != domain.ErrInvalidPhoneCode {
+		t.Errorf("Expected ErrInvalidPhoneCode for a used code, got %v", err)
+	}
+	if err := f.uc.SendCode(ctx, f.userID); err != nil || len(f.smsSender.sent) != 1 {
+		t.Errorf("Expected nothing to be sent to a verified number, got %v and %d messages", err, len(f.smsSender.sent))
+	}
+}
+
+func TestPhoneVerificationUseCase_SendCode_Errors(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.SendCode(ctx, 999); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	user.Phone = "12345"
+	if err := f.uc.SendCode(ctx, f.userID); err != domain.ErrInvalidPhoneNumber {
+		t.Errorf("Expected ErrInvalidPhoneNumber, got %v", err)
+	}
+	user.Phone = ""
+	if err := f.uc.SendCode(ctx, f.userID); err != domain.ErrPhoneNumberMissing {
+		t.Errorf("Expected ErrPhoneNumberMissing, got %v", err)
+	}
+	if len(f.smsSender.sent) != 0 {
+		t.Errorf("Expected no messages, got %d", len(f.smsSender.sent))
+	}
+}
+
+func TestPhoneVerificationUseCase_SendCode_Throttled(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := f.uc.SendCode(ctx, f.userID); err != nil {
+			t.Fatalf("Expected code %d to be sent, got %v", i+1, err)
+		}
+	}
+	if err := f.uc.SendCode(ctx, f.userID); err != domain.ErrPhoneCodeThrottled {
+		t.Fatalf("Expected ErrPhoneCodeThrottled, got %v", err)
+	}
+	if len(f.smsSender.sent) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(f.smsSender.sent))
+	}
+
+	// The limit is per number, not per account
+	other, err := NewUserUseCase(f.userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{PhoneRegion: "TH"}).Register(ctx, "other@example.com", "password123", "Jane", "Doe", "0812345678", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	if err := f.uc.SendCode(ctx, other.ID); err != domain.ErrPhoneCodeThrottled {
+		t.Errorf("Expected ErrPhoneCodeThrottled for another account with the same number, got %v", err)
+	}
+
+	f.clock.Advance(time.Hour)
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Errorf("Expected a code to be sent once the window has passed, got %v", err)
+	}
+}
+
+func TestPhoneVerificationUseCase_VerifyPhone_Errors(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	if _, err := f.uc.VerifyPhone(ctx, f.userID, "123456"); err != domain.ErrInvalidPhoneCode {
+		t.Errorf("Expected ErrInvalidPhoneCode before a code is sent, got %v", err)
+	}
+	if _, err := f.uc.VerifyPhone(ctx, 999, "123456"); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	// Only the latest code works
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	f.clock.Advance(time.Minute)
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	first, latest := codeFromSMS(t, f.smsSender.sent[0]), codeFromSMS(t, f.smsSender.sent[1])
+	if first != latest {
+		if _, err := f.uc.VerifyPhone(ctx, f.userID, first); err != domain.ErrInvalidPhoneCode {
+			t.Errorf("Expected ErrInvalidPhoneCode for a superseded code, got %v", err)
+		}
+	}
+
+	// Expired
+	f.clock.Advance(10 * time.Minute)
+	if _, err := f.uc.VerifyPhone(ctx, f.userID, latest); err != domain.ErrInvalidPhoneCode {
+		t.Errorf("Expected ErrInvalidPhoneCode for an expired code, got %v", err)
+	}
+}
+
+func TestPhoneVerificationUseCase_VerifyPhone_LimitsAttempts(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	code := codeFromSMS(t, f.smsSender.sent[0])
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.uc.VerifyPhone(ctx, f.userID, wrong); err != domain.ErrInvalidPhoneCode {
+			t.Fatalf("Expected ErrInvalidPhoneCode for a wrong code, got %v", err)
+		}
+	}
+	if _, err := f.uc.VerifyPhone(ctx, f.userID, code); err != domain.ErrInvalidPhoneCode {
+		t.Errorf("Expected the code to stop working after too many attempts, got %v", err)
+	}
+}
+
+func TestPhoneVerificationUseCase_VerifyPhone_NumberChanged(t *testing.T) {
+	f := newTestPhoneVerificationUseCase(t)
+	ctx := context.Background()
+
+	if err := f.uc.SendCode(ctx, f.userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	user, _ := f.userRepo.GetByID(ctx, f.userID)
+	user.Phone = "+14155552671"
+
+	if _, err := f.uc.VerifyPhone(ctx, f.userID, codeFromSMS(t, f.smsSender.sent[0])); err != domain.ErrInvalidPhoneCode {
+		t.Errorf("Expected ErrInvalidPhoneCode once the number has changed, got %v", err)
+	}
+}
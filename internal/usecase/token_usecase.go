@@ -0,0 +1,183 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// TokenConfig holds token lifetimes used by TokenUseCase
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenUseCase implements domain.TokenService and handles refresh token rotation and logout
+type TokenUseCase struct {
+	userRepo         domain.UserRepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	revocationStore  domain.TokenRevocationStore
+	authService      domain.AuthService
+	config           TokenConfig
+	now              func() time.Time
+}
+
+// NewTokenUseCase creates a new TokenUseCase instance
+func NewTokenUseCase(
+	userRepo domain.UserRepository,
+	refreshTokenRepo domain.RefreshTokenRepository,
+	revocationStore domain.TokenRevocationStore,
+	authService domain.AuthService,
+	config TokenConfig,
+) domain.TokenService {
+	return &TokenUseCase{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		revocationStore:  revocationStore,
+		authService:      authService,
+		config:           config,
+		now:              time.Now,
+	}
+}
+
+// IssueRefreshToken starts a new refresh token family for a freshly logged in user
+func (uc *TokenUseCase) IssueRefreshToken(ctx context.Context, userID int) (string, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return "", domain.ErrTokenGenerationError
+	}
+	return uc.createRefreshToken(ctx, userID, familyID, uc.now())
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a token that was already rotated revokes its whole family.
+func (uc *TokenUseCase) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	stored, err := uc.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	if stored.RevokedAt != nil || stored.IsExpired(now) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// A used token showing up again means it was stolen or replayed
+	if stored.UsedAt != nil {
+		return nil, uc.revokeFamily(ctx, stored.FamilyID, now)
+	}
+
+	marked, err := uc.refreshTokenRepo.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// Lost a race against another request rotating the same token
+		return nil, uc.revokeFamily(ctx, stored.FamilyID, now)
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	accessToken, err := uc.authService.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		return nil, domain.ErrTokenGenerationError
+	}
+
+	newRefreshToken, err := uc.createRefreshToken(ctx, user.ID, stored.FamilyID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+	}, nil
+}
+
+// Logout revokes the presented access token and, if given, the refresh token family it belongs to
+func (uc *TokenUseCase) Logout(ctx context.Context, claims *domain.TokenClaims, refreshToken string) error {
+	if claims.TokenID != "" {
+		if err := uc.revocationStore.RevokeToken(ctx, claims.TokenID, claims.UserID, claims.ExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := uc.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if err == domain.ErrInvalidRefreshToken {
+			// Logging out with an unknown refresh token is not an error
+			return nil
+		}
+		return err
+	}
+	if stored.UserID != claims.UserID {
+		return nil
+	}
+	return uc.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID, uc.now())
+}
+
+// LogoutAll revokes every access token and refresh token issued to the user so far
+func (uc *TokenUseCase) LogoutAll(ctx context.Context, userID int) error {
+	now := uc.now()
+
+	// Access tokens issued before now are all expired after one access TTL
+	if err := uc.revocationStore.RevokeUserTokens(ctx, userID, now, now.Add(uc.config.AccessTTL)); err != nil {
+		return err
+	}
+	return uc.refreshTokenRepo.RevokeAllForUser(ctx, userID, now)
+}
+
+// createRefreshToken generates and stores a refresh token, returning its plain value
+func (uc *TokenUseCase) createRefreshToken(ctx context.Context, userID int, familyID string, now time.Time) (string, error) {
+	value, err := randomToken(32)
+	if err != nil {
+		return "", domain.ErrTokenGenerationError
+	}
+
+	token := &domain.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(value),
+		ExpiresAt: now.Add(uc.config.RefreshTTL),
+		CreatedAt: now,
+	}
+	if err := uc.refreshTokenRepo.Create(ctx, token); err != nil {
+		return "", domain.ErrTokenGenerationError
+	}
+
+	return value, nil
+}
+
+func (uc *TokenUseCase) revokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	if err := uc.refreshTokenRepo.RevokeFamily(ctx, familyID, now); err != nil {
+		return err
+	}
+	return domain.ErrRefreshTokenReused
+}
+
+// randomToken returns n random bytes encoded as URL-safe base64
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex-encoded SHA-256 digest of an opaque token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hello-world/internal/domain"
+)
+
+// MockRefreshTokenRepository implements domain.RefreshTokenRepository for testing
+type MockRefreshTokenRepository struct {
+	tokens map[string]*domain.RefreshToken
+	nextID int
+}
+
+func NewMockRefreshTokenRepository() *MockRefreshTokenRepository {
+	return &MockRefreshTokenRepository{
+		tokens: make(map[string]*domain.RefreshToken),
+		nextID: 1,
+	}
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	token.ID = m.nextID
+	m.nextID++
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	token, exists := m.tokens[tokenHash]
+	if !exists {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, id int, usedAt time.Time) (bool, error) {
+	for _, token := range m.tokens {
+		if token.ID == id {
+			if token.UsedAt != nil {
+				return false, nil
+			}
+			token.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error {
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// MockTokenRevocationStore implements domain.TokenRevocationStore for testing
+type MockTokenRevocationStore struct {
+	tokens map[string]time.Time
+	users  map[int]time.Time
+}
+
+func NewMockTokenRevocationStore() *MockTokenRevocationStore {
+	return &MockTokenRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]time.Time),
+	}
+}
+
+func (m *MockTokenRevocationStore) RevokeToken(ctx context.Context, tokenID string, userID int, expiresAt time.Time) error {
+	m.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (m *MockTokenRevocationStore) RevokeUserTokens(ctx context.Context, userID int, issuedBefore, expiresAt time.Time) error {
+	m.users[userID] = issuedBefore
+	return nil
+}
+
+func (m *MockTokenRevocationStore) IsRevoked(ctx context.Context, claims *domain.TokenClaims) (bool, error) {
+	if _, revoked := m.tokens[claims.TokenID]; revoked {
+		return true, nil
+	}
+	if cutoff, ok := m.users[claims.UserID]; ok && claims.IssuedAt.Before(cutoff) {
+		return true, nil
+	}
+	return false, nil
+}
+
+func setupTokenUseCase(t *testing.T) (domain.TokenService, *MockRefreshTokenRepository, *MockTokenRevocationStore, int) {
+	userRepo := NewMockUserRepository()
+	refreshTokenRepo := NewMockRefreshTokenRepository()
+	revocationStore := NewMockTokenRevocationStore()
+	authService := NewMockAuthService()
+
+	userID := registerTestUser(t, userRepo)
+
+	tokenService := NewTokenUseCase(userRepo, refreshTokenRepo, revocationStore, authService, TokenConfig{
+		AccessTTL:  time.Hour,
+		RefreshTTL: time.Hour,
+	})
+	return tokenService, refreshTokenRepo, revocationStore, userID
+}
+
+func TestTokenUseCase_IssueRefreshToken(t *testing.T) {
+	tokenService, refreshTokenRepo, _, userID := setupTokenUseCase(t)
+
+	refreshToken, err := tokenService.IssueRefreshToken(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if refreshToken == "" {
+		t.Fatal("Expected refresh token to be returned")
+	}
+	if _, exists := refreshTokenRepo.tokens[refreshToken]; exists {
+		t.Fatal("Expected refresh token to be stored hashed, not in plain text")
+	}
+	if _, exists := refreshTokenRepo.tokens[hashToken(refreshToken)]; !exists {
+		t.Fatal("Expected refresh token hash to be stored")
+	}
+}
+
+func TestTokenUseCase_Refresh_Rotates(t *testing.T) {
+	tokenService, _, _, userID := setupTokenUseCase(t)
+	ctx := context.Background()
+
+	refreshToken, err := tokenService.IssueRefreshToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to issue refresh token: %v", err)
+	}
+
+	pair, err := tokenService.Refresh(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pair.AccessToken != "mock_token" {
+		t.Fatalf("Expected access token mock_token, got %s", pair.AccessToken)
+	}
+	if pair.RefreshToken == "" || pair.RefreshToken == refreshToken {
+		t.Fatal("Expected a new refresh token to be issued")
+	}
+
+	// The rotated token can be used in turn
+	if _, err := tokenService.Refresh(ctx, pair.RefreshToken); err != nil {
+		t.Fatalf("Expected rotated token to be valid, got %v", err)
+	}
+}
+
+func TestTokenUseCase_Refresh_ReuseRevokesFamily(t *testing.T) {
+	tokenService, _, _, userID := setupTokenUseCase(t)
+	ctx := context.Background()
+
+	refreshToken, err := tokenService.IssueRefreshToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to issue refresh token: %v", err)
+	}
+
+	pair, err := tokenService.Refresh(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("Failed to refresh: %v", err)
+	}
+
+	// Replaying the original token is detected as reuse
+	_, err = tokenService.Refresh(ctx, refreshToken)
+	if err != domain.ErrRefreshTokenReused {
+		t.Fatalf("Expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	// The legitimate successor is revoked together with its family
+	_, err = tokenService.Refresh(ctx, pair.RefreshToken)
+	if err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("Expected ErrInvalidRefreshToken after family revocation, got %v", err)
+	}
+}
+
+func TestTokenUseCase_Refresh_Expired(t *testing.T) {
+	tokenService, _, _, userID := setupTokenUseCase(t)
+	ctx := context.Background()
+
+	refreshToken, err := tokenService.IssueRefreshToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to issue refresh token: %v", err)
+	}
+
+	tokenService.(*TokenUseCase).now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	_, err = tokenService.Refresh(ctx, refreshToken)
+	if err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestTokenUseCase_Refresh_UnknownToken(t *testing.T) {
+	tokenService, _, _, _ := setupTokenUseCase(t)
+
+	_, err := tokenService.Refresh(context.Background(), "unknown")
+	if err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("Expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
+
+func TestTokenUseCase_Logout(t *testing.T) {
+	tokenService, _, revocationStore, userID := setupTokenUseCase(t)
+	ctx := context.Background()
+
+	refreshToken, err := tokenService.IssueRefreshToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to issue refresh token: %v", err)
+	}
+
+	claims := &domain.TokenClaims{UserID: userID, TokenID: "jti-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := tokenService.Logout(ctx, claims, refreshToken); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if revoked, _ := revocationStore.IsRevoked(ctx, claims); !revoked {
+		t.Fatal("Expected access token to be revoked")
+	}
+	if _, err := tokenService.Refresh(ctx, refreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("Expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestTokenUseCase_Logout_UnknownRefreshToken(t *testing.T) {
+	tokenService, _, _, userID := setupTokenUseCase(t)
+
+	claims := &domain.TokenClaims{UserID: userID, TokenID: "jti-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := tokenService.Logout(context.Background(), claims, "unknown"); err != nil {
+		t.Fatalf("Expected logout with unknown refresh token to succeed, got %v", err)
+	}
+}
+
+func TestTokenUseCase_LogoutAll(t *testing.T) {
+	tokenService, _, revocationStore, userID := setupTokenUseCase(t)
+	ctx := context.Background()
+
+	refreshToken, err := tokenService.IssueRefreshToken(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to issue refresh token: %v", err)
+	}
+
+	issuedAt := time.Now().Add(-time.Minute)
+	if err := tokenService.LogoutAll(ctx, userID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	claims := &domain.TokenClaims{UserID: userID, TokenID: "jti-1", IssuedAt: issuedAt}
+	if revoked, _ := revocationStore.IsRevoked(ctx, claims); !revoked {
+		t.Fatal("Expected earlier access tokens to be revoked")
+	}
+	if _, err := tokenService.Refresh(ctx, refreshToken); err != domain.ErrInvalidRefreshToken {
+		t.Fatalf("Expected refresh token to be revoked, got %v", err)
+	}
+}
@@ -2,38 +2,95 @@ package usecase
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"hello-world/internal/domain"
 )
 
+// UserConfig holds account settings used by UserUseCase
+type UserConfig struct {
+	// DeletionGracePeriod is how long a deleted account can be restored
+	// before it is purged
+	DeletionGracePeriod time.Duration
+	// RequireVerifiedEmail refuses logins until the email address is verified
+	RequireVerifiedEmail bool
+	// PasswordPolicy is the policy passwords of new accounts must follow
+	PasswordPolicy domain.PasswordPolicy
+	// PhoneRegion is the region of phone numbers given without a country
+	// code, see domain.NormalizePhone
+	PhoneRegion string
+}
+
 // UserUseCase implements domain.UserService and handles user-related business logic
 type UserUseCase struct {
-	userRepo    domain.UserRepository
-	authService domain.AuthService
+	userRepo         domain.UserRepository
+	mfaRepo          domain.MFARepository
+	refreshTokenRepo domain.RefreshTokenRepository
+	authService      domain.AuthService
+	hasher           domain.PasswordHasher
+	config           UserConfig
+	now              func() time.Time
+
+	// dummyHash is compared against when a login names no account, so that
+	// it takes as long as a wrong password for an existing one
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
+// dummyPassword is hashed once to obtain dummyHash
+const dummyPassword = "timing-safe-login-dummy-password"
+
 // NewUserUseCase creates a new UserUseCase instance
-func NewUserUseCase(userRepo domain.UserRepository, authService domain.AuthService) domain.UserService {
+func NewUserUseCase(userRepo domain.UserRepository, mfaRepo domain.MFARepository, refreshTokenRepo domain.RefreshTokenRepository, authService domain.AuthService, hasher domain.PasswordHasher, config UserConfig) domain.UserService {
 	return &UserUseCase{
-		userRepo:    userRepo,
-		authService: authService,
+		userRepo:         userRepo,
+		mfaRepo:          mfaRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		authService:      authService,
+		hasher:           hasher,
+		config:           config,
+		now:              time.Now,
 	}
 }
 
-// Register creates a new user account
+// Register creates a new user account. The email address and phone number
+// are stored normalized. An email address, password or phone number that
+// cannot be used gets a *domain.ValidationError listing all of them.
 func (uc *UserUseCase) Register(ctx context.Context, email, password, firstName, lastName, phone string, birthday time.Time) (*domain.User, error) {
-	// Check if user already exists
-	exists, err := uc.userRepo.Exists(ctx, email)
+	var invalid domain.ValidationError
+
+	normalizedEmail, err := domain.NormalizeEmail(email)
 	if err != nil {
-		return nil, err
+		invalid.Add("email", domain.FieldRuleEmail, domain.ErrInvalidEmailAddress.Message)
+	} else {
+		// Check if user already exists
+		exists, err := uc.userRepo.Exists(ctx, normalizedEmail)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, domain.ErrUserAlreadyExists
+		}
+		email = normalizedEmail
+	}
+
+	if err := uc.config.PasswordPolicy.Validate(password, email, firstName, lastName); err != nil {
+		invalid.AddPasswordPolicy("password", err.(*domain.PasswordPolicyError))
 	}
-	if exists {
-		return nil, domain.ErrUserAlreadyExists
+
+	if phone != "" {
+		if phone, err = domain.NormalizePhone(phone, uc.config.PhoneRegion); err != nil {
+			invalid.Add("phone", domain.FieldRulePhone, domain.ErrInvalidPhoneNumber.Message)
+		}
+	}
+
+	if err := invalid.Err(); err != nil {
+		return nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := uc.authService.HashPassword(password)
+	hashedPassword, err := uc.hasher.Hash(password)
 	if err != nil {
 		return nil, domain.ErrPasswordHashError
 	}
@@ -44,9 +101,12 @@ func (uc *UserUseCase) Register(ctx context.Context, email, password, firstName,
 		return nil, err
 	}
 
-	// Save user
+	// Save user. A soft-deleted account keeps its email until it is purged.
 	err = uc.userRepo.Create(ctx, user)
 	if err != nil {
+		if err == domain.ErrUserAlreadyExists {
+			return nil, err
+		}
 		return nil, domain.ErrUserCreationError
 	}
 
@@ -56,30 +116,80 @@ func (uc *UserUseCase) Register(ctx context.Context, email, password, firstName,
 	return &responseUser, nil
 }
 
-// Login authenticates a user and returns a JWT token and user data
-func (uc *UserUseCase) Login(ctx context.Context, email, password string) (string, *domain.User, error) {
+// Login authenticates a user and returns a JWT token and user data.
+// Users with two-factor authentication get an MFA challenge instead, which
+// MFAService.CompleteLogin exchanges for a token.
+func (uc *UserUseCase) Login(ctx context.Context, email, password string) (*domain.LoginResult, error) {
 	// Get user by email
 	user, err := uc.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		return "", nil, domain.ErrInvalidCredentials
+		// Still spend the time of a password check, or the response time
+		// would reveal which emails are registered
+		_ = uc.hasher.Compare(uc.dummyPasswordHash(), password)
+		return nil, domain.ErrInvalidCredentials
 	}
 
 	// Verify password
-	err = uc.authService.ComparePassword(user.Password, password)
+	err = uc.hasher.Compare(user.Password, password)
 	if err != nil {
-		return "", nil, domain.ErrInvalidCredentials
+		return nil, domain.ErrInvalidCredentials
 	}
 
-	// Generate JWT token
-	token, err := uc.authService.GenerateToken(user.ID, user.Email)
-	if err != nil {
-		return "", nil, domain.ErrTokenGenerationError
+	// Upgrade hashes made with an older algorithm or cost while the plain
+	// password is at hand. Failing to do so does not fail the login; the
+	// next one tries again.
+	if uc.hasher.NeedsRehash(user.Password) {
+		uc.rehashPassword(ctx, user, password)
+	}
+
+	if uc.config.RequireVerifiedEmail && !user.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
 	}
 
 	// Create a copy for response to avoid modifying the stored user
 	responseUser := *user
 	responseUser.Password = ""
-	return token, &responseUser, nil
+	result := &domain.LoginResult{User: &responseUser}
+
+	// Ask for the second factor before issuing a token
+	enrollment, err := uc.mfaRepo.GetEnrollment(ctx, user.ID)
+	if err != nil && err != domain.ErrMFANotEnrolled {
+		return nil, err
+	}
+	if err == nil && enrollment.IsConfirmed() {
+		result.MFAChallenge, err = uc.authService.GenerateMFAChallenge(user.ID)
+		if err != nil {
+			return nil, domain.ErrTokenGenerationError
+		}
+		return result, nil
+	}
+
+	// Generate JWT token
+	result.Token, err = uc.authService.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		return nil, domain.ErrTokenGenerationError
+	}
+	return result, nil
+}
+
+// rehashPassword stores the password hashed with the current algorithm and
+// cost. Only the hash is written, and only if it is still the one the
+// password was checked against, so a concurrent change is not undone.
+func (uc *UserUseCase) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	hashedPassword, err := uc.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+	_, _ = uc.userRepo.ReplacePasswordHash(ctx, user.ID, user.Password, hashedPassword)
+}
+
+// dummyPasswordHash hashes dummyPassword on first use, so it is checked with
+// the same algorithm and cost as the passwords of real accounts
+func (uc *UserUseCase) dummyPasswordHash() string {
+	uc.dummyHashOnce.Do(func() {
+		uc.dummyHash, _ = uc.hasher.Hash(dummyPassword)
+	})
+	return uc.dummyHash
 }
 
 // GetUserByID retrieves a user by ID
@@ -100,28 +210,50 @@ func (uc *UserUseCase) GetUserProfile(ctx context.Context, userID int) (*domain.
 	return uc.GetUserByID(ctx, userID)
 }
 
-// UpdateUser updates user information
+// UpdateUser updates user information, skipping empty values
 func (uc *UserUseCase) UpdateUser(ctx context.Context, userID int, firstName, lastName, phone string, birthday *time.Time) (*domain.User, error) {
-	// Get existing user
-	user, err := uc.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return nil, domain.ErrUserNotFound
-	}
-
-	// Update fields if provided
+	var update domain.UserUpdate
 	if firstName != "" {
-		user.FirstName = firstName
+		update.FirstName = &firstName
 	}
 	if lastName != "" {
-		user.LastName = lastName
+		update.LastName = &lastName
 	}
 	if phone != "" {
-		user.Phone = phone
+		update.Phone = &phone
 	}
-	if birthday != nil {
-		user.Birthday = *birthday
+	update.Birthday = birthday
+
+	return uc.UpdateProfile(ctx, userID, update)
+}
+
+// UpdateProfile applies a partial update to the user's profile.
+// A new phone number is normalized and has to be verified again. The phone
+// number and birthday are required and cannot be cleared; a phone number or
+// birthday that cannot be used gets a *domain.ValidationError listing both.
+func (uc *UserUseCase) UpdateProfile(ctx context.Context, userID int, update domain.UserUpdate) (*domain.User, error) {
+	var invalid domain.ValidationError
+	if update.Phone != nil {
+		phone, err := domain.NormalizePhone(*update.Phone, uc.config.PhoneRegion)
+		if err != nil {
+			invalid.Add("phone", domain.FieldRulePhone, domain.ErrInvalidPhoneNumber.Message)
+		}
+		update.Phone = &phone
+	}
+	if update.Birthday != nil && update.Birthday.IsZero() {
+		invalid.Add("birthday", domain.FieldRuleBirthday, domain.ErrInvalidBirthday.Message)
+	}
+	if err := invalid.Err(); err != nil {
+		return nil, err
+	}
+
+	// Get existing user
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
 	}
 
+	update.Apply(user)
 	user.UpdatedAt = time.Now()
 
 	// Validate updated user
@@ -133,11 +265,146 @@ func (uc *UserUseCase) UpdateUser(ctx context.Context, userID int, firstName, la
 	// Save updated user
 	err = uc.userRepo.Update(ctx, user)
 	if err != nil {
-		return nil, domain.ErrUserCreationError // Generic update error
+		return nil, domain.ErrUserUpdateError
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// ChangePassword replaces the user's password once the current one checks
+// out. Refresh tokens are revoked before the password is stored, so the new
+// password never coexists with sessions of the old one; if storing it fails,
+// the old password stays and the user has to log in again. The password is
+// stored together with a bump of the token version, which invalidates every
+// access token issued before, so the caller gets a new one in the result.
+func (uc *UserUseCase) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) (*domain.LoginResult, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err := uc.hasher.Compare(user.Password, currentPassword); err != nil {
+		return nil, domain.ErrIncorrectPassword
+	}
+	if err := uc.config.PasswordPolicy.Validate(newPassword, user.Email, user.FirstName, user.LastName); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return nil, domain.ErrPasswordHashError
+	}
+	now := uc.now()
+	if err := uc.refreshTokenRepo.RevokeAllForUser(ctx, userID, now); err != nil {
+		return nil, err
+	}
+
+	if user.TokenVersion, err = uc.userRepo.UpdatePassword(ctx, user.ID, hashedPassword, now); err != nil {
+		return nil, domain.ErrUserUpdateError
+	}
+	user.Password = hashedPassword
+	user.UpdatedAt = now
+
+	token, err := uc.authService.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion)
+	if err != nil {
+		return nil, domain.ErrTokenGenerationError
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &domain.LoginResult{Token: token, User: &responseUser}, nil
+}
+
+// SetRole changes a user's role. It takes effect at once: role checks read
+// the stored user, not the role claim of tokens already issued, which stays
+// stale until the user logs in again.
+func (uc *UserUseCase) SetRole(ctx context.Context, userID int, role domain.Role) (*domain.User, error) {
+	if !role.IsValid() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+
+	err = uc.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, domain.ErrUserUpdateError
+	}
+
+	// Create a copy for response to avoid modifying the stored user
+	responseUser := *user
+	responseUser.Password = ""
+	return &responseUser, nil
+}
+
+// ListUsers returns one page of users for administrators
+func (uc *UserUseCase) ListUsers(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	page, err := uc.userRepo.List(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Never hand password hashes to the interface layer
+	for i, user := range page.Users {
+		responseUser := *user
+		responseUser.Password = ""
+		page.Users[i] = &responseUser
+	}
+	return page, nil
+}
+
+// DeleteAccount soft-deletes a user. The account can be restored until the
+// grace period ends, after which it is purged.
+func (uc *UserUseCase) DeleteAccount(ctx context.Context, userID int) error {
+	if err := uc.userRepo.SoftDelete(ctx, userID, uc.now()); err != nil {
+		if err == domain.ErrUserNotFound {
+			return err
+		}
+		return domain.ErrUserUpdateError
+	}
+	return nil
+}
+
+// RestoreAccount undoes DeleteAccount while the grace period lasts
+func (uc *UserUseCase) RestoreAccount(ctx context.Context, userID int) (*domain.User, error) {
+	user, err := uc.userRepo.GetDeletedByID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	// The purger may not have run yet, so check the deadline here
+	if !uc.now().Before(user.DeletedAt.Add(uc.config.DeletionGracePeriod)) {
+		return nil, domain.ErrRestorePeriodExpired
+	}
+
+	if err := uc.userRepo.Restore(ctx, userID); err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil, err
+		}
+		return nil, domain.ErrUserUpdateError
 	}
 
 	// Create a copy for response to avoid modifying the stored user
 	responseUser := *user
 	responseUser.Password = ""
+	responseUser.DeletedAt = nil
 	return &responseUser, nil
 }
+
+// PurgeDeletedUsers permanently removes accounts whose grace period has ended
+func (uc *UserUseCase) PurgeDeletedUsers(ctx context.Context) (int, error) {
+	return uc.userRepo.PurgeDeleted(ctx, uc.now().Add(-uc.config.DeletionGracePeriod))
+}
@@ -2,6 +2,10 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,26 +14,29 @@ import (
 
 // MockUserRepository implements domain.UserRepository for testing
 type MockUserRepository struct {
-	users  map[string]*domain.User
-	nextID int
+	// users is keyed by domain.EmailKey, like the repositories look users up
+	users            map[string]*domain.User
+	nextID           int
+	verificationSent map[int]time.Time
 }
 
 func NewMockUserRepository() *MockUserRepository {
 	return &MockUserRepository{
-		users:  make(map[string]*domain.User),
-		nextID: 1,
+		users:            make(map[string]*domain.User),
+		nextID:           1,
+		verificationSent: make(map[int]time.Time),
 	}
 }
 
 func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) error {
 	user.ID = m.nextID
 	m.nextID++
-	m.users[user.Email] = user
+	m.users[domain.EmailKey(user.Email)] = user
 	return nil
 }
 
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	user, exists := m.users[email]
+	user, exists := m.users[domain.EmailKey(email)]
 	if !exists {
 		return nil, domain.ErrUserNotFound
 	}
@@ -46,7 +53,19 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id int) (*domain.User,
 }
 
 func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
-	m.users[user.Email] = user
+	if other, exists := m.users[domain.EmailKey(user.Email)]; exists && other.ID != user.ID {
+		return domain.ErrUserAlreadyExists
+	}
+	// Re-key the user if the email changed; like the real repositories,
+	// Update keeps the stored password and token version
+	for email, stored := range m.users {
+		if stored.ID == user.ID {
+			user.Password = stored.Password
+			user.TokenVersion = stored.TokenVersion
+			delete(m.users, email)
+		}
+	}
+	m.users[domain.EmailKey(user.Email)] = user
 	return nil
 }
 
@@ -61,18 +80,109 @@ func (m *MockUserRepository) Delete(ctx context.Context, id int) error {
 }
 
 func (m *MockUserRepository) Exists(ctx context.Context, email string) (bool, error) {
-	_, exists := m.users[email]
+	_, exists := m.users[domain.EmailKey(email)]
 	return exists, nil
 }
 
+func (m *MockUserRepository) List(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	page := &domain.UserPage{}
+	for _, user := range m.users {
+		if query.Filter.Matches(user) {
+			page.Users = append(page.Users, user)
+		}
+	}
+	page.Total = len(page.Users)
+	return page, nil
+}
+
+func (m *MockUserRepository) SoftDelete(ctx context.Context, id int, deletedAt time.Time) error {
+	for _, user := range m.users {
+		if user.ID == id && user.DeletedAt == nil {
+			user.DeletedAt = &deletedAt
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (m *MockUserRepository) GetDeletedByID(ctx context.Context, id int) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.ID == id && user.DeletedAt != nil {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id int) error {
+	for _, user := range m.users {
+		if user.ID == id && user.DeletedAt != nil {
+			user.DeletedAt = nil
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+func (m *MockUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	for email, user := range m.users {
+		if user.DeletedAt != nil && user.DeletedAt.Before(deletedBefore) {
+			delete(m.users, email)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *MockUserRepository) RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error) {
+	if last, sent := m.verificationSent[id]; sent && last.After(notBefore) {
+		return false, nil
+	}
+	m.verificationSent[id] = sentAt
+	return true, nil
+}
+
+// MockPasswordHasher implements domain.PasswordHasher for testing. It hashes
+// passwords as "hashed_<password>", and also accepts the outdated hashes
+// "legacy_<password>", which need rehashing.
+type MockPasswordHasher struct {
+	hashError bool
+}
+
+func NewMockPasswordHasher() *MockPasswordHasher {
+	return &MockPasswordHasher{}
+}
+
+func (m *MockPasswordHasher) Hash(password string) (string, error) {
+	if m.hashError {
+		return "", domain.ErrPasswordHashError
+	}
+	return "hashed_" + password, nil
+}
+
+func (m *MockPasswordHasher) Compare(hash, password string) error {
+	if hash == "hashed_"+password || hash == "legacy_"+password {
+		return nil
+	}
+	return domain.ErrInvalidCredentials
+}
+
+func (m *MockPasswordHasher) NeedsRehash(hash string) bool {
+	return strings.HasPrefix(hash, "legacy_")
+}
+
 // MockAuthService implements domain.AuthService for testing
-type MockAuthService struct{}
+type MockAuthService struct {
+	challenges int
+}
 
 func NewMockAuthService() *MockAuthService {
 	return &MockAuthService{}
 }
 
-func (m *MockAuthService) GenerateToken(userID int, email string) (string, error) {
+func (m *MockAuthService) GenerateToken(userID int, email string, role domain.Role, tokenVersion int) (string, error) {
 	return "mock_token", nil
 }
 
@@ -80,15 +190,78 @@ func (m *MockAuthService) ValidateToken(token string) (*domain.TokenClaims, erro
 	return &domain.TokenClaims{UserID: 1, Email: "test@example.com"}, nil
 }
 
-func (m *MockAuthService) HashPassword(password string) (string, error) {
-	return "hashed_" + password, nil
+func (m *MockAuthService) PublicKeys() []domain.JSONWebKey {
+	return nil
 }
 
-func (m *MockAuthService) ComparePassword(hashedPassword, password string) error {
-	if hashedPassword == "hashed_"+password {
-		return nil
+func (m *MockAuthService) GenerateMFAChallenge(userID int) (string, error) {
+	m.challenges++
+	return fmt.Sprintf("mfa_challenge_%d_%d", userID, m.challenges), nil
+}
+
+func (m *MockAuthService) ValidateMFAChallenge(token string) (*domain.TokenClaims, error) {
+	var userID, n int
+	if _, err := fmt.Sscanf(token, "mfa_challenge_%d_%d", &userID, &n); err != nil {
+		return nil, domain.ErrInvalidMFAToken
 	}
-	return domain.ErrInvalidCredentials
+	return &domain.TokenClaims{UserID: userID, TokenID: token, ExpiresAt: time.Now().Add(5 * time.Minute)}, nil
+}
+
+func (m *MockAuthService) GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	return fmt.Sprintf("verify_%d_%s", userID, email), nil
+}
+
+func (m *MockAuthService) ValidateEmailVerificationToken(token string) (int, string, error) {
+	var userID int
+	var email string
+	if _, err := fmt.Sscanf(token, "verify_%d_%s", &userID, &email); err != nil {
+		return 0, "", domain.ErrInvalidVerificationToken
+	}
+	return userID, email, nil
+}
+
+func (m *MockAuthService) GenerateEmailRevertToken(userID int, email string) (string, error) {
+	return fmt.Sprintf("revert_%d_%s", userID, email), nil
+}
+
+func (m *MockAuthService) ValidateEmailRevertToken(token string) (int, string, error) {
+	var userID int
+	var email string
+	if _, err := fmt.Sscanf(token, "revert_%d_%s", &userID, &email); err != nil {
+		return 0, "", domain.ErrInvalidEmailChangeToken
+	}
+	return userID, email, nil
+}
+
+// fakeClock is a settable time source for the now field of a use case
+type fakeClock struct {
+	now time.Time
+}
+
+// newFakeClock creates a clock set to noon on 1 January 2024
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// registerTestUser registers test@example.com, with the password
+// password123, in userRepo and returns the user's ID
+func registerTestUser(t *testing.T, userRepo *MockUserRepository) int {
+	t.Helper()
+
+	user, err := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{}).Register(context.Background(), "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	return user.ID
 }
 
 // Test functions
@@ -96,14 +269,14 @@ func TestUserUseCase_Register(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 	firstName := "John"
 	lastName := "Doe"
-	phone := "1234567890"
+	phone := "+14155552671"
 	birthday := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	// Act
@@ -125,20 +298,23 @@ func TestUserUseCase_Register(t *testing.T) {
 	if user.Password != "" {
 		t.Fatal("Expected password to be cleared from response")
 	}
+	if user.Role != domain.RoleUser {
+		t.Fatalf("Expected role %s, got %s", domain.RoleUser, user.Role)
+	}
 }
 
 func TestUserUseCase_Login(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 
 	// Create a user first
-	_, err := userService.Register(ctx, email, password, "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := userService.Register(ctx, email, password, "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("Failed to register user: %v", err)
 	}
@@ -151,15 +327,19 @@ func TestUserUseCase_Login(t *testing.T) {
 	t.Logf("Stored user password: %s", storedUser.Password)
 
 	// Act
-	token, loginUser, err := userService.Login(ctx, email, password)
+	result, err := userService.Login(ctx, email, password)
 
 	// Assert
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
-	if token == "" {
+	if result.Token == "" {
 		t.Fatal("Expected token to be returned")
 	}
+	if result.MFARequired() {
+		t.Fatal("Expected no MFA challenge without an enrollment")
+	}
+	loginUser := result.User
 	if loginUser.Email != email {
 		t.Fatalf("Expected email %s, got %s", email, loginUser.Email)
 	}
@@ -168,18 +348,66 @@ func TestUserUseCase_Login(t *testing.T) {
 	}
 }
 
+func TestUserUseCase_Register_WeakPassword(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{
+		PasswordPolicy: domain.PasswordPolicy{MinLength: 8, DisallowPersonalInfo: true},
+	})
+
+	_, err := userService.Register(context.Background(), "test@example.com", "Doe", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	expected := []string{"password:" + domain.PasswordRuleMinLength, "password:" + domain.PasswordRulePersonalInfo}
+	if violations := fieldViolations(t, err); strings.Join(violations, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the length and personal information rules to be broken, got %v", violations)
+	}
+	if len(userRepo.users) != 0 {
+		t.Error("Expected no user to be created")
+	}
+}
+
+func TestUserUseCase_Register_ReportsEveryInvalidField(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{
+		PasswordPolicy: domain.PasswordPolicy{MinLength: 8},
+	})
+
+	_, err := userService.Register(context.Background(), "test.example.com", "short", "John", "Doe", "12345", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	expected := []string{"email:" + domain.FieldRuleEmail, "password:" + domain.PasswordRuleMinLength, "phone:" + domain.FieldRulePhone}
+	if violations := fieldViolations(t, err); strings.Join(violations, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, violations)
+	}
+	if len(userRepo.users) != 0 {
+		t.Error("Expected no user to be created")
+	}
+}
+
+// fieldViolations returns the "field:rule" pairs of a *domain.ValidationError
+func fieldViolations(t *testing.T, err error) []string {
+	t.Helper()
+	invalid, ok := err.(*domain.ValidationError)
+	if !ok {
+		t.Fatalf("Expected *domain.ValidationError, got %v", err)
+	}
+	var violations []string
+	for _, violation := range invalid.Violations {
+		violations = append(violations, violation.Field+":"+violation.Rule)
+	}
+	return violations
+}
+
 func TestUserUseCase_Register_DuplicateEmail(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 	firstName := "John"
 	lastName := "Doe"
-	phone := "1234567890"
+	phone := "+14155552671"
 	birthday := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
 
 	// Register user first time
@@ -200,18 +428,98 @@ func TestUserUseCase_Register_DuplicateEmail(t *testing.T) {
 	}
 }
 
+func TestUserUseCase_Register_NormalizesEmail(t *testing.T) {
+	userService := NewUserUseCase(NewMockUserRepository(), NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+	birthday := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	user, err := userService.Register(ctx, "  Bob@Example.COM ", "password123", "Bob", "Doe", "+14155552671", birthday)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Email != "Bob@example.com" {
+		t.Errorf("Expected the normalized email Bob@example.com, got %q", user.Email)
+	}
+
+	if _, err := userService.Register(ctx, "bob@example.com", "password123", "Bob", "Doe", "+14155552671", birthday); err != domain.ErrUserAlreadyExists {
+		t.Errorf("Expected ErrUserAlreadyExists for the same address in another case, got %v", err)
+	}
+	_, err = userService.Register(ctx, "bob.example.com", "password123", "Bob", "Doe", "+14155552671", birthday)
+	if violations := fieldViolations(t, err); len(violations) != 1 || violations[0] != "email:"+domain.FieldRuleEmail {
+		t.Errorf("Expected the email address to be refused, got %v", violations)
+	}
+}
+
+func TestUserUseCase_Register_NormalizesPhone(t *testing.T) {
+	userService := NewUserUseCase(NewMockUserRepository(), NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{PhoneRegion: "TH"})
+	ctx := context.Background()
+	birthday := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	user, err := userService.Register(ctx, "bob@example.com", "password123", "Bob", "Doe", "081-234-5678", birthday)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Phone != "+66812345678" {
+		t.Errorf("Expected the number in the default region as +66812345678, got %q", user.Phone)
+	}
+
+	_, err = userService.Register(ctx, "alice@example.com", "password123", "Alice", "Doe", "12345", birthday)
+	if violations := fieldViolations(t, err); len(violations) != 1 || violations[0] != "phone:"+domain.FieldRulePhone {
+		t.Errorf("Expected the phone number to be refused, got %v", violations)
+	}
+}
+
+func TestUserUseCase_UpdateProfile_NewPhoneIsUnverified(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{PhoneRegion: "TH"})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	stored, _ := userRepo.GetByID(ctx, user.ID)
+	verifiedAt := time.Now()
+	stored.PhoneVerifiedAt = &verifiedAt
+
+	// The same number written differently is not a change
+	samePhone := "+1 415-555-2671"
+	updated, err := userService.UpdateProfile(ctx, user.ID, domain.UserUpdate{Phone: &samePhone})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated.Phone != "+14155552671" || !updated.IsPhoneVerified() {
+		t.Errorf("Expected the verified number to be kept, got %q verified at %v", updated.Phone, updated.PhoneVerifiedAt)
+	}
+
+	newPhone := "0812345678"
+	updated, err = userService.UpdateProfile(ctx, user.ID, domain.UserUpdate{Phone: &newPhone})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if updated.Phone != "+66812345678" || updated.IsPhoneVerified() {
+		t.Errorf("Expected the new number to be normalized and unverified, got %q verified at %v", updated.Phone, updated.PhoneVerifiedAt)
+	}
+
+	invalidPhone := "not a phone"
+	_, err = userService.UpdateProfile(ctx, user.ID, domain.UserUpdate{Phone: &invalidPhone})
+	if violations := fieldViolations(t, err); len(violations) != 1 || violations[0] != "phone:"+domain.FieldRulePhone {
+		t.Errorf("Expected the phone number to be refused, got %v", violations)
+	}
+}
+
 func TestUserUseCase_UpdateUser(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 
 	// Create a user first
-	_, err := userService.Register(ctx, email, password, "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := userService.Register(ctx, email, password, "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("Failed to register user: %v", err)
 	}
@@ -224,7 +532,7 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 
 	// Act - Update user
 	newBirthday := time.Date(1995, 5, 15, 0, 0, 0, 0, time.UTC)
-	updatedUser, err := userService.UpdateUser(ctx, user.ID, "Jane", "Smith", "0987654321", &newBirthday)
+	updatedUser, err := userService.UpdateUser(ctx, user.ID, "Jane", "Smith", "+66812345678", &newBirthday)
 
 	// Assert
 	if err != nil {
@@ -236,8 +544,8 @@ func TestUserUseCase_UpdateUser(t *testing.T) {
 	if updatedUser.LastName != "Smith" {
 		t.Fatalf("Expected last name Smith, got %s", updatedUser.LastName)
 	}
-	if updatedUser.Phone != "0987654321" {
-		t.Fatalf("Expected phone 0987654321, got %s", updatedUser.Phone)
+	if updatedUser.Phone != "+66812345678" {
+		t.Fatalf("Expected phone +66812345678, got %s", updatedUser.Phone)
 	}
 	if !updatedUser.Birthday.Equal(newBirthday) {
 		t.Fatalf("Expected birthday %v, got %v", newBirthday, updatedUser.Birthday)
@@ -251,12 +559,12 @@ func TestUserUseCase_UpdateUser_NotFound(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 
 	// Act - Try to update non-existent user
-	_, err := userService.UpdateUser(ctx, 999, "Jane", "Smith", "0987654321", nil)
+	_, err := userService.UpdateUser(ctx, 999, "Jane", "Smith", "+66812345678", nil)
 
 	// Assert
 	if err != domain.ErrUserNotFound {
@@ -268,14 +576,14 @@ func TestUserUseCase_UpdateUser_PartialUpdate(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 
 	// Create a user first
-	originalUser, err := userService.Register(ctx, email, password, "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	originalUser, err := userService.Register(ctx, email, password, "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("Failed to register user: %v", err)
 	}
@@ -294,8 +602,198 @@ func TestUserUseCase_UpdateUser_PartialUpdate(t *testing.T) {
 	if updatedUser.LastName != "Doe" {
 		t.Fatalf("Expected last name to remain Doe, got %s", updatedUser.LastName)
 	}
-	if updatedUser.Phone != "1234567890" {
-		t.Fatalf("Expected phone to remain 1234567890, got %s", updatedUser.Phone)
+	if updatedUser.Phone != "+14155552671" {
+		t.Fatalf("Expected phone to remain +14155552671, got %s", updatedUser.Phone)
+	}
+}
+
+func TestUserUseCase_UpdateProfile_ClearPhoneOrBirthday(t *testing.T) {
+	// Arrange
+	userRepo := NewMockUserRepository()
+	authService := NewMockAuthService()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
+
+	ctx := context.Background()
+	originalUser, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	// Act & Assert - neither can be cleared, and both are reported
+	emptyPhone := ""
+	zeroBirthday := time.Time{}
+	_, err = userService.UpdateProfile(ctx, originalUser.ID, domain.UserUpdate{Phone: &emptyPhone, Birthday: &zeroBirthday})
+	expected := []string{"phone:" + domain.FieldRulePhone, "birthday:" + domain.FieldRuleBirthday}
+	if violations := fieldViolations(t, err); strings.Join(violations, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, violations)
+	}
+
+	user, _ := userRepo.GetByID(ctx, originalUser.ID)
+	if user.Phone != "+14155552671" || user.Birthday.IsZero() {
+		t.Errorf("Expected the phone and birthday to be kept, got %s and %v", user.Phone, user.Birthday)
+	}
+}
+
+func TestUserUseCase_UpdateProfile_RequiredFieldCleared(t *testing.T) {
+	// Arrange
+	userRepo := NewMockUserRepository()
+	authService := NewMockAuthService()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
+
+	ctx := context.Background()
+	originalUser, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	// Act
+	emptyName := ""
+	_, err = userService.UpdateProfile(ctx, originalUser.ID, domain.UserUpdate{FirstName: &emptyName})
+
+	// Assert
+	if err != domain.ErrInvalidFirstName {
+		t.Fatalf("Expected ErrInvalidFirstName, got %v", err)
+	}
+}
+
+func TestUserUseCase_Login_RehashesOutdatedHash(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	if _, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	userRepo.users["test@example.com"].Password = "legacy_password123"
+
+	result, err := userService.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.User.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+	if stored := userRepo.users["test@example.com"].Password; stored != "hashed_password123" {
+		t.Errorf("Expected the outdated hash to be upgraded, got %q", stored)
+	}
+}
+
+func TestUserUseCase_Login_RehashKeepsConcurrentChanges(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	uc := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{}).(*UserUseCase)
+	ctx := context.Background()
+
+	if _, err := uc.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	stored := userRepo.users["test@example.com"]
+	stored.Password = "legacy_password123"
+	loggedIn := *stored
+
+	// The password and profile change while the login rehashes
+	stored.Password = "hashed_newpassword456"
+	stored.FirstName = "Jane"
+	uc.rehashPassword(ctx, &loggedIn, "password123")
+
+	if stored.Password != "hashed_newpassword456" || stored.FirstName != "Jane" {
+		t.Errorf("Expected the concurrent changes to be kept, got password %q and first name %q", stored.Password, stored.FirstName)
+	}
+}
+
+func TestUserUseCase_ChangePassword(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	refreshTokenRepo := NewMockRefreshTokenRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), refreshTokenRepo, NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	refreshToken := &domain.RefreshToken{UserID: user.ID, TokenHash: "refresh", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	result, err := userService.ChangePassword(ctx, user.ID, "password123", "newpassword456")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Token == "" {
+		t.Error("Expected a new access token")
+	}
+	if result.User.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+
+	stored := userRepo.users["test@example.com"]
+	if stored.Password != "hashed_newpassword456" {
+		t.Errorf("Expected password to be changed, got %q", stored.Password)
+	}
+	if stored.TokenVersion != 1 || result.User.TokenVersion != 1 {
+		t.Errorf("Expected the token version to be bumped to 1, got %d", stored.TokenVersion)
+	}
+	if refreshToken.RevokedAt == nil {
+		t.Error("Expected refresh tokens to be revoked")
+	}
+}
+
+// failingRevokeRefreshTokenRepository fails to revoke refresh tokens
+type failingRevokeRefreshTokenRepository struct {
+	*MockRefreshTokenRepository
+}
+
+func (r *failingRevokeRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int, revokedAt time.Time) error {
+	return errors.New("database is unavailable")
+}
+
+func TestUserUseCase_ChangePassword_RevokeFailureKeepsPassword(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	refreshTokenRepo := &failingRevokeRefreshTokenRepository{NewMockRefreshTokenRepository()}
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), refreshTokenRepo, NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	if _, err := userService.ChangePassword(ctx, user.ID, "password123", "newpassword456"); err == nil {
+		t.Fatal("Expected an error when refresh tokens cannot be revoked")
+	}
+	stored := userRepo.users["test@example.com"]
+	if stored.Password != "hashed_password123" || stored.TokenVersion != 0 {
+		t.Errorf("Expected the user to be unchanged, got password %q and token version %d", stored.Password, stored.TokenVersion)
+	}
+}
+
+func TestUserUseCase_ChangePassword_Errors(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{
+		PasswordPolicy: domain.PasswordPolicy{MinLength: 8},
+	})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	if _, err := userService.ChangePassword(ctx, user.ID, "wrongpassword", "newpassword456"); err != domain.ErrIncorrectPassword {
+		t.Errorf("Expected ErrIncorrectPassword, got %v", err)
+	}
+	if _, err := userService.ChangePassword(ctx, user.ID, "password123", "short"); err == nil {
+		t.Error("Expected a weak password to be rejected")
+	} else if _, ok := err.(*domain.PasswordPolicyError); !ok {
+		t.Errorf("Expected *domain.PasswordPolicyError, got %v", err)
+	}
+	if _, err := userService.ChangePassword(ctx, 999, "password123", "newpassword456"); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	stored := userRepo.users["test@example.com"]
+	if stored.Password != "hashed_password123" || stored.TokenVersion != 0 {
+		t.Errorf("Expected the user to be unchanged, got password %q and token version %d", stored.Password, stored.TokenVersion)
 	}
 }
 
@@ -303,20 +801,20 @@ func TestUserUseCase_Login_InvalidCredentials(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 
 	// Create a user first
-	_, err := userService.Register(ctx, email, password, "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := userService.Register(ctx, email, password, "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("Failed to register user: %v", err)
 	}
 
 	// Act - Try to login with wrong password
-	_, _, err = userService.Login(ctx, email, "wrongpassword")
+	_, err = userService.Login(ctx, email, "wrongpassword")
 
 	// Assert
 	if err != domain.ErrInvalidCredentials {
@@ -328,12 +826,12 @@ func TestUserUseCase_Login_UserNotFound(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 
 	// Act - Try to login with non-existent user
-	_, _, err := userService.Login(ctx, "nonexistent@example.com", "password")
+	_, err := userService.Login(ctx, "nonexistent@example.com", "password")
 
 	// Assert
 	if err != domain.ErrInvalidCredentials {
@@ -341,18 +839,120 @@ func TestUserUseCase_Login_UserNotFound(t *testing.T) {
 	}
 }
 
+// recordingPasswordHasher records the hashes passwords are compared with
+type recordingPasswordHasher struct {
+	*MockPasswordHasher
+	compared []string
+}
+
+func (m *recordingPasswordHasher) Compare(hash, password string) error {
+	m.compared = append(m.compared, hash)
+	return m.MockPasswordHasher.Compare(hash, password)
+}
+
+func TestUserUseCase_Login_ComparesPasswordForUnknownEmail(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	hasher := &recordingPasswordHasher{MockPasswordHasher: NewMockPasswordHasher()}
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), hasher, UserConfig{})
+	ctx := context.Background()
+
+	if _, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	// A wrong password and an unknown email each cost one password check,
+	// the latter against a hash made by the same hasher
+	for _, email := range []string{"test@example.com", "nonexistent@example.com"} {
+		if _, err := userService.Login(ctx, email, "wrongpassword"); err != domain.ErrInvalidCredentials {
+			t.Fatalf("Expected ErrInvalidCredentials for %s, got %v", email, err)
+		}
+	}
+	expected := []string{"hashed_password123", "hashed_" + dummyPassword}
+	if len(hasher.compared) != len(expected) || hasher.compared[0] != expected[0] || hasher.compared[1] != expected[1] {
+		t.Errorf("Expected the password to be compared with %v, got %v", expected, hasher.compared)
+	}
+}
+
+// slowPasswordHasher makes password comparisons take as long as a real
+// password hash would, so that login timing can be measured
+type slowPasswordHasher struct {
+	*MockPasswordHasher
+	cost time.Duration
+}
+
+func (m *slowPasswordHasher) Compare(hash, password string) error {
+	time.Sleep(m.cost)
+	return m.MockPasswordHasher.Compare(hash, password)
+}
+
+func TestUserUseCase_Login_TimingSafeForUnknownEmail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping login timing measurement in short mode")
+	}
+	const (
+		samples     = 41
+		compareCost = 5 * time.Millisecond
+	)
+	userRepo := NewMockUserRepository()
+	hasher := &slowPasswordHasher{MockPasswordHasher: NewMockPasswordHasher(), cost: compareCost}
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), hasher, UserConfig{})
+	ctx := context.Background()
+
+	if _, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	timeLogin := func(email string) time.Duration {
+		start := time.Now()
+		if _, err := userService.Login(ctx, email, "wrongpassword"); err != domain.ErrInvalidCredentials {
+			t.Fatalf("Expected ErrInvalidCredentials for %s, got %v", email, err)
+		}
+		return time.Since(start)
+	}
+
+	// Interleave the two paths so drift in machine load affects both alike
+	var known, unknown []time.Duration
+	for i := 0; i < samples; i++ {
+		known = append(known, timeLogin("test@example.com"))
+		unknown = append(unknown, timeLogin("nonexistent@example.com"))
+	}
+
+	// Compare the lower quartile and the median, which scheduler noise
+	// barely moves, with half a password check of tolerance
+	for _, q := range []float64{0.25, 0.5} {
+		knownQ, unknownQ := durationQuantile(known, q), durationQuantile(unknown, q)
+		if unknownQ < compareCost {
+			t.Fatalf("Expected a login for an unknown email to check a password, %v quantile was %v", q, unknownQ)
+		}
+		diff := knownQ - unknownQ
+		if diff < 0 {
+			diff = -diff
+		}
+		if tolerance := compareCost / 2; diff > tolerance {
+			t.Errorf("Expected %v quantiles within %v, got %v for a known email and %v for an unknown one", q, tolerance, knownQ, unknownQ)
+		}
+	}
+}
+
+// durationQuantile returns the q-quantile of durations by nearest rank
+func durationQuantile(durations []time.Duration, q float64) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
 func TestUserUseCase_GetUserProfile(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 	email := "test@example.com"
 	password := "password123"
 
 	// Create a user first
-	createdUser, err := userService.Register(ctx, email, password, "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	createdUser, err := userService.Register(ctx, email, password, "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("Failed to register user: %v", err)
 	}
@@ -375,13 +975,13 @@ func TestUserUseCase_GetUserProfile(t *testing.T) {
 func TestUserUseCase_Register_HashPasswordError(t *testing.T) {
 	// Arrange
 	userRepo := NewMockUserRepository()
-	authService := &MockAuthServiceWithError{hashError: true}
-	userService := NewUserUseCase(userRepo, authService)
+	hasher := &MockPasswordHasher{hashError: true}
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), hasher, UserConfig{})
 
 	ctx := context.Background()
 
 	// Act
-	_, err := userService.Register(ctx, "test@example.com", "password", "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := userService.Register(ctx, "test@example.com", "password", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 
 	// Assert
 	if err != domain.ErrPasswordHashError {
@@ -393,12 +993,12 @@ func TestUserUseCase_Register_CreateUserError(t *testing.T) {
 	// Arrange
 	userRepo := &MockUserRepositoryWithError{createError: true}
 	authService := NewMockAuthService()
-	userService := NewUserUseCase(userRepo, authService)
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), authService, NewMockPasswordHasher(), UserConfig{})
 
 	ctx := context.Background()
 
 	// Act
-	_, err := userService.Register(ctx, "test@example.com", "password", "John", "Doe", "1234567890", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err := userService.Register(ctx, "test@example.com", "password", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
 
 	// Assert
 	if err != domain.ErrUserCreationError {
@@ -408,13 +1008,11 @@ func TestUserUseCase_Register_CreateUserError(t *testing.T) {
 
 // Mock implementations with error scenarios
 type MockAuthServiceWithError struct {
-	hashError     bool
 	tokenError    bool
-	compareError  bool
 	validateError bool
 }
 
-func (m *MockAuthServiceWithError) GenerateToken(userID int, email string) (string, error) {
+func (m *MockAuthServiceWithError) GenerateToken(userID int, email string, role domain.Role, tokenVersion int) (string, error) {
 	if m.tokenError {
 		return "", domain.ErrTokenGenerationError
 	}
@@ -428,21 +1026,50 @@ func (m *MockAuthServiceWithError) ValidateToken(token string) (*domain.TokenCla
 	return &domain.TokenClaims{UserID: 1, Email: "test@example.com"}, nil
 }
 
-func (m *MockAuthServiceWithError) HashPassword(password string) (string, error) {
-	if m.hashError {
-		return "", domain.ErrPasswordHashError
+func (m *MockAuthServiceWithError) PublicKeys() []domain.JSONWebKey {
+	return nil
+}
+
+func (m *MockAuthServiceWithError) GenerateMFAChallenge(userID int) (string, error) {
+	if m.tokenError {
+		return "", domain.ErrTokenGenerationError
 	}
-	return "hashed_" + password, nil
+	return "mock_mfa_challenge", nil
 }
 
-func (m *MockAuthServiceWithError) ComparePassword(hashedPassword, password string) error {
-	if m.compareError {
-		return domain.ErrInvalidCredentials
+func (m *MockAuthServiceWithError) ValidateMFAChallenge(token string) (*domain.TokenClaims, error) {
+	if m.validateError {
+		return nil, domain.ErrInvalidMFAToken
 	}
-	if hashedPassword == "hashed_"+password {
-		return nil
+	return &domain.TokenClaims{UserID: 1, TokenID: token, ExpiresAt: time.Now().Add(5 * time.Minute)}, nil
+}
+
+func (m *MockAuthServiceWithError) GenerateEmailVerificationToken(userID int, email string) (string, error) {
+	if m.tokenError {
+		return "", domain.ErrTokenGenerationError
 	}
-	return domain.ErrInvalidCredentials
+	return "mock_verification_token", nil
+}
+
+func (m *MockAuthServiceWithError) ValidateEmailVerificationToken(token string) (int, string, error) {
+	if m.validateError {
+		return 0, "", domain.ErrInvalidVerificationToken
+	}
+	return 1, "test@example.com", nil
+}
+
+func (m *MockAuthServiceWithError) GenerateEmailRevertToken(userID int, email string) (string, error) {
+	if m.tokenError {
+		return "", domain.ErrTokenGenerationError
+	}
+	return "mock_revert_token", nil
+}
+
+func (m *MockAuthServiceWithError) ValidateEmailRevertToken(token string) (int, string, error) {
+	if m.validateError {
+		return 0, "", domain.ErrInvalidEmailChangeToken
+	}
+	return 1, "test@example.com", nil
 }
 
 type MockUserRepositoryWithError struct {
@@ -457,6 +1084,28 @@ func (m *MockUserRepositoryWithError) Create(ctx context.Context, user *domain.U
 	return nil
 }
 
+func (m *MockUserRepository) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	for _, user := range m.users {
+		if user.ID == id && user.DeletedAt == nil && user.Password == oldHash {
+			user.Password = newHash
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	for _, user := range m.users {
+		if user.ID == id && user.DeletedAt == nil {
+			user.Password = hash
+			user.UpdatedAt = updatedAt
+			user.TokenVersion++
+			return user.TokenVersion, nil
+		}
+	}
+	return 0, domain.ErrUserNotFound
+}
+
 func (m *MockUserRepositoryWithError) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	return nil, domain.ErrUserNotFound
 }
@@ -479,3 +1128,194 @@ func (m *MockUserRepositoryWithError) Exists(ctx context.Context, email string)
 	}
 	return false, nil
 }
+
+func (m *MockUserRepositoryWithError) List(ctx context.Context, query domain.UserListQuery) (*domain.UserPage, error) {
+	return &domain.UserPage{}, nil
+}
+
+func (m *MockUserRepositoryWithError) SoftDelete(ctx context.Context, id int, deletedAt time.Time) error {
+	return domain.ErrUserNotFound
+}
+
+func (m *MockUserRepositoryWithError) GetDeletedByID(ctx context.Context, id int) (*domain.User, error) {
+	return nil, domain.ErrUserNotFound
+}
+
+func (m *MockUserRepositoryWithError) Restore(ctx context.Context, id int) error {
+	return domain.ErrUserNotFound
+}
+
+func (m *MockUserRepositoryWithError) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *MockUserRepositoryWithError) RecordVerificationSent(ctx context.Context, id int, sentAt, notBefore time.Time) (bool, error) {
+	return false, nil
+}
+
+func (m *MockUserRepositoryWithError) ReplacePasswordHash(ctx context.Context, id int, oldHash, newHash string) (bool, error) {
+	return false, nil
+}
+
+func (m *MockUserRepositoryWithError) UpdatePassword(ctx context.Context, id int, hash string, updatedAt time.Time) (int, error) {
+	return 0, domain.ErrUserNotFound
+}
+
+func TestUserUseCase_SetRole(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	registered, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	user, err := userService.SetRole(ctx, registered.ID, domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Role != domain.RoleAdmin {
+		t.Errorf("Expected role %s, got %s", domain.RoleAdmin, user.Role)
+	}
+	if user.Password != "" {
+		t.Error("Expected password to be cleared from response")
+	}
+
+	stored, _ := userRepo.GetByID(ctx, registered.ID)
+	if stored.Role != domain.RoleAdmin {
+		t.Errorf("Expected stored role %s, got %s", domain.RoleAdmin, stored.Role)
+	}
+}
+
+func TestUserUseCase_SetRole_Errors(t *testing.T) {
+	userService := NewUserUseCase(NewMockUserRepository(), NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	if _, err := userService.SetRole(ctx, 1, domain.Role("root")); err != domain.ErrInvalidRole {
+		t.Errorf("Expected ErrInvalidRole, got %v", err)
+	}
+	if _, err := userService.SetRole(ctx, 999, domain.RoleAdmin); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUserUseCase_ListUsers(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+	ctx := context.Background()
+
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		if _, err := userService.Register(ctx, email, "password123", "John", "Doe", "+14155552671", time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+			t.Fatalf("Failed to register user: %v", err)
+		}
+	}
+
+	page, err := userService.ListUsers(ctx, domain.UserListQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if page.Total != 2 || len(page.Users) != 2 {
+		t.Fatalf("Expected 2 users, got %d (total %d)", len(page.Users), page.Total)
+	}
+	for _, user := range page.Users {
+		if user.Password != "" {
+			t.Error("Expected passwords to be cleared from the listing")
+		}
+	}
+
+	stored, _ := userRepo.GetByEmail(ctx, "a@example.com")
+	if stored.Password == "" {
+		t.Error("Expected stored user to keep its password hash")
+	}
+}
+
+func TestUserUseCase_ListUsers_InvalidQuery(t *testing.T) {
+	userService := NewUserUseCase(NewMockUserRepository(), NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{})
+
+	_, err := userService.ListUsers(context.Background(), domain.UserListQuery{SortBy: "email"})
+	if err != domain.ErrInvalidSortField {
+		t.Errorf("Expected ErrInvalidSortField, got %v", err)
+	}
+}
+
+func TestUserUseCase_DeleteAndRestoreAccount(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userService := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{DeletionGracePeriod: 24 * time.Hour})
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "test@example.com", "password123", "John", "Doe", "", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := userService.DeleteAccount(ctx, user.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := userService.DeleteAccount(ctx, user.ID); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound when deleting twice, got %v", err)
+	}
+
+	restored, err := userService.RestoreAccount(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Password != "" {
+		t.Errorf("Expected restored user without deletion mark or password, got %+v", restored)
+	}
+	if _, err := userService.RestoreAccount(ctx, user.ID); err != domain.ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound when restoring an active user, got %v", err)
+	}
+}
+
+func TestUserUseCase_RestoreAccount_GracePeriodExpired(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	uc := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{DeletionGracePeriod: 24 * time.Hour}).(*UserUseCase)
+	ctx := context.Background()
+
+	user, err := uc.Register(ctx, "test@example.com", "password123", "John", "Doe", "", time.Time{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	deletedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return deletedAt }
+	if err := uc.DeleteAccount(ctx, user.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	uc.now = func() time.Time { return deletedAt.Add(24 * time.Hour) }
+	if _, err := uc.RestoreAccount(ctx, user.ID); err != domain.ErrRestorePeriodExpired {
+		t.Errorf("Expected ErrRestorePeriodExpired, got %v", err)
+	}
+}
+
+func TestUserUseCase_PurgeDeletedUsers(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	uc := NewUserUseCase(userRepo, NewMockMFARepository(), NewMockRefreshTokenRepository(), NewMockAuthService(), NewMockPasswordHasher(), UserConfig{DeletionGracePeriod: 24 * time.Hour}).(*UserUseCase)
+	ctx := context.Background()
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	for i, deletedAgo := range []time.Duration{48 * time.Hour, time.Hour} {
+		user, err := uc.Register(ctx, fmt.Sprintf("user%d@example.com", i), "password123", "John", "Doe", "", time.Time{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		uc.now = func() time.Time { return now.Add(-deletedAgo) }
+		if err := uc.DeleteAccount(ctx, user.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	uc.now = func() time.Time { return now }
+	purged, err := uc.PurgeDeletedUsers(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("Expected 1 purged user, got %d", purged)
+	}
+	if _, err := userRepo.GetDeletedByID(ctx, 2); err != nil {
+		t.Errorf("Expected the recently deleted user to be kept, got %v", err)
+	}
+}
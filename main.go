@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"hello-world/internal/app"
 
@@ -30,22 +33,51 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	// "migrate" subcommand manages the database schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := app.RunMigrate(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+
+	// "keys" subcommand lists or rotates the token signing keys and exits
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := app.RunKeys(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal("Key management failed: ", err)
+		}
+		return
+	}
+
 	// Initialize dependency injection container
 	container, err := app.NewContainer()
 	if err != nil {
 		log.Fatal("Failed to initialize application:", err)
 	}
-	defer container.Close()
 
 	// Setup routes
 	r := container.Router.SetupRoutes()
 
+	// Stop on Ctrl+C or when the process manager asks us to
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start server
-	serverAddr := container.Config.Server.Host + ":" + container.Config.Server.Port
-	log.Printf("Server starting on %s", serverAddr)
+	server := app.NewHTTPServer(container.Config.Server, r)
+	log.Printf("Server starting on %s", server.Addr)
 	log.Printf("Swagger UI available at: http://localhost:%s/swagger/", container.Config.Server.Port)
 
-	if err := http.ListenAndServe(serverAddr, r); err != nil {
-		log.Fatal("Failed to start server:", err)
+	serverErr := app.RunServer(ctx, server, container.Config.Server.ShutdownTimeout)
+	if serverErr != nil {
+		log.Printf("Server error: %v", serverErr)
+	}
+
+	// Close the container only once no request can use it anymore
+	if err := container.Close(); err != nil {
+		log.Printf("Failed to close application: %v", err)
+	}
+	if serverErr != nil {
+		os.Exit(1)
 	}
+	log.Println("Server stopped")
 }
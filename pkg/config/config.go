@@ -1,50 +1,452 @@
 package config
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Deployment environments
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Email verification modes, set with EMAIL_VERIFICATION
+const (
+	// EmailVerificationNone lets unverified users do everything
+	EmailVerificationNone = "none"
+	// EmailVerificationLogin refuses logins until the email is verified
+	EmailVerificationLogin = "login"
+	// EmailVerificationRestrict lets unverified users log in, but only to
+	// view or delete their account, resend the verification email and
+	// change their email address
+	EmailVerificationRestrict = "restrict"
+)
+
+// DefaultJWTSecret is the signing secret used when JWT_SECRET is not set.
+// It is public, so it is only accepted in development.
+const DefaultJWTSecret = "your-secret-key"
+
+// MinJWTSecretLength is the minimum length in bytes of the JWT secret outside
+// development, matching the 256-bit output of HS256
+const MinJWTSecretLength = 32
+
+// KeyEncryptionKeyLength is the length in bytes of the decoded
+// JWT_KEY_ENCRYPTION_KEY, an AES-256 key
+const KeyEncryptionKeyLength = 32
+
+// Password hashing algorithms, set with PASSWORD_HASH_ALGORITHM
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// BcryptMaxPasswordLength is the longest PASSWORD_MAX_LENGTH allowed with
+// bcrypt, which ignores every byte of a password after the first 72
+const BcryptMaxPasswordLength = 72
+
 // Config holds the application configuration
 type Config struct {
+	// Env is the deployment environment, EnvDevelopment or EnvProduction
+	Env      string
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Account  AccountConfig
+	Login    LoginConfig
+	Password PasswordConfig
+	MFA      MFAConfig
+	Mail     MailConfig
+	Phone    PhoneConfig
+	SMS      SMSConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port              string
+	Host              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// after a shutdown signal
+	ShutdownTimeout time.Duration
+	// TrustedProxies are the IP addresses and CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers name the client
+	TrustedProxies []string
+}
+
+// ParseTrustedProxies returns TrustedProxies as prefixes; a single address
+// is a prefix covering only itself
+func (c ServerConfig) ParseTrustedProxies() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+	for _, proxy := range c.TrustedProxies {
+		if strings.Contains(proxy, "/") {
+			prefix, err := netip.ParsePrefix(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("SERVER_TRUSTED_PROXIES: invalid CIDR range %q", proxy)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("SERVER_TRUSTED_PROXIES: invalid IP address %q", proxy)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
 }
 
 // DatabaseConfig holds database-related configuration
 type DatabaseConfig struct {
-	Driver string
-	DSN    string
+	Driver      string
+	DSN         string
+	AutoMigrate bool
 }
 
 // JWTConfig holds JWT-related configuration
 type JWTConfig struct {
-	Secret string
+	Secret                  string
+	Issuer                  string
+	Audience                string
+	AccessTTL               time.Duration
+	ClockSkew               time.Duration
+	RefreshTTL              time.Duration
+	RevocationPruneInterval time.Duration
+
+	// PrivateKeyFile is a PEM encoded RSA or Ed25519 private key. When set,
+	// tokens are signed with it (RS256 or EdDSA) instead of the secret.
+	PrivateKeyFile string
+	// KeyReloadInterval is how often the signing key ring is reloaded, to
+	// pick up keys rotated by other instances and drop expired ones
+	KeyReloadInterval time.Duration
+	// KeyEncryptionKey is the base64 encoded AES-256 key that encrypts the
+	// private keys and secrets in the signing key ring stored in the database
+	KeyEncryptionKey string
+}
+
+// DecodeKeyEncryptionKey returns the decoded KeyEncryptionKey, or nil when
+// it is not set
+func (c JWTConfig) DecodeKeyEncryptionKey() ([]byte, error) {
+	if c.KeyEncryptionKey == "" {
+		return nil, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(c.KeyEncryptionKey)
+	if err != nil || len(key) != KeyEncryptionKeyLength {
+		return nil, fmt.Errorf("JWT_KEY_ENCRYPTION_KEY must be %d base64 encoded bytes", KeyEncryptionKeyLength)
+	}
+	return key, nil
+}
+
+// AccountConfig holds account lifecycle configuration
+type AccountConfig struct {
+	// DeletionGracePeriod is how long a deleted account can be restored
+	DeletionGracePeriod time.Duration
+	// PurgeInterval is how often accounts past the grace period are purged
+	PurgeInterval time.Duration
+	// PasswordResetTTL is how long an emailed password reset link can be used
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the page the reset link points to; the token is
+	// added as the "token" query parameter
+	PasswordResetURL string
+	// EmailVerification is what unverified users may do, one of the
+	// EmailVerification* modes
+	EmailVerification string
+	// EmailVerificationTTL is how long an emailed verification link works
+	EmailVerificationTTL time.Duration
+	// EmailVerificationURL is the page the verification link points to; the
+	// token is added as the "token" query parameter
+	EmailVerificationURL string
+	// EmailVerificationResendInterval is the minimum time between two
+	// verification emails to the same user
+	EmailVerificationResendInterval time.Duration
+	// EmailChangeURL is the page the link confirming a new email address
+	// points to; the token is added as the "token" query parameter
+	EmailChangeURL string
+	// EmailRevertURL is the page the link restoring a changed email address
+	// points to; the token is added as the "token" query parameter
+	EmailRevertURL string
+	// EmailRevertTTL is how long the link restoring a changed email address works
+	EmailRevertTTL time.Duration
+	// MinimumAge is how old users must be, by the birthday they register with
+	MinimumAge int
+}
+
+// LoginConfig holds the limits on failed logins
+type LoginConfig struct {
+	// MaxFailures is how many failed logins lock an account; 0 disables locking
+	MaxFailures int
+	// IPMaxFailures is how many failed logins lock a client IP address,
+	// across all accounts; 0 disables locking
+	IPMaxFailures int
+	// Lockout is how long the first lock lasts; it doubles with every
+	// further failure, up to MaxLockout
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// FailureWindow is how long failures are remembered after the last one
+	FailureWindow time.Duration
+}
+
+// PasswordConfig holds the password policy new passwords must follow
+type PasswordConfig struct {
+	// MinLength is the fewest characters a password may have
+	MinLength int
+	// MaxLength is the most bytes a password may have; 0 means no limit.
+	// bcrypt ignores anything past 72 bytes.
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// DisallowPersonalInfo rejects passwords containing the user's email or name
+	DisallowPersonalInfo bool
+	// BannedListFile lists breached or common passwords to refuse, one per line
+	BannedListFile string
+
+	// HashAlgorithm is PasswordHashArgon2id or PasswordHashBcrypt. Stored
+	// hashes made with another algorithm or cost are upgraded at login.
+	HashAlgorithm string
+	BcryptCost    int
+	// Argon2Memory is in KiB
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// MFAConfig holds two-factor authentication configuration
+type MFAConfig struct {
+	// Issuer names the service in authenticator apps
+	Issuer string
+	// ChallengeTTL is how long a user has to enter a code after the password
+	ChallengeTTL time.Duration
+	// ChallengeMaxFailures is how many wrong codes a login may get before
+	// the password must be entered again; 0 disables the limit. Wrong codes
+	// also lock the user's second factor after LOGIN_MAX_FAILURES.
+	ChallengeMaxFailures int
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	// Driver is "smtp" to send emails, or "log" to write them to LogFile
+	// (or the standard logger's output) for local development
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	LogFile      string
+}
+
+// PhoneConfig holds phone number normalization and verification configuration
+type PhoneConfig struct {
+	// DefaultRegion is the ISO 3166-1 alpha-2 region, such as "US", of phone
+	// numbers written without a country code; empty requires a country code
+	DefaultRegion string
+	// CodeTTL is how long a texted verification code works
+	CodeTTL time.Duration
+	// CodeMaxAttempts is how many times a code may be entered
+	CodeMaxAttempts int
+	// MaxSends is how many codes one phone number may be sent per SendWindow
+	MaxSends   int
+	SendWindow time.Duration
+}
+
+// SMSConfig holds outgoing text message configuration
+type SMSConfig struct {
+	// Driver is "webhook" to post text messages to WebhookURL, or "log" to
+	// write them to LogFile (or the standard logger's output) for local
+	// development
+	Driver       string
+	WebhookURL   string
+	WebhookToken string
+	LogFile      string
 }
 
 // Load loads configuration from environment variables or defaults
 func Load() *Config {
 	return &Config{
+		Env: getEnv("APP_ENV", EnvProduction),
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "3333"),
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:              getEnv("SERVER_PORT", "3333"),
+			Host:              getEnv("SERVER_HOST", "0.0.0.0"),
+			ReadTimeout:       getDurationEnv("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getDurationEnv("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      getDurationEnv("SERVER_WRITE_TIMEOUT", 15*time.Second),
+			IdleTimeout:       getDurationEnv("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:   getDurationEnv("SERVER_SHUTDOWN_TIMEOUT", 15*time.Second),
+			TrustedProxies:    getListEnv("SERVER_TRUSTED_PROXIES"),
 		},
 		Database: DatabaseConfig{
-			Driver: getEnv("DB_DRIVER", "sqlite3"),
-			DSN:    getEnv("DB_DSN", "./app.db"),
+			Driver:      getEnv("DB_DRIVER", "sqlite3"),
+			DSN:         getEnv("DB_DSN", "./app.db"),
+			AutoMigrate: getBoolEnv("DB_AUTO_MIGRATE", true),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-secret-key"),
+			Secret:                  getEnv("JWT_SECRET", DefaultJWTSecret),
+			PrivateKeyFile:          getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			Issuer:                  getEnv("JWT_ISSUER", ""),
+			Audience:                getEnv("JWT_AUDIENCE", ""),
+			AccessTTL:               getDurationEnv("JWT_ACCESS_TTL", 24*time.Hour),
+			ClockSkew:               getDurationEnv("JWT_CLOCK_SKEW", 30*time.Second),
+			RefreshTTL:              getDurationEnv("JWT_REFRESH_TTL", 30*24*time.Hour),
+			RevocationPruneInterval: getDurationEnv("JWT_REVOCATION_PRUNE_INTERVAL", time.Minute),
+			KeyReloadInterval:       getDurationEnv("JWT_KEY_RELOAD_INTERVAL", time.Minute),
+			KeyEncryptionKey:        getEnv("JWT_KEY_ENCRYPTION_KEY", ""),
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod:             getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			PurgeInterval:                   getDurationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour),
+			PasswordResetTTL:                getDurationEnv("PASSWORD_RESET_TTL", time.Hour),
+			PasswordResetURL:                getEnv("PASSWORD_RESET_URL", "http://localhost:3333/reset-password"),
+			EmailVerification:               getEnv("EMAIL_VERIFICATION", EmailVerificationNone),
+			EmailVerificationTTL:            getDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			EmailVerificationURL:            getEnv("EMAIL_VERIFICATION_URL", "http://localhost:3333/verify-email"),
+			EmailVerificationResendInterval: getDurationEnv("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute),
+			EmailChangeURL:                  getEnv("EMAIL_CHANGE_URL", "http://localhost:3333/email/confirm"),
+			EmailRevertURL:                  getEnv("EMAIL_REVERT_URL", "http://localhost:3333/email/revert"),
+			EmailRevertTTL:                  getDurationEnv("EMAIL_REVERT_TTL", 7*24*time.Hour),
+			MinimumAge:                      getIntEnv("ACCOUNT_MINIMUM_AGE", 13),
+		},
+		Login: LoginConfig{
+			MaxFailures:   getIntEnv("LOGIN_MAX_FAILURES", 5),
+			IPMaxFailures: getIntEnv("LOGIN_IP_MAX_FAILURES", 50),
+			Lockout:       getDurationEnv("LOGIN_LOCKOUT", time.Minute),
+			MaxLockout:    getDurationEnv("LOGIN_MAX_LOCKOUT", time.Hour),
+			FailureWindow: getDurationEnv("LOGIN_FAILURE_WINDOW", 24*time.Hour),
+		},
+		Password: PasswordConfig{
+			MinLength:            getIntEnv("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:            getIntEnv("PASSWORD_MAX_LENGTH", 72),
+			RequireUppercase:     getBoolEnv("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireLowercase:     getBoolEnv("PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireDigit:         getBoolEnv("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:        getBoolEnv("PASSWORD_REQUIRE_SYMBOL", false),
+			DisallowPersonalInfo: getBoolEnv("PASSWORD_DISALLOW_PERSONAL_INFO", true),
+			BannedListFile:       getEnv("PASSWORD_BANNED_LIST_FILE", ""),
+			HashAlgorithm:        getEnv("PASSWORD_HASH_ALGORITHM", PasswordHashArgon2id),
+			BcryptCost:           getIntEnv("PASSWORD_BCRYPT_COST", 10),
+			Argon2Memory:         getIntEnv("PASSWORD_ARGON2_MEMORY", 19*1024),
+			Argon2Iterations:     getIntEnv("PASSWORD_ARGON2_ITERATIONS", 2),
+			Argon2Parallelism:    getIntEnv("PASSWORD_ARGON2_PARALLELISM", 1),
+		},
+		MFA: MFAConfig{
+			Issuer:               getEnv("MFA_ISSUER", "Go Chi API"),
+			ChallengeTTL:         getDurationEnv("MFA_CHALLENGE_TTL", 5*time.Minute),
+			ChallengeMaxFailures: getIntEnv("MFA_CHALLENGE_MAX_FAILURES", 3),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			LogFile:      getEnv("MAIL_LOG_FILE", ""),
+		},
+		Phone: PhoneConfig{
+			DefaultRegion:   getEnv("PHONE_DEFAULT_REGION", "US"),
+			CodeTTL:         getDurationEnv("PHONE_CODE_TTL", 10*time.Minute),
+			CodeMaxAttempts: getIntEnv("PHONE_CODE_MAX_ATTEMPTS", 5),
+			MaxSends:        getIntEnv("PHONE_CODE_MAX_SENDS", 3),
+			SendWindow:      getDurationEnv("PHONE_CODE_SEND_WINDOW", time.Hour),
+		},
+		SMS: SMSConfig{
+			Driver:       getEnv("SMS_DRIVER", "log"),
+			WebhookURL:   getEnv("SMS_WEBHOOK_URL", ""),
+			WebhookToken: getEnv("SMS_WEBHOOK_TOKEN", ""),
+			LogFile:      getEnv("SMS_LOG_FILE", ""),
 		},
 	}
 }
 
+// IsDevelopment reports whether the application runs in development mode
+func (c *Config) IsDevelopment() bool {
+	return c.Env == EnvDevelopment
+}
+
+// Validate rejects configurations that are unsafe or impossible to run
+// with. Development mode may use the default JWT secret, store signing keys
+// unencrypted and log emails and text messages instead of sending them; the
+// secret is unused when tokens are signed with a private key.
+func (c *Config) Validate() error {
+	if _, err := c.Server.ParseTrustedProxies(); err != nil {
+		return err
+	}
+	if c.Mail.Driver == "smtp" && c.Mail.SMTPHost == "" {
+		return errors.New("SMTP_HOST must be set when MAIL_DRIVER=smtp")
+	}
+	if c.SMS.Driver == "webhook" && c.SMS.WebhookURL == "" {
+		return errors.New("SMS_WEBHOOK_URL must be set when SMS_DRIVER=webhook")
+	}
+	if !c.IsDevelopment() && c.Mail.Driver != "smtp" {
+		return errors.New("MAIL_DRIVER must be set to smtp outside development (set APP_ENV=development to log emails)")
+	}
+	if !c.IsDevelopment() && c.SMS.Driver != "webhook" {
+		return errors.New("SMS_DRIVER must be set to webhook outside development (set APP_ENV=development to log text messages)")
+	}
+	switch c.Account.EmailVerification {
+	case "", EmailVerificationNone, EmailVerificationLogin, EmailVerificationRestrict:
+	default:
+		return fmt.Errorf("EMAIL_VERIFICATION must be %q, %q or %q", EmailVerificationNone, EmailVerificationLogin, EmailVerificationRestrict)
+	}
+	if c.Account.MinimumAge < 0 {
+		return errors.New("ACCOUNT_MINIMUM_AGE must not be negative")
+	}
+	if (c.Login.MaxFailures > 0 || c.Login.IPMaxFailures > 0) && (c.Login.Lockout <= 0 || c.Login.MaxLockout < c.Login.Lockout) {
+		return errors.New("LOGIN_LOCKOUT must be positive and at most LOGIN_MAX_LOCKOUT")
+	}
+	if c.MFA.ChallengeMaxFailures < 0 {
+		return errors.New("MFA_CHALLENGE_MAX_FAILURES must not be negative")
+	}
+	switch c.Password.HashAlgorithm {
+	case "":
+	case PasswordHashBcrypt:
+		if c.Password.MaxLength <= 0 || c.Password.MaxLength > BcryptMaxPasswordLength {
+			return fmt.Errorf("PASSWORD_MAX_LENGTH must be between 1 and %d with bcrypt", BcryptMaxPasswordLength)
+		}
+	case PasswordHashArgon2id:
+		if c.Password.Argon2Memory <= 0 || c.Password.Argon2Iterations <= 0 || c.Password.Argon2Parallelism <= 0 || c.Password.Argon2Parallelism > 255 {
+			return errors.New("PASSWORD_ARGON2_MEMORY and PASSWORD_ARGON2_ITERATIONS must be positive, and PASSWORD_ARGON2_PARALLELISM between 1 and 255")
+		}
+	default:
+		return fmt.Errorf("PASSWORD_HASH_ALGORITHM must be %q or %q", PasswordHashArgon2id, PasswordHashBcrypt)
+	}
+	if c.Phone.CodeTTL < 0 || c.Phone.CodeMaxAttempts < 0 || c.Phone.MaxSends < 0 || c.Phone.SendWindow < 0 {
+		return errors.New("PHONE_CODE_TTL, PHONE_CODE_MAX_ATTEMPTS, PHONE_CODE_MAX_SENDS and PHONE_CODE_SEND_WINDOW must not be negative")
+	}
+	if c.Password.MaxLength < 0 {
+		return errors.New("PASSWORD_MAX_LENGTH must not be negative")
+	}
+	if c.Password.MaxLength > 0 && c.Password.MinLength > c.Password.MaxLength {
+		return errors.New("PASSWORD_MIN_LENGTH must be at most PASSWORD_MAX_LENGTH")
+	}
+	if _, err := c.JWT.DecodeKeyEncryptionKey(); err != nil {
+		return err
+	}
+	if !c.IsDevelopment() && c.Database.Driver != "memory" && c.JWT.KeyEncryptionKey == "" {
+		return errors.New("JWT_KEY_ENCRYPTION_KEY must be set outside development to encrypt the stored signing keys")
+	}
+	if c.IsDevelopment() || c.JWT.PrivateKeyFile != "" {
+		return nil
+	}
+	if c.JWT.Secret == DefaultJWTSecret {
+		return errors.New("JWT_SECRET must be set outside development (set APP_ENV=development to use the default)")
+	}
+	if len(c.JWT.Secret) < MinJWTSecretLength {
+		return fmt.Errorf("JWT_SECRET must be at least %d bytes long", MinJWTSecretLength)
+	}
+	return nil
+}
+
 // getEnv gets an environment variable with a fallback default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -52,3 +454,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getListEnv gets a comma-separated list environment variable, skipping
+// blank entries
+func getListEnv(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getDurationEnv gets a duration environment variable (e.g. "720h") with a fallback default value
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getIntEnv gets an integer environment variable with a fallback default value
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+// getBoolEnv gets a boolean environment variable (e.g. "true", "0") with a fallback default value
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
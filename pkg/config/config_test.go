@@ -1,8 +1,10 @@
 package config
 
 import (
+	"encoding/base64"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -22,6 +24,14 @@ func TestLoad(t *testing.T) {
 		t.Errorf("Expected default port 3333, got %s", config.Server.Port)
 	}
 
+	if config.Server.ReadTimeout != 15*time.Second || config.Server.WriteTimeout != 15*time.Second {
+		t.Errorf("Expected default read and write timeouts of 15s, got %v and %v", config.Server.ReadTimeout, config.Server.WriteTimeout)
+	}
+
+	if config.Server.ShutdownTimeout != 15*time.Second {
+		t.Errorf("Expected default shutdown timeout 15s, got %v", config.Server.ShutdownTimeout)
+	}
+
 	if config.Database.Driver != "sqlite3" {
 		t.Errorf("Expected default driver sqlite3, got %s", config.Database.Driver)
 	}
@@ -29,6 +39,50 @@ func TestLoad(t *testing.T) {
 	if config.Database.DSN != "./app.db" {
 		t.Errorf("Expected default DSN ./app.db, got %s", config.Database.DSN)
 	}
+
+	if config.Env != EnvProduction || config.IsDevelopment() {
+		t.Errorf("Expected default environment %s, got %s", EnvProduction, config.Env)
+	}
+
+	if config.JWT.AccessTTL != 24*time.Hour {
+		t.Errorf("Expected default access TTL 24h, got %v", config.JWT.AccessTTL)
+	}
+
+	if config.JWT.KeyReloadInterval != time.Minute {
+		t.Errorf("Expected default key reload interval 1m, got %v", config.JWT.KeyReloadInterval)
+	}
+
+	if config.Account.DeletionGracePeriod != 30*24*time.Hour {
+		t.Errorf("Expected default deletion grace period 720h, got %v", config.Account.DeletionGracePeriod)
+	}
+
+	if config.Account.PurgeInterval != time.Hour {
+		t.Errorf("Expected default purge interval 1h, got %v", config.Account.PurgeInterval)
+	}
+
+	if config.MFA.Issuer != "Go Chi API" || config.MFA.ChallengeTTL != 5*time.Minute || config.MFA.ChallengeMaxFailures != 3 {
+		t.Errorf("Expected default MFA issuer Go Chi API, challenge TTL 5m and 3 challenge max failures, got %q, %v and %d", config.MFA.Issuer, config.MFA.ChallengeTTL, config.MFA.ChallengeMaxFailures)
+	}
+
+	if config.Account.PasswordResetTTL != time.Hour {
+		t.Errorf("Expected default password reset TTL 1h, got %v", config.Account.PasswordResetTTL)
+	}
+
+	if config.Mail.Driver != "log" {
+		t.Errorf("Expected default mail driver log, got %s", config.Mail.Driver)
+	}
+
+	if config.Account.EmailVerification != EmailVerificationNone || config.Account.EmailVerificationResendInterval != time.Minute {
+		t.Errorf("Expected default email verification none with a 1m resend interval, got %q and %v", config.Account.EmailVerification, config.Account.EmailVerificationResendInterval)
+	}
+
+	if config.Account.EmailRevertTTL != 7*24*time.Hour {
+		t.Errorf("Expected default email revert TTL 168h, got %v", config.Account.EmailRevertTTL)
+	}
+
+	if config.Account.MinimumAge != 13 {
+		t.Errorf("Expected default minimum age 13, got %d", config.Account.MinimumAge)
+	}
 }
 
 func TestLoad_WithEnvironmentVariables(t *testing.T) {
@@ -168,3 +222,334 @@ func TestLoad_EmptyEnvironmentVariables(t *testing.T) {
 		t.Errorf("Expected default DSN ./app.db, got %s", config.Database.DSN)
 	}
 }
+
+func TestLoad_RefreshTTL(t *testing.T) {
+	originalTTL := os.Getenv("JWT_REFRESH_TTL")
+	defer os.Setenv("JWT_REFRESH_TTL", originalTTL)
+
+	os.Setenv("JWT_REFRESH_TTL", "")
+	if ttl := Load().JWT.RefreshTTL; ttl != 30*24*time.Hour {
+		t.Errorf("Expected default refresh TTL 720h, got %s", ttl)
+	}
+
+	os.Setenv("JWT_REFRESH_TTL", "48h")
+	if ttl := Load().JWT.RefreshTTL; ttl != 48*time.Hour {
+		t.Errorf("Expected refresh TTL 48h from env, got %s", ttl)
+	}
+
+	os.Setenv("JWT_REFRESH_TTL", "not-a-duration")
+	if ttl := Load().JWT.RefreshTTL; ttl != 30*24*time.Hour {
+		t.Errorf("Expected invalid refresh TTL to fall back to 720h, got %s", ttl)
+	}
+}
+
+func TestLoad_AutoMigrate(t *testing.T) {
+	original := os.Getenv("DB_AUTO_MIGRATE")
+	defer os.Setenv("DB_AUTO_MIGRATE", original)
+
+	os.Setenv("DB_AUTO_MIGRATE", "")
+	if !Load().Database.AutoMigrate {
+		t.Error("Expected migrations to run on startup by default")
+	}
+
+	os.Setenv("DB_AUTO_MIGRATE", "false")
+	if Load().Database.AutoMigrate {
+		t.Error("Expected DB_AUTO_MIGRATE=false to disable migrations on startup")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		secret  string
+		isValid bool
+	}{
+		{name: "Default secret in development", env: EnvDevelopment, secret: DefaultJWTSecret, isValid: true},
+		{name: "Default secret in production", env: EnvProduction, secret: DefaultJWTSecret, isValid: false},
+		{name: "Short secret in production", env: EnvProduction, secret: "too-short-secret", isValid: false},
+		{name: "Short secret with unknown environment", env: "staging", secret: "too-short-secret", isValid: false},
+		{name: "Long secret in production", env: EnvProduction, secret: "a-production-secret-of-32-bytes!", isValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Env: tt.env, JWT: JWTConfig{Secret: tt.secret, KeyEncryptionKey: testKeyEncryptionKey}, Mail: testMailConfig, SMS: testSMSConfig}
+			err := config.Validate()
+			if tt.isValid && err != nil {
+				t.Errorf("Expected config to be valid, got %v", err)
+			}
+			if !tt.isValid && err == nil {
+				t.Error("Expected config to be rejected")
+			}
+		})
+	}
+}
+
+// testKeyEncryptionKey is a valid JWT_KEY_ENCRYPTION_KEY
+var testKeyEncryptionKey = base64.StdEncoding.EncodeToString(make([]byte, KeyEncryptionKeyLength))
+
+// testMailConfig and testSMSConfig deliver messages, as required outside development
+var (
+	testMailConfig = MailConfig{Driver: "smtp", SMTPHost: "smtp.example.com"}
+	testSMSConfig  = SMSConfig{Driver: "webhook", WebhookURL: "https://sms.example.com/send"}
+)
+
+func TestConfig_Validate_KeyEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		driver  string
+		key     string
+		isValid bool
+	}{
+		{name: "Missing in development", env: EnvDevelopment, driver: "sqlite3", isValid: true},
+		{name: "Missing in production", env: EnvProduction, driver: "sqlite3", isValid: false},
+		{name: "Missing with the memory driver", env: EnvProduction, driver: "memory", isValid: true},
+		{name: "Set in production", env: EnvProduction, driver: "sqlite3", key: testKeyEncryptionKey, isValid: true},
+		{name: "Not base64", env: EnvDevelopment, driver: "sqlite3", key: "not base64!", isValid: false},
+		{name: "Too short", env: EnvDevelopment, driver: "sqlite3", key: base64.StdEncoding.EncodeToString(make([]byte, 16)), isValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Env:      tt.env,
+				Database: DatabaseConfig{Driver: tt.driver},
+				JWT:      JWTConfig{Secret: "a-production-secret-of-32-bytes!", KeyEncryptionKey: tt.key},
+				Mail:     testMailConfig,
+				SMS:      testSMSConfig,
+			}
+			err := config.Validate()
+			if tt.isValid && err != nil {
+				t.Errorf("Expected config to be valid, got %v", err)
+			}
+			if !tt.isValid && err == nil {
+				t.Error("Expected config to be rejected")
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_Mail(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, Mail: MailConfig{Driver: "smtp"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected MAIL_DRIVER=smtp without SMTP_HOST to be rejected")
+	}
+
+	config.Mail.SMTPHost = "smtp.example.com"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1,,2001:db8::/32")
+
+	proxies, err := Load().Server.ParseTrustedProxies()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"}
+	if len(proxies) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, proxies)
+	}
+	for i, proxy := range proxies {
+		if proxy.String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], proxy)
+		}
+	}
+}
+
+func TestConfig_Validate_TrustedProxies(t *testing.T) {
+	for _, proxy := range []string{"10.0.0.0/33", "proxy.example.com"} {
+		config := &Config{Env: EnvDevelopment, Server: ServerConfig{TrustedProxies: []string{proxy}}}
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected trusted proxy %q to be rejected", proxy)
+		}
+	}
+}
+
+func TestConfig_Validate_SMS(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, SMS: SMSConfig{Driver: "webhook"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected SMS_DRIVER=webhook without SMS_WEBHOOK_URL to be rejected")
+	}
+
+	config.SMS.WebhookURL = "https://sms.example.com/send"
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+}
+
+func TestConfig_Validate_DeliveryDrivers(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		mail    MailConfig
+		sms     SMSConfig
+		isValid bool
+	}{
+		{name: "Log drivers in development", env: EnvDevelopment, mail: MailConfig{Driver: "log"}, sms: SMSConfig{Driver: "log"}, isValid: true},
+		{name: "Log mail driver in production", env: EnvProduction, mail: MailConfig{Driver: "log"}, sms: testSMSConfig, isValid: false},
+		{name: "Log SMS driver in production", env: EnvProduction, mail: testMailConfig, sms: SMSConfig{Driver: "log"}, isValid: false},
+		{name: "No drivers in production", env: EnvProduction, isValid: false},
+		{name: "Real drivers in production", env: EnvProduction, mail: testMailConfig, sms: testSMSConfig, isValid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Env:  tt.env,
+				JWT:  JWTConfig{Secret: "a-production-secret-of-32-bytes!", KeyEncryptionKey: testKeyEncryptionKey},
+				Mail: tt.mail,
+				SMS:  tt.sms,
+			}
+			err := config.Validate()
+			if tt.isValid && err != nil {
+				t.Errorf("Expected config to be valid, got %v", err)
+			}
+			if !tt.isValid && err == nil {
+				t.Error("Expected config to be rejected")
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_EmailVerification(t *testing.T) {
+	for _, mode := range []string{EmailVerificationNone, EmailVerificationLogin, EmailVerificationRestrict} {
+		config := &Config{Env: EnvDevelopment, Account: AccountConfig{EmailVerification: mode}}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected EMAIL_VERIFICATION=%s to be valid, got %v", mode, err)
+		}
+	}
+
+	config := &Config{Env: EnvDevelopment, Account: AccountConfig{EmailVerification: "always"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unknown EMAIL_VERIFICATION mode to be rejected")
+	}
+}
+
+func TestConfig_Validate_MinimumAge(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, Account: AccountConfig{MinimumAge: -1}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a negative ACCOUNT_MINIMUM_AGE to be rejected")
+	}
+}
+
+func TestConfig_Validate_Login(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, Login: LoginConfig{MaxFailures: 5, Lockout: time.Hour, MaxLockout: time.Minute}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a first lockout longer than the maximum to be rejected")
+	}
+
+	config.Login.MaxLockout = time.Hour
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+
+	// Without limits the lockout is unused
+	config.Login = LoginConfig{}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config without login limits to be valid, got %v", err)
+	}
+}
+
+func TestLoad_Login(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES", "3")
+	t.Setenv("LOGIN_IP_MAX_FAILURES", "not-a-number")
+
+	config := Load()
+	if config.Login.MaxFailures != 3 {
+		t.Errorf("Expected 3 max failures, got %d", config.Login.MaxFailures)
+	}
+	if config.Login.IPMaxFailures != 50 {
+		t.Errorf("Expected the default of 50 IP max failures for an invalid value, got %d", config.Login.IPMaxFailures)
+	}
+	if config.Login.Lockout != time.Minute || config.Login.MaxLockout != time.Hour || config.Login.FailureWindow != 24*time.Hour {
+		t.Errorf("Expected default lockout 1m, max lockout 1h and window 24h, got %+v", config.Login)
+	}
+}
+
+func TestConfig_Validate_Phone(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, Phone: PhoneConfig{CodeTTL: 10 * time.Minute, CodeMaxAttempts: 5, MaxSends: 3, SendWindow: time.Hour}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+
+	config.Phone.SendWindow = -time.Hour
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a negative PHONE_CODE_SEND_WINDOW to be rejected")
+	}
+}
+
+func TestLoad_Phone(t *testing.T) {
+	t.Setenv("PHONE_DEFAULT_REGION", "TH")
+	t.Setenv("PHONE_CODE_MAX_SENDS", "not-a-number")
+
+	config := Load()
+	if config.Phone.DefaultRegion != "TH" {
+		t.Errorf("Expected default region TH, got %q", config.Phone.DefaultRegion)
+	}
+	if config.Phone.MaxSends != 3 || config.Phone.SendWindow != time.Hour {
+		t.Errorf("Expected the default of 3 sends per hour for an invalid value, got %+v", config.Phone)
+	}
+	if config.Phone.CodeTTL != 10*time.Minute || config.Phone.CodeMaxAttempts != 5 {
+		t.Errorf("Expected codes to last 10m and allow 5 attempts by default, got %+v", config.Phone)
+	}
+	if config.SMS.Driver != "log" {
+		t.Errorf("Expected the log SMS driver by default, got %q", config.SMS.Driver)
+	}
+}
+
+func TestConfig_Validate_Password(t *testing.T) {
+	config := &Config{Env: EnvDevelopment, Password: PasswordConfig{MinLength: 8, MaxLength: 100, HashAlgorithm: PasswordHashBcrypt}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a maximum length past what bcrypt hashes to be rejected")
+	}
+
+	// argon2id hashes passwords of any length
+	config.Password = PasswordConfig{MinLength: 8, MaxLength: 100, HashAlgorithm: PasswordHashArgon2id, Argon2Memory: 19 * 1024, Argon2Iterations: 2, Argon2Parallelism: 1}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+
+	config.Password.MaxLength = 6
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a minimum length above the maximum to be rejected")
+	}
+
+	config.Password.MaxLength = 72
+	config.Password.Argon2Iterations = 0
+	if err := config.Validate(); err == nil {
+		t.Error("Expected argon2id without iterations to be rejected")
+	}
+
+	config.Password.HashAlgorithm = "md5"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unsupported hash algorithm to be rejected")
+	}
+}
+
+func TestLoad_Password(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REQUIRE_SYMBOL", "true")
+	t.Setenv("PASSWORD_BANNED_LIST_FILE", "/etc/passwords.txt")
+
+	config := Load()
+	if config.Password.MinLength != 12 || config.Password.MaxLength != 72 {
+		t.Errorf("Expected lengths 12 to 72, got %d to %d", config.Password.MinLength, config.Password.MaxLength)
+	}
+	if !config.Password.RequireSymbol || config.Password.RequireDigit {
+		t.Errorf("Expected only symbols to be required, got %+v", config.Password)
+	}
+	if !config.Password.DisallowPersonalInfo {
+		t.Error("Expected personal information to be disallowed by default")
+	}
+	if config.Password.BannedListFile != "/etc/passwords.txt" {
+		t.Errorf("Expected banned list file to be loaded, got %q", config.Password.BannedListFile)
+	}
+	if config.Password.HashAlgorithm != PasswordHashArgon2id || config.Password.Argon2Memory != 19*1024 || config.Password.BcryptCost != 10 {
+		t.Errorf("Expected argon2id with 19 MiB and a bcrypt cost of 10 by default, got %+v", config.Password)
+	}
+}
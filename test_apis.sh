@@ -15,7 +15,7 @@ TEST_EMAIL="test@example.com"
 TEST_PASSWORD="password123"
 TEST_FIRSTNAME="John"
 TEST_LASTNAME="Doe"
-TEST_PHONE="0812345678"
+TEST_PHONE="+66812345678"
 TEST_BIRTHDAY="1990-01-01"
 
 # JWT Token variable
@@ -57,7 +57,7 @@ echo -e "${BLUE}Base URL: $BASE_URL${NC}\n"
 echo -e "${YELLOW}Checking if server is running...${NC}"
 if ! curl -s "$BASE_URL" > /dev/null; then
     echo -e "${RED}❌ Server is not running at $BASE_URL${NC}"
-    echo -e "${YELLOW}Please start the server with: go run .${NC}"
+    echo -e "${YELLOW}Please start the server with: APP_ENV=development go run .${NC}"
     exit 1
 fi
 echo -e "${GREEN}✅ Server is running${NC}\n"